@@ -3,6 +3,7 @@ package main
 import (
 	_ "github.com/go-sql-driver/mysql"
 	_ "github.com/lib/pq"
+	_ "modernc.org/sqlite"
 )
 
 func main() {
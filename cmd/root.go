@@ -1,17 +1,19 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
 
 	"github.com/AbdelilahOu/DBMcp/internal/config"
 	"github.com/AbdelilahOu/DBMcp/internal/server"
+	"github.com/AbdelilahOu/DBMcp/internal/tools"
 	"github.com/spf13/cobra"
 )
 
 var rootCmd = &cobra.Command{
 	Use:   "db-mcp-server",
-	Short: "DB MCP Server for querying Postgres/MySQL",
+	Short: "DB MCP Server for querying Postgres/MySQL/SQLite",
 	Long:  `A Model Context Protocol (MCP) server exposing DB tools for AI clients.`,
 }
 
@@ -25,6 +27,7 @@ func Execute() {
 func init() {
 	rootCmd.PersistentFlags().StringP("connection", "n", "", "Named connection from config file")
 	rootCmd.PersistentFlags().StringP("config", "c", "", "connections config file path")
+	rootCmd.PersistentFlags().Bool("read-only", false, "Refuse all writes on every connection")
 
 	stdioCmd := &cobra.Command{
 		Use:   "stdio",
@@ -32,27 +35,144 @@ func init() {
 		RunE:  runStdioServer,
 	}
 	rootCmd.AddCommand(stdioCmd)
+
+	httpCmd := &cobra.Command{
+		Use:   "http",
+		Short: "Run over the MCP streamable HTTP transport (for shared, networked deployments)",
+		RunE:  runHTTPServer,
+	}
+	httpCmd.Flags().StringP("addr", "a", ":8080", "Address to listen on")
+	httpCmd.Flags().String("base-path", "/mcp", "URL path the MCP endpoint is served under")
+	httpCmd.Flags().String("tls-cert", "", "TLS certificate file (overrides tls.cert_file)")
+	httpCmd.Flags().String("tls-key", "", "TLS private key file (overrides tls.key_file)")
+	rootCmd.AddCommand(httpCmd)
+
+	diffCmd := &cobra.Command{
+		Use:   "diff",
+		Short: "Compare the schemas of two configured connections",
+		RunE:  runDiff,
+	}
+	diffCmd.Flags().String("source", "", "Connection holding the reference schema")
+	diffCmd.Flags().String("target", "", "Connection holding the schema to compare")
+	diffCmd.Flags().String("schema", "", "Schema to compare on both sides (defaults to each connection's current schema)")
+	diffCmd.Flags().Bool("ddl", false, "Print the DDL that brings the target in line with the source instead of the diff")
+	diffCmd.MarkFlagRequired("source")
+	diffCmd.MarkFlagRequired("target")
+	rootCmd.AddCommand(diffCmd)
 }
 
 func runStdioServer(cmd *cobra.Command, args []string) error {
+	cfg, initialConnection, err := loadServerConfig(cmd)
+	if err != nil {
+		return err
+	}
+
+	return server.RunStdioServer(server.StdioServerConfig{
+		Version:           "v0.1.0",
+		InitialConnection: initialConnection,
+		Config:            cfg,
+	})
+}
+
+func runHTTPServer(cmd *cobra.Command, args []string) error {
+	addr, _ := cmd.Flags().GetString("addr")
+	basePath, _ := cmd.Flags().GetString("base-path")
+	tlsCert, _ := cmd.Flags().GetString("tls-cert")
+	tlsKey, _ := cmd.Flags().GetString("tls-key")
+
+	cfg, initialConnection, err := loadServerConfig(cmd)
+	if err != nil {
+		return err
+	}
+
+	if cfg != nil {
+		if tlsCert == "" {
+			tlsCert = cfg.TLS.CertFile
+		}
+		if tlsKey == "" {
+			tlsKey = cfg.TLS.KeyFile
+		}
+	}
+
+	return server.RunHTTPServer(server.HTTPServerConfig{
+		Version:           "v0.1.0",
+		InitialConnection: initialConnection,
+		Config:            cfg,
+		Addr:              addr,
+		BasePath:          basePath,
+		TLSCertFile:       tlsCert,
+		TLSKeyFile:        tlsKey,
+	})
+}
+
+func runDiff(cmd *cobra.Command, args []string) error {
+	source, _ := cmd.Flags().GetString("source")
+	target, _ := cmd.Flags().GetString("target")
+	schema, _ := cmd.Flags().GetString("schema")
+	ddl, _ := cmd.Flags().GetBool("ddl")
+	configPath, _ := cmd.Flags().GetString("config")
+
+	cfg, err := config.LoadConfig(configPath)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %v", err)
+	}
+
+	output, err := tools.DiffSchemas(cmd.Context(), cfg, tools.DiffSchemasInput{
+		Source:     source,
+		Target:     target,
+		Schema:     schema,
+		IncludeDDL: ddl,
+	})
+	if err != nil {
+		return err
+	}
+
+	if ddl {
+		fmt.Print(output.DDL)
+		return nil
+	}
+	jsonBytes, err := json.MarshalIndent(output, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(jsonBytes))
+	return nil
+}
+
+// loadServerConfig loads the config file and resolves which connection, if
+// any, the server should open on startup.
+func loadServerConfig(cmd *cobra.Command) (*config.Config, string, error) {
 	connection, _ := cmd.Flags().GetString("connection")
 	configPath, _ := cmd.Flags().GetString("config")
+	readOnly, _ := cmd.Flags().GetBool("read-only")
 
 	var initialConnection string
 
-	// Load config and set global config for tools to use
+	// Load config and set global config for tools to use. Without one the
+	// server still starts, on an empty config, so --read-only keeps
+	// applying.
 	cfg, err := config.LoadConfig(configPath)
-	if err != nil {
+	loaded := err == nil
+	if !loaded {
 		fmt.Printf("Warning: Failed to load config: %v\n", err)
 		fmt.Println("Server will start without connections. Use list_connections and switch_connection tools.")
-	} else {
+		cfg = &config.Config{}
+	}
+	if readOnly {
+		cfg.ReadOnly = true
+	}
+	if cfg.ReadOnly {
+		fmt.Println("Read-only mode enabled. execute_query is disabled.")
+	}
+
+	if loaded {
 		if connection != "" {
 			// User explicitly specified a connection
 			if _, exists := cfg.GetConnection(connection); exists {
 				fmt.Printf("Config loaded. Will initialize connection: %s\n", connection)
 				initialConnection = connection
 			} else {
-				return fmt.Errorf("connection '%s' not found in config", connection)
+				return nil, "", fmt.Errorf("connection '%s' not found in config", connection)
 			}
 		} else if cfg.DefaultConnection != "" {
 			// Try to use default connection if it exists
@@ -67,9 +187,5 @@ func runStdioServer(cmd *cobra.Command, args []string) error {
 		}
 	}
 
-	return server.RunStdioServer(server.StdioServerConfig{
-		Version:           "v0.1.0",
-		InitialConnection: initialConnection,
-		Config:            cfg,
-	})
+	return cfg, initialConnection, nil
 }
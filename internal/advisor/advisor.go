@@ -0,0 +1,513 @@
+// Package advisor proposes indexes for the filter, join and sort columns
+// execution plans show being read without one.
+package advisor
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/AbdelilahOu/DBMcp/internal/catalog"
+	"github.com/AbdelilahOu/DBMcp/internal/dialect"
+	"github.com/AbdelilahOu/DBMcp/internal/plan"
+)
+
+// Query is a statement to advise on, with its plan.
+type Query struct {
+	Text  string
+	Nodes []plan.Node
+}
+
+// Table is what the advisor needs to know about a table.
+type Table struct {
+	Columns []catalog.Column
+	Indexes []catalog.Index
+}
+
+type Suggestion struct {
+	Table     string   `json:"table" jsonschema_description:"Table to index"`
+	Columns   []string `json:"columns" jsonschema_description:"Key columns in order, with DESC where a sort needs it"`
+	Statement string   `json:"statement" jsonschema_description:"CREATE INDEX statement"`
+	Reasons   []string `json:"reasons" jsonschema_description:"What in the plans the index is for"`
+	Queries   []int    `json:"queries" jsonschema_description:"Numbers of the queries it is for, from 1"`
+}
+
+type Advice struct {
+	Suggestions []Suggestion
+	// Notes are about candidates an existing index already covers.
+	Notes []string
+}
+
+// maxIndexName is the shortest identifier limit of the supported
+// databases, Postgres' 63 bytes.
+const maxIndexName = 63
+
+type kind int
+
+const (
+	filterCandidate kind = iota
+	joinCandidate
+	sortCandidate
+)
+
+type sortKey struct {
+	column string
+	desc   bool
+}
+
+// candidate is an index some plan asks for: equality columns in any order,
+// then at most one range column, then sort keys.
+type candidate struct {
+	kind     kind
+	table    string
+	equality []string
+	rangeCol string
+	sortKeys []sortKey
+	reason   string
+	query    int
+}
+
+// Suggest proposes indexes for queries. table returns the columns and
+// indexes of a table of schema, or nil when there is no such table.
+func Suggest(d dialect.Dialect, schema string, queries []Query, table func(name string) (*Table, error)) (Advice, error) {
+	var candidates []candidate
+	for i, q := range queries {
+		candidates = append(candidates, candidatesOf(q, i+1)...)
+	}
+
+	advice := Advice{Suggestions: make([]Suggestion, 0)}
+	byKey := map[string]int{}
+	noted := map[string]bool{}
+	for _, c := range candidates {
+		t, err := table(c.table)
+		if err != nil {
+			return Advice{}, err
+		}
+		if t == nil || !c.resolve(t) {
+			continue
+		}
+
+		columns := c.columns()
+		if index := covering(t.Indexes, c); index != "" {
+			note := fmt.Sprintf("%s (%s) is already covered by index %s; the planner did not use it, likely because it expects too many matching rows",
+				c.table, strings.Join(columns, ", "), index)
+			if c.kind != joinCandidate && !noted[note] {
+				noted[note] = true
+				advice.Notes = append(advice.Notes, note)
+			}
+			continue
+		}
+
+		key := strings.ToLower(c.table + "(" + strings.Join(columns, ",") + ")")
+		i, ok := byKey[key]
+		if !ok {
+			i = len(advice.Suggestions)
+			byKey[key] = i
+			advice.Suggestions = append(advice.Suggestions, Suggestion{
+				Table:     c.table,
+				Columns:   columns,
+				Statement: statement(d, schema, c.table, columns),
+				Reasons:   make([]string, 0),
+				Queries:   make([]int, 0),
+			})
+		}
+		s := &advice.Suggestions[i]
+		if !slices.Contains(s.Reasons, c.reason) {
+			s.Reasons = append(s.Reasons, c.reason)
+		}
+		if !slices.Contains(s.Queries, c.query) {
+			s.Queries = append(s.Queries, c.query)
+		}
+	}
+	return advice, nil
+}
+
+// resolve keeps the columns the table has, in the table's spelling, and
+// reports whether any are left.
+func (c *candidate) resolve(t *Table) bool {
+	names := map[string]string{}
+	for _, col := range t.Columns {
+		names[strings.ToLower(col.Name)] = col.Name
+	}
+
+	var equality []string
+	for _, col := range c.equality {
+		if name, ok := names[strings.ToLower(col)]; ok && !slices.Contains(equality, name) {
+			equality = append(equality, name)
+		}
+	}
+	c.equality = equality
+	if name, ok := names[strings.ToLower(c.rangeCol)]; ok && !slices.Contains(equality, name) {
+		c.rangeCol = name
+	} else {
+		c.rangeCol = ""
+	}
+	var keys []sortKey
+	for _, k := range c.sortKeys {
+		name, ok := names[strings.ToLower(k.column)]
+		if !ok {
+			// A partial sort order is no use to the sort.
+			keys = nil
+			break
+		}
+		keys = append(keys, sortKey{name, k.desc})
+	}
+	c.sortKeys = keys
+
+	return len(c.equality) > 0 || c.rangeCol != "" || len(c.sortKeys) > 0
+}
+
+func (c candidate) columns() []string {
+	columns := append([]string{}, c.equality...)
+	if c.rangeCol != "" {
+		columns = append(columns, c.rangeCol)
+	}
+	for _, k := range c.sortKeys {
+		if k.desc {
+			columns = append(columns, k.column+" DESC")
+		} else {
+			columns = append(columns, k.column)
+		}
+	}
+	return columns
+}
+
+// covering returns the name of an index whose leading columns are the
+// candidate's, or "". Partial indexes only serve some rows, so they do not
+// count.
+func covering(indexes []catalog.Index, c candidate) string {
+	var ordered []string
+	if c.rangeCol != "" {
+		ordered = append(ordered, c.rangeCol)
+	}
+	for _, k := range c.sortKeys {
+		ordered = append(ordered, k.column)
+	}
+
+	for _, index := range indexes {
+		if index.IsPartial || len(index.Columns) < len(c.equality)+len(ordered) {
+			continue
+		}
+		leading := lowered(index.Columns[:len(c.equality)])
+		sort.Strings(leading)
+		equality := lowered(c.equality)
+		sort.Strings(equality)
+		if strings.Join(leading, ",") != strings.Join(equality, ",") {
+			continue
+		}
+		rest := lowered(index.Columns[len(c.equality) : len(c.equality)+len(ordered)])
+		if strings.Join(rest, ",") == strings.Join(lowered(ordered), ",") {
+			return index.Name
+		}
+	}
+	return ""
+}
+
+func statement(d dialect.Dialect, schema, table string, columns []string) string {
+	name := "idx_" + table
+	parts := make([]string, len(columns))
+	for i, col := range columns {
+		column, desc := strings.CutSuffix(col, " DESC")
+		name += "_" + column
+		parts[i] = d.QuoteIdentifier(column)
+		if desc {
+			parts[i] += " DESC"
+		}
+	}
+	if len(name) > maxIndexName {
+		name = truncateName(name, maxIndexName)
+	}
+	return fmt.Sprintf("CREATE INDEX %s ON %s (%s);", d.QuoteIdentifier(name), d.QualifiedName(schema, table), strings.Join(parts, ", "))
+}
+
+// truncateName cuts name to at most limit bytes on a rune boundary and ends
+// it with a hash of the whole name, so long names that share a prefix stay
+// distinct.
+func truncateName(name string, limit int) string {
+	sum := sha256.Sum256([]byte(name))
+	suffix := "_" + hex.EncodeToString(sum[:4])
+	cut := limit - len(suffix)
+	for cut > 0 && !utf8.RuneStart(name[cut]) {
+		cut--
+	}
+	return name[:cut] + suffix
+}
+
+// candidatesOf reads the candidates off one query's plan.
+func candidatesOf(q Query, number int) []candidate {
+	nodes := q.Nodes
+	aliases := aliasesOf(q)
+	tableOf := func(n plan.Node) string {
+		if t, ok := aliases[strings.ToLower(n.Relation)]; ok {
+			return t
+		}
+		return n.Relation
+	}
+
+	children := make([][]int, len(nodes)+1)
+	for _, n := range nodes {
+		children[n.Parent] = append(children[n.Parent], n.ID)
+	}
+
+	var candidates []candidate
+	// filtered holds the filter candidate of each fully scanned table, for
+	// sorts to build on.
+	filtered := map[string]candidate{}
+	fullScans := map[string]bool{}
+
+	for _, n := range nodes {
+		if !n.FullScan() || n.Relation == "" {
+			continue
+		}
+		table := tableOf(n)
+		fullScans[strings.ToLower(table)] = true
+
+		c := candidate{kind: filterCandidate, table: table, query: number}
+		for _, cmp := range comparisons(n.Filter) {
+			// Comparisons with another table's columns are joins, below.
+			if !cmp.left.belongsTo(n) || cmp.right != nil && !cmp.right.belongsTo(n) {
+				continue
+			}
+			switch {
+			case cmp.equality:
+				c.equality = append(c.equality, cmp.left.column)
+			case c.rangeCol == "":
+				c.rangeCol = cmp.left.column
+			}
+		}
+		if len(c.equality) == 0 && c.rangeCol == "" {
+			continue
+		}
+		c.reason = fmt.Sprintf("%s on %s reads %s rows to filter on %s", n.Operation, table, formatRows(n), n.Filter)
+		filtered[strings.ToLower(table)] = c
+		candidates = append(candidates, c)
+	}
+
+	for _, n := range nodes {
+		for _, condition := range []string{n.Condition, n.Filter} {
+			for _, cmp := range comparisons(condition) {
+				if !cmp.equality || cmp.right == nil {
+					continue
+				}
+				for _, side := range []reference{cmp.left, *cmp.right} {
+					table, ok := aliases[strings.ToLower(side.qualifier)]
+					if !ok || !fullScans[strings.ToLower(table)] {
+						continue
+					}
+					candidates = append(candidates, candidate{
+						kind:     joinCandidate,
+						table:    table,
+						equality: []string{side.column},
+						reason:   fmt.Sprintf("%s joins on %s while %s is read in full", n.Operation, cmp.text, table),
+						query:    number,
+					})
+				}
+			}
+		}
+	}
+
+	for _, n := range nodes {
+		if len(n.SortKeys) == 0 || !strings.Contains(strings.ToLower(n.Operation), "sort") {
+			continue
+		}
+		var below []plan.Node
+		var walk func(id int)
+		walk = func(id int) {
+			for _, child := range children[id] {
+				below = append(below, nodes[child-1])
+				walk(child)
+			}
+		}
+		walk(n.ID)
+
+		table, keys := sortKeysOf(n.SortKeys, below, aliases, tableOf)
+		if table == "" || !fullScans[strings.ToLower(table)] {
+			continue
+		}
+		// Rows matching the equality filters come out in index order; after
+		// a range column they do not.
+		c := filtered[strings.ToLower(table)]
+		c.kind, c.table, c.rangeCol, c.sortKeys, c.query = sortCandidate, table, "", keys, number
+		c.reason = fmt.Sprintf("%s on %s runs after reading %s in full", n.Operation, strings.Join(n.SortKeys, ", "), table)
+		candidates = append(candidates, c)
+	}
+	return candidates
+}
+
+var sortDirectionRe = regexp.MustCompile(`(?i)\s+(ASC|DESC)?(\s+NULLS\s+(FIRST|LAST))?$`)
+
+// sortKeysOf returns the table all keys sort on, or "" when they are not
+// plain columns of one table read below the sort.
+func sortKeysOf(keys []string, below []plan.Node, aliases map[string]string, tableOf func(plan.Node) string) (string, []sortKey) {
+	var relations []string
+	for _, n := range below {
+		if n.Relation != "" && !slices.Contains(relations, tableOf(n)) {
+			relations = append(relations, tableOf(n))
+		}
+	}
+
+	var table string
+	var sortKeys []sortKey
+	for _, key := range keys {
+		desc := false
+		if m := sortDirectionRe.FindStringSubmatch(key); m != nil {
+			desc = strings.EqualFold(m[1], "DESC")
+			key = key[:len(key)-len(m[0])]
+		}
+		ref, ok := parseReference(unquote(strings.TrimSpace(key)))
+		if !ok {
+			return "", nil
+		}
+
+		var keyTable string
+		switch {
+		case ref.qualifier != "":
+			keyTable, ok = aliases[strings.ToLower(ref.qualifier)]
+			if !ok {
+				return "", nil
+			}
+		case len(relations) == 1:
+			keyTable = relations[0]
+		default:
+			return "", nil
+		}
+		if table != "" && !strings.EqualFold(table, keyTable) {
+			return "", nil
+		}
+		table = keyTable
+		sortKeys = append(sortKeys, sortKey{ref.column, desc})
+	}
+	return table, sortKeys
+}
+
+// reference is a column as a plan prints it, maybe qualified by a table
+// or alias.
+type reference struct {
+	qualifier string
+	column    string
+}
+
+// belongsTo reports whether r is a column of the table n reads.
+func (r reference) belongsTo(n plan.Node) bool {
+	return r.qualifier == "" || strings.EqualFold(r.qualifier, n.Relation) || strings.EqualFold(r.qualifier, n.Alias)
+}
+
+var identifierRe = regexp.MustCompile(`^[A-Za-z_][\w$]*(\.[A-Za-z_][\w$]*)*$`)
+
+func parseReference(s string) (reference, bool) {
+	if !identifierRe.MatchString(s) {
+		return reference{}, false
+	}
+	parts := strings.Split(s, ".")
+	r := reference{column: parts[len(parts)-1]}
+	if len(parts) > 1 {
+		r.qualifier = parts[len(parts)-2]
+	}
+	return r, true
+}
+
+type comparison struct {
+	text     string
+	left     reference
+	equality bool
+	// right is set when the column is compared with another column.
+	right *reference
+}
+
+var comparisonRe = regexp.MustCompile(`(?i)([A-Za-z_][\w$]*(?:\.[A-Za-z_][\w$]*)*)\s*(=|<>|!=|<=|>=|<|>|!~~\*?|~~\*?|\bnot\s+like\b|\blike\b|\bin\b|\bis\b|\bbetween\b)\s*([A-Za-z_][\w$]*(?:\.[A-Za-z_][\w$]*)+)?`)
+
+// comparisons finds the column comparisons of a condition that an index
+// could serve. Columns inside function calls and casts are skipped.
+func comparisons(condition string) []comparison {
+	condition = unquote(condition)
+	var found []comparison
+	for _, m := range comparisonRe.FindAllStringSubmatchIndex(condition, -1) {
+		if m[0] > 0 && strings.ContainsRune(":.", rune(condition[m[0]-1])) {
+			continue
+		}
+		left, _ := parseReference(condition[m[2]:m[3]])
+		c := comparison{text: condition[m[0]:m[1]], left: left}
+		switch op := strings.ToLower(strings.Join(strings.Fields(condition[m[4]:m[5]]), " ")); op {
+		case "=", "in", "is":
+			c.equality = true
+		case "<", ">", "<=", ">=", "between", "like", "~~":
+		default:
+			continue
+		}
+		if m[6] >= 0 {
+			if right, ok := parseReference(condition[m[6]:m[7]]); ok {
+				c.right = &right
+			}
+		}
+		found = append(found, c)
+	}
+	return found
+}
+
+var (
+	quoteReplacer = strings.NewReplacer("`", "", `"`, "")
+	fromRe        = regexp.MustCompile(`(?i)\b(?:from|join)\s+((?:[\w$]+\.)?[\w$]+)(?:\s+(?:as\s+)?([\w$]+))?`)
+)
+
+// notAliases are keywords that may follow a table name in FROM.
+var notAliases = map[string]bool{
+	"where": true, "join": true, "inner": true, "left": true, "right": true, "full": true,
+	"cross": true, "natural": true, "straight_join": true, "on": true, "using": true,
+	"group": true, "order": true, "having": true, "limit": true, "offset": true,
+	"union": true, "except": true, "intersect": true, "window": true, "for": true,
+	"lateral": true, "set": true, "tablesample": true, "force": true, "use": true, "ignore": true,
+}
+
+func unquote(s string) string {
+	return quoteReplacer.Replace(s)
+}
+
+// aliasesOf maps the aliases and table names of a query, lowercased, to
+// table names. MySQL plans name tables by their alias.
+func aliasesOf(q Query) map[string]string {
+	aliases := map[string]string{}
+	for _, m := range fromRe.FindAllStringSubmatch(unquote(q.Text), -1) {
+		name := m[1]
+		if i := strings.LastIndex(name, "."); i >= 0 {
+			name = name[i+1:]
+		}
+		aliases[strings.ToLower(name)] = name
+		if alias := strings.ToLower(m[2]); alias != "" && !notAliases[alias] {
+			aliases[alias] = name
+		}
+	}
+	for _, n := range q.Nodes {
+		if n.Relation == "" {
+			continue
+		}
+		if _, ok := aliases[strings.ToLower(n.Relation)]; !ok {
+			aliases[strings.ToLower(n.Relation)] = n.Relation
+		}
+		if n.Alias != "" {
+			aliases[strings.ToLower(n.Alias)] = n.Relation
+		}
+	}
+	return aliases
+}
+
+func formatRows(n plan.Node) string {
+	rows := n.RowsScanned()
+	if n.EstimatedRows != nil && rows == 0 {
+		rows = *n.EstimatedRows
+	}
+	return strconv.FormatFloat(rows, 'f', 0, 64)
+}
+
+func lowered(values []string) []string {
+	out := make([]string, len(values))
+	for i, v := range values {
+		out[i] = strings.ToLower(v)
+	}
+	return out
+}
@@ -0,0 +1,161 @@
+package advisor
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/AbdelilahOu/DBMcp/internal/catalog"
+	"github.com/AbdelilahOu/DBMcp/internal/dialect"
+	"github.com/AbdelilahOu/DBMcp/internal/plan"
+)
+
+func TestComparisons(t *testing.T) {
+	tests := []struct {
+		condition string
+		// want lists each comparison as qualifier.column, = or <, and the
+		// right-hand column if any.
+		want []string
+	}{
+		{"(status = 'open'::text)", []string{"status ="}},
+		{"(o.customer_id = c.id)", []string{"o.customer_id = c.id"}},
+		{"((`shop`.`o`.`status` = 'open') and (`shop`.`o`.`customer_id` is not null))", []string{"o.status =", "o.customer_id ="}},
+		{"(created_at >= '2024-01-01'::date)", []string{"created_at <"}},
+		{"(t.amount BETWEEN 1 AND 5)", []string{"t.amount <"}},
+		{"(name ~~ 'abc%'::text)", []string{"name <"}},
+		{"(id IN (1, 2, 3))", []string{"id ="}},
+		{"(status <> 'closed'::text)", nil},
+		{"(name !~~ 'abc%'::text)", nil},
+		{"(lower(email) = 'a@b.c'::text)", nil},
+		{"((amount)::numeric > 5)", nil},
+		{"", nil},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, c := range comparisons(tt.condition) {
+			s := c.left.column
+			if c.left.qualifier != "" {
+				s = c.left.qualifier + "." + s
+			}
+			if c.equality {
+				s += " ="
+			} else {
+				s += " <"
+			}
+			if c.right != nil {
+				s += " " + c.right.qualifier + "." + c.right.column
+			}
+			got = append(got, s)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("comparisons(%q) = %q, want %q", tt.condition, got, tt.want)
+		}
+	}
+}
+
+func TestAliasesOf(t *testing.T) {
+	tests := []struct {
+		query string
+		nodes []plan.Node
+		want  map[string]string
+	}{
+		{
+			query: "SELECT * FROM orders o JOIN customers AS c ON c.id = o.customer_id",
+			want:  map[string]string{"orders": "orders", "o": "orders", "customers": "customers", "c": "customers"},
+		},
+		{
+			query: "SELECT * FROM shop.orders WHERE id = 1 ORDER BY id",
+			want:  map[string]string{"orders": "orders"},
+		},
+		{
+			query: `SELECT * FROM "Orders" ord LEFT JOIN items USING (order_id)`,
+			want:  map[string]string{"orders": "Orders", "ord": "Orders", "items": "items"},
+		},
+		{
+			query: "SELECT * FROM orders AS o",
+			nodes: []plan.Node{{Relation: "orders", Alias: "o"}, {Relation: "line_items", Alias: "li"}},
+			want:  map[string]string{"orders": "orders", "o": "orders", "line_items": "line_items", "li": "line_items"},
+		},
+	}
+	for _, tt := range tests {
+		if got := aliasesOf(Query{Text: tt.query, Nodes: tt.nodes}); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("aliasesOf(%q) = %v, want %v", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestStatementNameLimit(t *testing.T) {
+	d := dialect.Postgres{}
+	short := statement(d, "public", "orders", []string{"status", "created_at DESC"})
+	if want := `CREATE INDEX "idx_orders_status_created_at" ON "public"."orders" ("status", "created_at" DESC);`; short != want {
+		t.Errorf("statement = %s, want %s", short, want)
+	}
+
+	long := strings.Repeat("col", 10)
+	names := map[string]bool{}
+	for _, columns := range [][]string{
+		{long + "_a", long + "_b"},
+		{long + "_a", long + "_c"},
+		{"ä" + long, "bestellung_über_datum"},
+		{"ääääääääääääääääääääääääääääää", "ü"},
+	} {
+		s := statement(d, "public", "bestellungen", columns)
+		name := s[len(`CREATE INDEX "`):strings.Index(s, `" ON`)]
+		if len(name) > maxIndexName {
+			t.Errorf("%s is %d bytes long, want at most %d", name, len(name), maxIndexName)
+		}
+		if !utf8.ValidString(name) {
+			t.Errorf("%q is cut inside a character", name)
+		}
+		if names[name] {
+			t.Errorf("%s is proposed for two different column lists", name)
+		}
+		names[name] = true
+	}
+}
+
+func TestSuggest(t *testing.T) {
+	nodes, err := plan.Parse("mysql", `{
+  "query_block": {
+    "select_id": 1,
+    "cost_info": {"query_cost": "10155.08"},
+    "table": {
+      "table_name": "o",
+      "access_type": "ALL",
+      "rows_examined_per_scan": 99712,
+      "filtered": "10.00",
+      "cost_info": {"read_cost": "9157.96", "eval_cost": "997.12"},
+      "attached_condition": "((`+"`shop`.`o`.`status`"+` = 'open') and (`+"`shop`.`o`.`created_at`"+` > '2024-01-01'))"
+    }
+  }
+}`)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	queries := []Query{{Text: "SELECT * FROM orders o WHERE o.status = 'open' AND o.created_at > '2024-01-01'", Nodes: nodes}}
+
+	tables := map[string]*Table{"orders": {Columns: []catalog.Column{{Name: "id"}, {Name: "status"}, {Name: "created_at"}}}}
+	lookup := func(name string) (*Table, error) { return tables[name], nil }
+
+	advice, err := Suggest(dialect.MySQL{}, "shop", queries, lookup)
+	if err != nil {
+		t.Fatalf("Suggest: %v", err)
+	}
+	if len(advice.Suggestions) != 1 {
+		t.Fatalf("suggestions = %+v, want 1", advice.Suggestions)
+	}
+	if got, want := advice.Suggestions[0].Statement, "CREATE INDEX `idx_orders_status_created_at` ON `shop`.`orders` (`status`, `created_at`);"; got != want {
+		t.Errorf("statement = %s, want %s", got, want)
+	}
+
+	// An index that already leads with the columns is noted, not proposed.
+	tables["orders"].Indexes = []catalog.Index{{Name: "orders_status_created", Columns: []string{"status", "created_at", "id"}}}
+	advice, err = Suggest(dialect.MySQL{}, "shop", queries, lookup)
+	if err != nil {
+		t.Fatalf("Suggest: %v", err)
+	}
+	if len(advice.Suggestions) != 0 || len(advice.Notes) != 1 {
+		t.Errorf("advice = %+v, want only a note about orders_status_created", advice)
+	}
+}
@@ -0,0 +1,137 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/AbdelilahOu/DBMcp/internal/config"
+	"github.com/AbdelilahOu/DBMcp/internal/logger"
+	sdkauth "github.com/modelcontextprotocol/go-sdk/auth"
+)
+
+// ErrNoCredentials is returned by an Authenticator when the request carries
+// no credentials it understands, so the next one in a Chain can try.
+var ErrNoCredentials = errors.New("no credentials")
+
+type Principal struct {
+	Subject   string                 `json:"subject"`
+	Method    string                 `json:"method"`
+	Claims    map[string]interface{} `json:"claims,omitempty"`
+	ExpiresAt time.Time              `json:"expires_at,omitempty"`
+}
+
+type Authenticator interface {
+	Authenticate(r *http.Request) (*Principal, error)
+}
+
+type principalKey struct{}
+
+func WithPrincipal(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+func PrincipalFromContext(ctx context.Context) *Principal {
+	p, _ := ctx.Value(principalKey{}).(*Principal)
+	return p
+}
+
+// PrincipalFromTokenInfo recovers the principal that Middleware handed to the
+// MCP SDK, which forwards it to tool handlers as req.Extra.TokenInfo.
+func PrincipalFromTokenInfo(info *sdkauth.TokenInfo) *Principal {
+	if info == nil {
+		return nil
+	}
+	p, _ := info.Extra["principal"].(*Principal)
+	return p
+}
+
+// Chain tries each authenticator in order and returns the first principal.
+type Chain []Authenticator
+
+func (c Chain) Authenticate(r *http.Request) (*Principal, error) {
+	for _, a := range c {
+		p, err := a.Authenticate(r)
+		if errors.Is(err, ErrNoCredentials) {
+			continue
+		}
+		return p, err
+	}
+	return nil, ErrNoCredentials
+}
+
+func New(cfg config.AuthConfig) (Authenticator, error) {
+	var chain Chain
+
+	if cfg.ClientCert != nil {
+		chain = append(chain, NewClientCertAuthenticator(cfg.ClientCert.AllowedSubjects))
+	}
+	if len(cfg.BearerTokens) > 0 {
+		a, err := NewStaticTokenAuthenticator(cfg.BearerTokens)
+		if err != nil {
+			return nil, err
+		}
+		chain = append(chain, a)
+	}
+	if cfg.JWT != nil {
+		a, err := NewJWTAuthenticator(*cfg.JWT)
+		if err != nil {
+			return nil, err
+		}
+		chain = append(chain, a)
+	}
+
+	if len(chain) == 0 {
+		return nil, fmt.Errorf("no authentication method configured")
+	}
+	return chain, nil
+}
+
+// tokenInfoLifetime bounds principals that carry no expiry of their own
+// (static tokens, client certificates); the SDK rejects a zero expiration.
+const tokenInfoLifetime = time.Hour
+
+// Middleware rejects unauthenticated requests with 401 and attaches the
+// principal to the request context. The MCP SDK only forwards
+// sdkauth.TokenInfo to tool handlers, and only sdkauth.RequireBearerToken can
+// put it on the context, so the principal is passed through that middleware.
+func Middleware(a Authenticator, next http.Handler) http.Handler {
+	forward := sdkauth.RequireBearerToken(func(ctx context.Context, _ string, _ *http.Request) (*sdkauth.TokenInfo, error) {
+		p := PrincipalFromContext(ctx)
+		if p == nil {
+			return nil, sdkauth.ErrInvalidToken
+		}
+		expiration := p.ExpiresAt
+		if expiration.IsZero() {
+			expiration = time.Now().Add(tokenInfoLifetime)
+		}
+		return &sdkauth.TokenInfo{
+			Expiration: expiration,
+			Extra:      map[string]any{"principal": p},
+		}, nil
+	}, nil)(next)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p, err := a.Authenticate(r)
+		if err != nil {
+			logger.Warn("Authentication failed", map[string]interface{}{
+				"remote_addr": r.RemoteAddr,
+				"error":       err.Error(),
+			})
+			w.Header().Set("WWW-Authenticate", `Bearer realm="dbmcp"`)
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		r = r.WithContext(WithPrincipal(r.Context(), p))
+		if _, ok := bearerToken(r); !ok {
+			// Client-certificate requests have no Authorization header, which
+			// RequireBearerToken insists on. The token value is ignored.
+			r = r.Clone(r.Context())
+			r.Header.Set("Authorization", "Bearer "+p.Method)
+		}
+		forward.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,133 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/AbdelilahOu/DBMcp/internal/config"
+	sdkauth "github.com/modelcontextprotocol/go-sdk/auth"
+)
+
+func newTestChain(t *testing.T) Authenticator {
+	t.Helper()
+	jwtConfig := testJWTConfig(t)
+	a, err := New(config.AuthConfig{
+		BearerTokens: []config.StaticToken{{Subject: "ci", Token: "ci-secret"}},
+		JWT:          &jwtConfig,
+		ClientCert:   &config.ClientCertConfig{AllowedSubjects: []string{"alice"}},
+	})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	return a
+}
+
+func validJWT(t *testing.T, subject string) string {
+	t.Helper()
+	return signJWT(t,
+		map[string]interface{}{"alg": "HS256", "kid": "k1"},
+		map[string]interface{}{"sub": subject, "iss": "issuer", "aud": "dbmcp", "exp": time.Now().Add(time.Hour).Unix()},
+		testSecret)
+}
+
+func TestChainOrder(t *testing.T) {
+	chain := newTestChain(t)
+	notAfter := time.Now().Add(time.Hour)
+
+	tests := []struct {
+		name    string
+		request *http.Request
+		subject string
+		method  string
+		noCreds bool
+		wantErr bool
+	}{
+		{name: "client certificate before a bearer token", request: withClientCert(bearerRequest("ci-secret"), "alice", notAfter), subject: "alice", method: "client_cert"},
+		{name: "disallowed certificate is not skipped", request: withClientCert(bearerRequest("ci-secret"), "mallory", notAfter), wantErr: true},
+		{name: "static token", request: bearerRequest("ci-secret"), subject: "ci", method: "bearer"},
+		{name: "JWT falls through the static tokens", request: bearerRequest(validJWT(t, "bob")), subject: "bob", method: "jwt"},
+		{name: "invalid static token is not tried as a JWT", request: bearerRequest("wrong"), wantErr: true},
+		{name: "invalid JWT", request: bearerRequest(validJWT(t, "bob") + "x"), wantErr: true},
+		{name: "no credentials", request: bearerRequest(""), noCreds: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := chain.Authenticate(tt.request)
+			switch {
+			case tt.noCreds:
+				if !errors.Is(err, ErrNoCredentials) {
+					t.Fatalf("err = %v, want ErrNoCredentials", err)
+				}
+			case tt.wantErr:
+				if err == nil || errors.Is(err, ErrNoCredentials) {
+					t.Fatalf("err = %v, want a rejection", err)
+				}
+			default:
+				if err != nil {
+					t.Fatalf("Authenticate: %v", err)
+				}
+				if p.Subject != tt.subject || p.Method != tt.method {
+					t.Errorf("principal = %+v, want subject %s by %s", p, tt.subject, tt.method)
+				}
+			}
+		})
+	}
+}
+
+func TestNewWithoutMethods(t *testing.T) {
+	if _, err := New(config.AuthConfig{}); err == nil {
+		t.Fatal("New accepted a config with no authentication method")
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	chain := newTestChain(t)
+
+	tests := []struct {
+		name    string
+		request *http.Request
+		subject string
+	}{
+		{name: "missing header", request: bearerRequest("")},
+		{name: "wrong token", request: bearerRequest("wrong")},
+		{name: "static token", request: bearerRequest("ci-secret"), subject: "ci"},
+		{name: "JWT", request: bearerRequest(validJWT(t, "bob")), subject: "bob"},
+		{name: "client certificate", request: withClientCert(bearerRequest(""), "alice", time.Now().Add(time.Hour)), subject: "alice"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var fromContext, fromTokenInfo *Principal
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				fromContext = PrincipalFromContext(r.Context())
+				fromTokenInfo = PrincipalFromTokenInfo(sdkauth.TokenInfoFromContext(r.Context()))
+			})
+			w := httptest.NewRecorder()
+			Middleware(chain, next).ServeHTTP(w, tt.request)
+
+			if tt.subject == "" {
+				if w.Code != http.StatusUnauthorized {
+					t.Fatalf("status = %d, want 401", w.Code)
+				}
+				if w.Header().Get("WWW-Authenticate") == "" {
+					t.Error("401 response has no WWW-Authenticate header")
+				}
+				if fromContext != nil {
+					t.Error("handler ran for an unauthenticated request")
+				}
+				return
+			}
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d, want 200: %s", w.Code, w.Body.String())
+			}
+			if fromContext == nil || fromContext.Subject != tt.subject {
+				t.Errorf("context principal = %+v, want subject %s", fromContext, tt.subject)
+			}
+			if fromTokenInfo != fromContext {
+				t.Errorf("token info principal = %+v, want the context principal", fromTokenInfo)
+			}
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/AbdelilahOu/DBMcp/internal/config"
+)
+
+func bearerToken(r *http.Request) (string, bool) {
+	fields := strings.Fields(r.Header.Get("Authorization"))
+	if len(fields) != 2 || !strings.EqualFold(fields[0], "bearer") {
+		return "", false
+	}
+	return fields[1], true
+}
+
+type StaticTokenAuthenticator struct {
+	tokens []staticToken
+}
+
+type staticToken struct {
+	subject string
+	digest  [32]byte
+}
+
+func NewStaticTokenAuthenticator(tokens []config.StaticToken) (*StaticTokenAuthenticator, error) {
+	a := &StaticTokenAuthenticator{}
+	for i, t := range tokens {
+		if t.Token == "" {
+			return nil, fmt.Errorf("bearer token %d has an empty token", i)
+		}
+		subject := t.Subject
+		if subject == "" {
+			subject = fmt.Sprintf("token-%d", i)
+		}
+		a.tokens = append(a.tokens, staticToken{subject: subject, digest: sha256.Sum256([]byte(t.Token))})
+	}
+	return a, nil
+}
+
+// Authenticate compares digests so every comparison takes the same time
+// regardless of token length or how many leading bytes match.
+func (a *StaticTokenAuthenticator) Authenticate(r *http.Request) (*Principal, error) {
+	token, ok := bearerToken(r)
+	if !ok {
+		return nil, ErrNoCredentials
+	}
+
+	digest := sha256.Sum256([]byte(token))
+	for _, t := range a.tokens {
+		if subtle.ConstantTimeCompare(digest[:], t.digest[:]) == 1 {
+			return &Principal{Subject: t.subject, Method: "bearer"}, nil
+		}
+	}
+	if looksLikeJWT(token) {
+		return nil, ErrNoCredentials
+	}
+	return nil, fmt.Errorf("invalid bearer token")
+}
+
+func looksLikeJWT(token string) bool {
+	return strings.Count(token, ".") == 2
+}
@@ -0,0 +1,94 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/AbdelilahOu/DBMcp/internal/config"
+)
+
+func TestStaticTokenAuthenticate(t *testing.T) {
+	a, err := NewStaticTokenAuthenticator([]config.StaticToken{
+		{Subject: "ci", Token: "ci-secret"},
+		{Token: "anonymous-secret"},
+	})
+	if err != nil {
+		t.Fatalf("NewStaticTokenAuthenticator: %v", err)
+	}
+
+	tests := []struct {
+		name          string
+		authorization string
+		subject       string
+		noCreds       bool
+		wantErr       bool
+	}{
+		{name: "valid", authorization: "Bearer ci-secret", subject: "ci"},
+		{name: "lower-case scheme", authorization: "bearer ci-secret", subject: "ci"},
+		{name: "default subject", authorization: "Bearer anonymous-secret", subject: "token-1"},
+		{name: "wrong token", authorization: "Bearer ci-secre", wantErr: true},
+		{name: "token prefix", authorization: "Bearer ci-secret-and-more", wantErr: true},
+		{name: "unknown JWT", authorization: "Bearer a.b.c", noCreds: true},
+		{name: "missing header", noCreds: true},
+		{name: "basic scheme", authorization: "Basic Y2k6Y2ktc2VjcmV0", noCreds: true},
+		{name: "no token", authorization: "Bearer", noCreds: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := bearerRequest("")
+			if tt.authorization != "" {
+				r.Header.Set("Authorization", tt.authorization)
+			}
+			p, err := a.Authenticate(r)
+			switch {
+			case tt.noCreds:
+				if !errors.Is(err, ErrNoCredentials) {
+					t.Fatalf("err = %v, want ErrNoCredentials", err)
+				}
+			case tt.wantErr:
+				if err == nil || errors.Is(err, ErrNoCredentials) {
+					t.Fatalf("err = %v, want a rejection", err)
+				}
+			default:
+				if err != nil {
+					t.Fatalf("Authenticate: %v", err)
+				}
+				if p.Subject != tt.subject || p.Method != "bearer" {
+					t.Errorf("principal = %+v, want subject %s by bearer", p, tt.subject)
+				}
+			}
+		})
+	}
+}
+
+func TestStaticTokenRejectsEmptyToken(t *testing.T) {
+	_, err := NewStaticTokenAuthenticator([]config.StaticToken{{Subject: "ci"}})
+	if err == nil || !strings.Contains(err.Error(), "empty token") {
+		t.Fatalf("err = %v, want an empty token error", err)
+	}
+}
+
+func TestBearerToken(t *testing.T) {
+	tests := []struct {
+		authorization string
+		token         string
+		ok            bool
+	}{
+		{"Bearer abc", "abc", true},
+		{"BEARER abc", "abc", true},
+		{"Bearer  abc ", "abc", true},
+		{"Bearer a b", "", false},
+		{"Token abc", "", false},
+		{"", "", false},
+	}
+	for _, tt := range tests {
+		r, _ := http.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("Authorization", tt.authorization)
+		token, ok := bearerToken(r)
+		if token != tt.token || ok != tt.ok {
+			t.Errorf("bearerToken(%q) = %q, %v; want %q, %v", tt.authorization, token, ok, tt.token, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package auth
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"os"
+	"slices"
+)
+
+// ClientCertAuthenticator accepts requests whose TLS client certificate was
+// verified against the configured CA during the handshake.
+type ClientCertAuthenticator struct {
+	allowedSubjects []string
+}
+
+func NewClientCertAuthenticator(allowedSubjects []string) *ClientCertAuthenticator {
+	return &ClientCertAuthenticator{allowedSubjects: allowedSubjects}
+}
+
+func (a *ClientCertAuthenticator) Authenticate(r *http.Request) (*Principal, error) {
+	if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+		return nil, ErrNoCredentials
+	}
+
+	leaf := r.TLS.VerifiedChains[0][0]
+	subject := leaf.Subject.CommonName
+	if subject == "" {
+		return nil, fmt.Errorf("client certificate has no common name")
+	}
+	if len(a.allowedSubjects) > 0 && !slices.Contains(a.allowedSubjects, subject) {
+		return nil, fmt.Errorf("client certificate subject %q is not allowed", subject)
+	}
+
+	return &Principal{
+		Subject:   subject,
+		Method:    "client_cert",
+		ExpiresAt: leaf.NotAfter,
+	}, nil
+}
+
+// ServerTLSConfig asks clients for a certificate signed by the CA in caFile.
+// Certificates are optional at the TLS layer so bearer-token clients can
+// still connect; the authenticator chain decides who gets in.
+func ServerTLSConfig(caFile string) (*tls.Config, error) {
+	pem, err := os.ReadFile(caFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read client CA file: %v", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("client CA file contains no certificates")
+	}
+	return &tls.Config{
+		ClientAuth: tls.VerifyClientCertIfGiven,
+		ClientCAs:  pool,
+		MinVersion: tls.VersionTLS12,
+	}, nil
+}
@@ -0,0 +1,72 @@
+package auth
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// withClientCert marks r as having presented a verified certificate for
+// commonName.
+func withClientCert(r *http.Request, commonName string, notAfter time.Time) *http.Request {
+	leaf := &x509.Certificate{Subject: pkix.Name{CommonName: commonName}, NotAfter: notAfter}
+	r.TLS = &tls.ConnectionState{
+		PeerCertificates: []*x509.Certificate{leaf},
+		VerifiedChains:   [][]*x509.Certificate{{leaf}},
+	}
+	return r
+}
+
+func TestClientCertAuthenticate(t *testing.T) {
+	notAfter := time.Now().Add(24 * time.Hour).Truncate(time.Second)
+
+	tests := []struct {
+		name    string
+		allowed []string
+		request *http.Request
+		subject string
+		noCreds bool
+		wantErr bool
+	}{
+		{name: "no TLS", request: bearerRequest(""), noCreds: true},
+		{
+			name: "unverified certificate",
+			request: func() *http.Request {
+				r := withClientCert(bearerRequest(""), "alice", notAfter)
+				r.TLS.VerifiedChains = nil
+				return r
+			}(),
+			noCreds: true,
+		},
+		{name: "any subject", request: withClientCert(bearerRequest(""), "alice", notAfter), subject: "alice"},
+		{name: "allowed subject", allowed: []string{"bob", "alice"}, request: withClientCert(bearerRequest(""), "alice", notAfter), subject: "alice"},
+		{name: "subject not allowed", allowed: []string{"bob"}, request: withClientCert(bearerRequest(""), "alice", notAfter), wantErr: true},
+		{name: "no common name", request: withClientCert(bearerRequest(""), "", notAfter), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := NewClientCertAuthenticator(tt.allowed).Authenticate(tt.request)
+			switch {
+			case tt.noCreds:
+				if !errors.Is(err, ErrNoCredentials) {
+					t.Fatalf("err = %v, want ErrNoCredentials", err)
+				}
+			case tt.wantErr:
+				if err == nil || errors.Is(err, ErrNoCredentials) {
+					t.Fatalf("err = %v, want a rejection", err)
+				}
+			default:
+				if err != nil {
+					t.Fatalf("Authenticate: %v", err)
+				}
+				if p.Subject != tt.subject || p.Method != "client_cert" || !p.ExpiresAt.Equal(notAfter) {
+					t.Errorf("principal = %+v, want subject %s by client_cert until %s", p, tt.subject, notAfter)
+				}
+			}
+		})
+	}
+}
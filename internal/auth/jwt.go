@@ -0,0 +1,195 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/AbdelilahOu/DBMcp/internal/config"
+)
+
+// clockSkew is how far exp and nbf may be off from the local clock.
+const clockSkew = 30 * time.Second
+
+var hmacAlgorithms = map[string]func() hash.Hash{
+	"HS256": sha256.New,
+	"HS384": sha512.New384,
+	"HS512": sha512.New,
+}
+
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	K   string `json:"k"`
+}
+
+type hmacKey struct {
+	alg    string
+	secret []byte
+}
+
+// JWTAuthenticator verifies HMAC-signed JWTs against the symmetric ("oct")
+// keys of a local JWKS file.
+type JWTAuthenticator struct {
+	keys     map[string]hmacKey
+	issuer   string
+	audience string
+}
+
+func NewJWTAuthenticator(cfg config.JWTConfig) (*JWTAuthenticator, error) {
+	if cfg.JWKSFile == "" {
+		return nil, fmt.Errorf("jwt.jwks_file is required")
+	}
+	data, err := os.ReadFile(cfg.JWKSFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read JWKS file: %v", err)
+	}
+
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("failed to parse JWKS file: %v", err)
+	}
+
+	a := &JWTAuthenticator{
+		keys:     make(map[string]hmacKey),
+		issuer:   cfg.Issuer,
+		audience: cfg.Audience,
+	}
+	for _, k := range set.Keys {
+		if k.Kty != "oct" {
+			continue
+		}
+		if k.Alg != "" && hmacAlgorithms[k.Alg] == nil {
+			return nil, fmt.Errorf("JWKS key '%s': unsupported algorithm %s", k.Kid, k.Alg)
+		}
+		secret, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(k.K, "="))
+		if err != nil {
+			return nil, fmt.Errorf("JWKS key '%s': invalid key material: %v", k.Kid, err)
+		}
+		a.keys[k.Kid] = hmacKey{alg: k.Alg, secret: secret}
+	}
+	if len(a.keys) == 0 {
+		return nil, fmt.Errorf("JWKS file contains no symmetric (oct) keys")
+	}
+	return a, nil
+}
+
+func (a *JWTAuthenticator) Authenticate(r *http.Request) (*Principal, error) {
+	token, ok := bearerToken(r)
+	if !ok || !looksLikeJWT(token) {
+		return nil, ErrNoCredentials
+	}
+
+	parts := strings.Split(token, ".")
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, fmt.Errorf("invalid JWT header: %v", err)
+	}
+
+	newHash := hmacAlgorithms[header.Alg]
+	if newHash == nil {
+		return nil, fmt.Errorf("unsupported JWT algorithm %q", header.Alg)
+	}
+
+	key, ok := a.keys[header.Kid]
+	if !ok && header.Kid == "" && len(a.keys) == 1 {
+		for _, k := range a.keys {
+			key, ok = k, true
+		}
+	}
+	if !ok {
+		return nil, fmt.Errorf("unknown JWT key id %q", header.Kid)
+	}
+	if key.alg != "" && key.alg != header.Alg {
+		return nil, fmt.Errorf("JWT algorithm %s does not match key algorithm %s", header.Alg, key.alg)
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("invalid JWT signature encoding")
+	}
+	mac := hmac.New(newHash, key.secret)
+	mac.Write([]byte(parts[0] + "." + parts[1]))
+	if !hmac.Equal(signature, mac.Sum(nil)) {
+		return nil, fmt.Errorf("invalid JWT signature")
+	}
+
+	var claims map[string]interface{}
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, fmt.Errorf("invalid JWT claims: %v", err)
+	}
+
+	now := time.Now()
+	exp, ok := numericDate(claims["exp"])
+	if !ok {
+		return nil, fmt.Errorf("JWT has no exp claim")
+	}
+	if now.After(exp.Add(clockSkew)) {
+		return nil, fmt.Errorf("JWT expired")
+	}
+	if nbf, ok := numericDate(claims["nbf"]); ok && now.Add(clockSkew).Before(nbf) {
+		return nil, fmt.Errorf("JWT not valid yet")
+	}
+	if a.issuer != "" && claims["iss"] != a.issuer {
+		return nil, fmt.Errorf("JWT issuer mismatch")
+	}
+	if a.audience != "" && !hasAudience(claims["aud"], a.audience) {
+		return nil, fmt.Errorf("JWT audience mismatch")
+	}
+
+	subject, _ := claims["sub"].(string)
+	if subject == "" {
+		return nil, fmt.Errorf("JWT has no sub claim")
+	}
+
+	return &Principal{
+		Subject:   subject,
+		Method:    "jwt",
+		Claims:    claims,
+		ExpiresAt: exp,
+	}, nil
+}
+
+func decodeSegment(segment string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+func numericDate(v interface{}) (time.Time, bool) {
+	f, ok := v.(float64)
+	if !ok {
+		return time.Time{}, false
+	}
+	return time.Unix(int64(f), 0), true
+}
+
+func hasAudience(aud interface{}, want string) bool {
+	switch v := aud.(type) {
+	case string:
+		return v == want
+	case []interface{}:
+		for _, a := range v {
+			if a == want {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,221 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/AbdelilahOu/DBMcp/internal/config"
+)
+
+var testSecret = []byte("0123456789abcdef0123456789abcdef")
+
+// testJWTConfig writes a JWKS holding testSecret as the HS256 key "k1".
+func testJWTConfig(t *testing.T) config.JWTConfig {
+	t.Helper()
+	jwks := map[string]interface{}{"keys": []map[string]string{{
+		"kty": "oct",
+		"kid": "k1",
+		"alg": "HS256",
+		"k":   base64.RawURLEncoding.EncodeToString(testSecret),
+	}}}
+	data, err := json.Marshal(jwks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return config.JWTConfig{JWKSFile: path, Issuer: "issuer", Audience: "dbmcp"}
+}
+
+func newTestJWTAuthenticator(t *testing.T) *JWTAuthenticator {
+	t.Helper()
+	a, err := NewJWTAuthenticator(testJWTConfig(t))
+	if err != nil {
+		t.Fatalf("NewJWTAuthenticator: %v", err)
+	}
+	return a
+}
+
+func encodeSegment(t *testing.T, v interface{}) string {
+	t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// signJWT signs header and claims with secret using the header's HMAC
+// algorithm; an algorithm the package does not know gets an empty signature.
+func signJWT(t *testing.T, header, claims map[string]interface{}, secret []byte) string {
+	t.Helper()
+	signingInput := encodeSegment(t, header) + "." + encodeSegment(t, claims)
+	alg, _ := header["alg"].(string)
+	newHash := hmacAlgorithms[alg]
+	if newHash == nil {
+		return signingInput + "."
+	}
+	mac := hmac.New(newHash, secret)
+	mac.Write([]byte(signingInput))
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func bearerRequest(token string) *http.Request {
+	r := httptest.NewRequest(http.MethodPost, "/mcp", nil)
+	if token != "" {
+		r.Header.Set("Authorization", "Bearer "+token)
+	}
+	return r
+}
+
+func TestJWTAuthenticate(t *testing.T) {
+	a := newTestJWTAuthenticator(t)
+	now := time.Now()
+	header := map[string]interface{}{"alg": "HS256", "kid": "k1", "typ": "JWT"}
+	claims := func(edit func(map[string]interface{})) map[string]interface{} {
+		c := map[string]interface{}{
+			"sub": "alice",
+			"iss": "issuer",
+			"aud": "dbmcp",
+			"exp": now.Add(time.Hour).Unix(),
+		}
+		if edit != nil {
+			edit(c)
+		}
+		return c
+	}
+	withHeader := func(edit func(map[string]interface{})) map[string]interface{} {
+		h := map[string]interface{}{}
+		for k, v := range header {
+			h[k] = v
+		}
+		edit(h)
+		return h
+	}
+
+	tests := []struct {
+		name    string
+		token   string
+		subject string
+		noCreds bool
+		wantErr string
+	}{
+		{name: "valid", token: signJWT(t, header, claims(nil), testSecret), subject: "alice"},
+		{
+			name:    "no kid with a single key",
+			token:   signJWT(t, withHeader(func(h map[string]interface{}) { delete(h, "kid") }), claims(nil), testSecret),
+			subject: "alice",
+		},
+		{
+			name:    "audience in an array",
+			token:   signJWT(t, header, claims(func(c map[string]interface{}) { c["aud"] = []string{"other", "dbmcp"} }), testSecret),
+			subject: "alice",
+		},
+		{
+			name:    "expired within the clock skew",
+			token:   signJWT(t, header, claims(func(c map[string]interface{}) { c["exp"] = now.Add(-10 * time.Second).Unix() }), testSecret),
+			subject: "alice",
+		},
+		{name: "wrong key", token: signJWT(t, header, claims(nil), []byte("another secret")), wantErr: "invalid JWT signature"},
+		{
+			name: "tampered claims",
+			token: func() string {
+				parts := strings.Split(signJWT(t, header, claims(nil), testSecret), ".")
+				parts[1] = encodeSegment(t, claims(func(c map[string]interface{}) { c["sub"] = "mallory" }))
+				return strings.Join(parts, ".")
+			}(),
+			wantErr: "invalid JWT signature",
+		},
+		{
+			name:    "alg none",
+			token:   signJWT(t, withHeader(func(h map[string]interface{}) { h["alg"] = "none" }), claims(nil), nil),
+			wantErr: "unsupported JWT algorithm",
+		},
+		{
+			name:    "RS256 against an oct key",
+			token:   signJWT(t, withHeader(func(h map[string]interface{}) { h["alg"] = "RS256" }), claims(nil), nil),
+			wantErr: "unsupported JWT algorithm",
+		},
+		{
+			name:    "HS384 against an HS256 key",
+			token:   signJWT(t, withHeader(func(h map[string]interface{}) { h["alg"] = "HS384" }), claims(nil), testSecret),
+			wantErr: "does not match key algorithm",
+		},
+		{
+			name:    "unknown kid",
+			token:   signJWT(t, withHeader(func(h map[string]interface{}) { h["kid"] = "k2" }), claims(nil), testSecret),
+			wantErr: "unknown JWT key id",
+		},
+		{
+			name:    "expired",
+			token:   signJWT(t, header, claims(func(c map[string]interface{}) { c["exp"] = now.Add(-time.Hour).Unix() }), testSecret),
+			wantErr: "JWT expired",
+		},
+		{
+			name:    "no exp",
+			token:   signJWT(t, header, claims(func(c map[string]interface{}) { delete(c, "exp") }), testSecret),
+			wantErr: "no exp claim",
+		},
+		{
+			name:    "not valid yet",
+			token:   signJWT(t, header, claims(func(c map[string]interface{}) { c["nbf"] = now.Add(time.Hour).Unix() }), testSecret),
+			wantErr: "not valid yet",
+		},
+		{
+			name:    "wrong issuer",
+			token:   signJWT(t, header, claims(func(c map[string]interface{}) { c["iss"] = "someone else" }), testSecret),
+			wantErr: "issuer mismatch",
+		},
+		{
+			name:    "wrong audience",
+			token:   signJWT(t, header, claims(func(c map[string]interface{}) { c["aud"] = []string{"other"} }), testSecret),
+			wantErr: "audience mismatch",
+		},
+		{
+			name:    "no sub",
+			token:   signJWT(t, header, claims(func(c map[string]interface{}) { delete(c, "sub") }), testSecret),
+			wantErr: "no sub claim",
+		},
+		{name: "missing header", token: "", noCreds: true},
+		{name: "not a JWT", token: "opaque-token", noCreds: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := a.Authenticate(bearerRequest(tt.token))
+			switch {
+			case tt.noCreds:
+				if !errors.Is(err, ErrNoCredentials) {
+					t.Fatalf("err = %v, want ErrNoCredentials", err)
+				}
+			case tt.wantErr != "":
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want %q", err, tt.wantErr)
+				}
+				if p != nil {
+					t.Errorf("rejected token returned principal %+v", p)
+				}
+			default:
+				if err != nil {
+					t.Fatalf("Authenticate: %v", err)
+				}
+				if p.Subject != tt.subject || p.Method != "jwt" {
+					t.Errorf("principal = %+v, want subject %s by jwt", p, tt.subject)
+				}
+				if p.ExpiresAt.IsZero() {
+					t.Error("principal has no expiry")
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,317 @@
+package catalog
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/AbdelilahOu/DBMcp/internal/dialect"
+)
+
+type Column struct {
+	Name          string `json:"name" jsonschema_description:"Column name"`
+	DataType      string `json:"data_type" jsonschema_description:"Data type of the column"`
+	ColumnType    string `json:"column_type" jsonschema_description:"Full column type, with length, precision and modifiers"`
+	IsNullable    bool   `json:"is_nullable" jsonschema_description:"Whether the column can contain NULL values"`
+	IsPrimaryKey  bool   `json:"is_primary_key" jsonschema_description:"Whether the column is part of the primary key"`
+	DefaultValue  string `json:"default_value,omitempty" jsonschema_description:"Default value for the column"`
+	CharMaxLength *int   `json:"char_max_length,omitempty" jsonschema_description:"Maximum length for character types"`
+	Identity      string `json:"identity,omitempty" jsonschema_description:"How the column generates its values: GENERATED ALWAYS|BY DEFAULT AS IDENTITY on Postgres, AUTO_INCREMENT on MySQL"`
+	Sequence      string `json:"sequence,omitempty" jsonschema_description:"Sequence the column owns through a serial default (Postgres)"`
+}
+
+type Index struct {
+	Name         string   `json:"name" jsonschema_description:"Index name"`
+	Columns      []string `json:"columns" jsonschema_description:"Columns included in the index; expression key parts show as (expression)"`
+	IsUnique     bool     `json:"is_unique" jsonschema_description:"Whether the index is unique"`
+	IsPrimary    bool     `json:"is_primary" jsonschema_description:"Whether the index backs the primary key"`
+	IsPartial    bool     `json:"is_partial" jsonschema_description:"Whether the index only covers rows matching a predicate"`
+	IsExpression bool     `json:"is_expression" jsonschema_description:"Whether any key part is an expression rather than a column"`
+	Predicate    string   `json:"predicate,omitempty" jsonschema_description:"WHERE clause of a partial index, when the database reports it"`
+	Definition   string   `json:"definition,omitempty" jsonschema_description:"CREATE INDEX statement, when the database reports it (Postgres, SQLite)"`
+	Constraint   string   `json:"constraint,omitempty" jsonschema_description:"UNIQUE or EXCLUDE when the index backs a constraint of that kind rather than standing alone (Postgres)"`
+}
+
+type ForeignKey struct {
+	Name              string   `json:"name" jsonschema_description:"Constraint name"`
+	Schema            string   `json:"schema" jsonschema_description:"Schema of the referencing table"`
+	Table             string   `json:"table" jsonschema_description:"Referencing table"`
+	Columns           []string `json:"columns" jsonschema_description:"Referencing columns, in key order"`
+	ReferencedSchema  string   `json:"referenced_schema" jsonschema_description:"Schema of the referenced table"`
+	ReferencedTable   string   `json:"referenced_table" jsonschema_description:"Referenced table"`
+	ReferencedColumns []string `json:"referenced_columns" jsonschema_description:"Referenced columns, matching columns one to one"`
+	OnUpdate          string   `json:"on_update" jsonschema_description:"Referential action on update (NO ACTION, RESTRICT, CASCADE, SET NULL or SET DEFAULT)"`
+	OnDelete          string   `json:"on_delete" jsonschema_description:"Referential action on delete"`
+}
+
+type CheckConstraint struct {
+	Name       string `json:"name" jsonschema_description:"Constraint name"`
+	Expression string `json:"expression" jsonschema_description:"Check expression as the database reports it"`
+}
+
+type View struct {
+	Name       string `json:"name" jsonschema_description:"View name"`
+	Definition string `json:"definition" jsonschema_description:"View query; the full CREATE VIEW statement on SQLite"`
+}
+
+type Routine struct {
+	Name       string `json:"name" jsonschema_description:"Routine name"`
+	Kind       string `json:"kind" jsonschema_description:"FUNCTION or PROCEDURE"`
+	Arguments  string `json:"arguments" jsonschema_description:"Argument list, which tells overloads apart"`
+	Definition string `json:"definition" jsonschema_description:"Routine definition: the full CREATE statement on Postgres, the body on MySQL"`
+}
+
+// Signature identifies a routine among overloads.
+func (r Routine) Signature() string {
+	return r.Name + "(" + r.Arguments + ")"
+}
+
+func CurrentSchema(ctx context.Context, db *sql.DB, d dialect.Dialect) (string, error) {
+	var schema sql.NullString
+	if err := db.QueryRowContext(ctx, d.CurrentSchemaQuery()).Scan(&schema); err != nil {
+		return "", err
+	}
+	if !schema.Valid || schema.String == "" {
+		return "", fmt.Errorf("no schema selected")
+	}
+	return schema.String, nil
+}
+
+// Tables returns the names of the tables in schema, leaving out views.
+func Tables(ctx context.Context, db *sql.DB, d dialect.Dialect, schema string) ([]string, error) {
+	query, args := d.ListTablesQuery(schema)
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query tables: %v", err)
+	}
+	defer rows.Close()
+
+	tables := make([]string, 0)
+	for rows.Next() {
+		var name, schemaName, tableType string
+		if err := rows.Scan(&name, &schemaName, &tableType); err != nil {
+			return nil, fmt.Errorf("scan error: %v", err)
+		}
+		if strings.Contains(strings.ToLower(tableType), "base table") {
+			tables = append(tables, name)
+		}
+	}
+
+	return tables, rows.Err()
+}
+
+func Columns(ctx context.Context, db *sql.DB, d dialect.Dialect, schema, table string) ([]Column, error) {
+	query, args := d.ColumnsQuery(schema, table)
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query columns: %v", err)
+	}
+	defer rows.Close()
+
+	columns := make([]Column, 0)
+	for rows.Next() {
+		var col Column
+		var charMaxLen sql.NullInt64
+
+		err := rows.Scan(
+			&col.Name,
+			&col.DataType,
+			&col.ColumnType,
+			&col.IsNullable,
+			&col.DefaultValue,
+			&charMaxLen,
+			&col.IsPrimaryKey,
+			&col.Identity,
+			&col.Sequence,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("scan error: %v", err)
+		}
+
+		if charMaxLen.Valid {
+			length := int(charMaxLen.Int64)
+			col.CharMaxLength = &length
+		}
+
+		columns = append(columns, col)
+	}
+
+	return columns, rows.Err()
+}
+
+func Indexes(ctx context.Context, db *sql.DB, d dialect.Dialect, schema, table string) ([]Index, error) {
+	query, args := d.IndexesQuery(schema, table)
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query indexes: %v", err)
+	}
+	defer rows.Close()
+
+	indexes := make([]Index, 0)
+	for rows.Next() {
+		var index Index
+		var columnsStr string
+
+		err := rows.Scan(&index.Name, &columnsStr, &index.IsUnique, &index.IsPrimary, &index.IsPartial, &index.IsExpression, &index.Predicate, &index.Definition, &index.Constraint)
+		if err != nil {
+			return nil, fmt.Errorf("scan error: %v", err)
+		}
+
+		if index.Columns, err = parseColumnList(columnsStr); err != nil {
+			return nil, fmt.Errorf("index %s: %v", index.Name, err)
+		}
+		indexes = append(indexes, index)
+	}
+
+	return indexes, rows.Err()
+}
+
+// ForeignKeys returns the foreign keys from or to table, or all those
+// touching schema when table is empty.
+func ForeignKeys(ctx context.Context, db *sql.DB, d dialect.Dialect, schema, table string) ([]ForeignKey, error) {
+	query, args := d.ForeignKeysQuery(schema, table)
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query foreign keys: %v", err)
+	}
+	defer rows.Close()
+
+	foreignKeys := make([]ForeignKey, 0)
+	for rows.Next() {
+		var fk ForeignKey
+		var columnsStr, referencedStr string
+
+		err := rows.Scan(
+			&fk.Name,
+			&fk.Schema,
+			&fk.Table,
+			&columnsStr,
+			&fk.ReferencedSchema,
+			&fk.ReferencedTable,
+			&referencedStr,
+			&fk.OnUpdate,
+			&fk.OnDelete,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("scan error: %v", err)
+		}
+
+		if fk.Columns, err = parseColumnList(columnsStr); err != nil {
+			return nil, fmt.Errorf("foreign key %s: %v", fk.Name, err)
+		}
+		if fk.ReferencedColumns, err = parseColumnList(referencedStr); err != nil {
+			return nil, fmt.Errorf("foreign key %s: %v", fk.Name, err)
+		}
+		foreignKeys = append(foreignKeys, fk)
+	}
+
+	return foreignKeys, rows.Err()
+}
+
+// ForeignKeysWithin keeps the foreign keys with both ends in schema.
+func ForeignKeysWithin(foreignKeys []ForeignKey, schema string) []ForeignKey {
+	kept := make([]ForeignKey, 0, len(foreignKeys))
+	for _, fk := range foreignKeys {
+		if fk.Schema == schema && fk.ReferencedSchema == schema {
+			kept = append(kept, fk)
+		}
+	}
+	return kept
+}
+
+func CheckConstraints(ctx context.Context, db *sql.DB, d dialect.Dialect, schema, table string) ([]CheckConstraint, error) {
+	checks := make([]CheckConstraint, 0)
+	query, args := d.CheckConstraintsQuery(schema, table)
+	if query == "" {
+		return checks, nil
+	}
+	if available := d.CheckConstraintsAvailableQuery(); available != "" {
+		var n int
+		if err := db.QueryRowContext(ctx, available).Scan(&n); err != nil {
+			return nil, fmt.Errorf("failed to query check constraints: %v", err)
+		}
+		if n == 0 {
+			return checks, nil
+		}
+	}
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query check constraints: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var check CheckConstraint
+		if err := rows.Scan(&check.Name, &check.Expression); err != nil {
+			return nil, fmt.Errorf("scan error: %v", err)
+		}
+		checks = append(checks, check)
+	}
+
+	return checks, rows.Err()
+}
+
+func Views(ctx context.Context, db *sql.DB, d dialect.Dialect, schema string) ([]View, error) {
+	query, args := d.ViewsQuery(schema)
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query views: %v", err)
+	}
+	defer rows.Close()
+
+	views := make([]View, 0)
+	for rows.Next() {
+		var view View
+		if err := rows.Scan(&view.Name, &view.Definition); err != nil {
+			return nil, fmt.Errorf("scan error: %v", err)
+		}
+		views = append(views, view)
+	}
+
+	return views, rows.Err()
+}
+
+func Routines(ctx context.Context, db *sql.DB, d dialect.Dialect, schema string) ([]Routine, error) {
+	routines := make([]Routine, 0)
+	query, args := d.RoutinesQuery(schema)
+	if query == "" {
+		return routines, nil
+	}
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query routines: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var routine Routine
+		if err := rows.Scan(&routine.Name, &routine.Kind, &routine.Arguments, &routine.Definition); err != nil {
+			return nil, fmt.Errorf("scan error: %v", err)
+		}
+		routines = append(routines, routine)
+	}
+
+	return routines, rows.Err()
+}
+
+// parseColumnList decodes the JSON array of names the catalog queries
+// aggregate column lists into.
+func parseColumnList(s string) ([]string, error) {
+	columns := make([]string, 0)
+	if s == "" {
+		return columns, nil
+	}
+	if err := json.Unmarshal([]byte(s), &columns); err != nil {
+		return nil, fmt.Errorf("invalid column list %q: %v", s, err)
+	}
+	return columns, nil
+}
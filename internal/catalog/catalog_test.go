@@ -0,0 +1,77 @@
+package catalog
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/AbdelilahOu/DBMcp/internal/dialect"
+	_ "modernc.org/sqlite"
+)
+
+func TestColumnListsKeepCommas(t *testing.T) {
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "catalog.db"))
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	for _, stmt := range []string{
+		`CREATE TABLE parent ("a,b" integer, c integer, PRIMARY KEY ("a,b", c))`,
+		`CREATE TABLE child (id integer PRIMARY KEY, "x,y" integer, z integer,
+			FOREIGN KEY ("x,y", z) REFERENCES parent ("a,b", c))`,
+		`CREATE INDEX child_xy_z ON child (z, "x,y")`,
+	} {
+		if _, err := db.ExecContext(ctx, stmt); err != nil {
+			t.Fatalf("%s: %v", stmt, err)
+		}
+	}
+
+	d := dialect.SQLite{}
+	indexes, err := Indexes(ctx, db, d, "main", "child")
+	if err != nil {
+		t.Fatalf("Indexes: %v", err)
+	}
+	if len(indexes) != 1 || !reflect.DeepEqual(indexes[0].Columns, []string{"z", "x,y"}) {
+		t.Errorf("indexes = %+v, want child_xy_z on [z x,y]", indexes)
+	}
+
+	foreignKeys, err := ForeignKeys(ctx, db, d, "main", "child")
+	if err != nil {
+		t.Fatalf("ForeignKeys: %v", err)
+	}
+	if len(foreignKeys) != 1 {
+		t.Fatalf("got %d foreign keys, want 1", len(foreignKeys))
+	}
+	fk := foreignKeys[0]
+	if !reflect.DeepEqual(fk.Columns, []string{"x,y", "z"}) || !reflect.DeepEqual(fk.ReferencedColumns, []string{"a,b", "c"}) {
+		t.Errorf("foreign key maps %v to %v, want [x,y z] to [a,b c]", fk.Columns, fk.ReferencedColumns)
+	}
+}
+
+func TestParseColumnList(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []string
+	}{
+		{"", []string{}},
+		{`["a"]`, []string{"a"}},
+		{`["a,b", "(expression)", "c\"d"]`, []string{"a,b", "(expression)", `c"d`}},
+	}
+	for _, tt := range tests {
+		got, err := parseColumnList(tt.in)
+		if err != nil {
+			t.Errorf("parseColumnList(%q): %v", tt.in, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseColumnList(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+	if _, err := parseColumnList("a,b"); err == nil {
+		t.Error("parseColumnList accepted a comma-joined list")
+	}
+}
@@ -0,0 +1,489 @@
+package catalog
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/AbdelilahOu/DBMcp/internal/dialect"
+)
+
+// DDL returns a script that brings target in line with source. Changes the
+// engine cannot make in place, such as altering a column on SQLite, show
+// up as SQL comments. Both schemas must come from the engine of d.
+//
+// Statements are ordered so that dependencies hold: views and foreign keys
+// are dropped first and created last, and new tables are created before
+// old ones are dropped.
+func DDL(d dialect.Dialect, source, target *Schema) (string, error) {
+	if source.Dialect != target.Dialect || source.Dialect != d.Name() {
+		return "", fmt.Errorf("cannot generate DDL between a %s and a %s schema", source.Dialect, target.Dialect)
+	}
+
+	m := &migration{d: d, c: comparer{source: source, target: target}, source: source, target: target}
+	pairs := matchTables(source.Tables, target.Tables)
+
+	m.dropViews()
+	for _, pair := range pairs {
+		if pair[1] != nil {
+			m.dropForeignKeys(pair[0], pair[1])
+		}
+	}
+	for _, pair := range pairs {
+		switch {
+		case pair[1] == nil:
+			m.createTable(pair[0])
+		case pair[0] != nil:
+			m.alterTable(pair[0], pair[1])
+		}
+	}
+	for _, pair := range pairs {
+		if pair[0] == nil {
+			m.add("DROP TABLE " + m.table(pair[1].Name))
+		}
+	}
+	for _, pair := range pairs {
+		if pair[0] != nil {
+			m.addForeignKeys(pair[0], pair[1])
+		}
+	}
+	m.createViews()
+	m.syncRoutines()
+
+	return m.script(), nil
+}
+
+type migration struct {
+	d              dialect.Dialect
+	c              comparer
+	source, target *Schema
+	statements     []string
+}
+
+func (m *migration) add(statements ...string) {
+	for _, s := range statements {
+		if s != "" {
+			m.statements = append(m.statements, s)
+		}
+	}
+}
+
+func (m *migration) note(format string, args ...interface{}) {
+	m.statements = append(m.statements, "-- "+fmt.Sprintf(format, args...))
+}
+
+// addOr adds statement, or the note when the engine has no statement for
+// the change.
+func (m *migration) addOr(statement string, format string, args ...interface{}) {
+	if statement == "" {
+		m.note(format, args...)
+		return
+	}
+	m.add(statement)
+}
+
+func (m *migration) script() string {
+	var b strings.Builder
+	for _, s := range m.statements {
+		b.WriteString(s)
+		if !strings.HasPrefix(s, "--") {
+			b.WriteString(";")
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+func (m *migration) table(name string) string {
+	return m.d.QualifiedName(m.target.Name, name)
+}
+
+// retarget rewrites a source definition for the target schema.
+func (m *migration) retarget(definition string) string {
+	definition = strings.TrimRight(strings.TrimSpace(definition), ";")
+	if m.source.Name == m.target.Name {
+		return definition
+	}
+	return m.source.unqualify(definition)
+}
+
+// inlineForeignKeys reports whether foreign keys have to be declared in
+// CREATE TABLE because the engine cannot add them afterwards.
+func (m *migration) inlineForeignKeys() bool {
+	return m.d.AddConstraintDDL(m.target.Name, "t", "c") == ""
+}
+
+func (m *migration) dropViews() {
+	sources := make(map[string]View, len(m.source.Views))
+	for _, v := range m.source.Views {
+		sources[v.Name] = v
+	}
+	for _, v := range m.target.Views {
+		if s, ok := sources[v.Name]; !ok || len(m.c.viewFields(s, v)) > 0 {
+			m.add("DROP VIEW " + m.table(v.Name))
+		}
+	}
+}
+
+func (m *migration) createViews() {
+	targets := make(map[string]View, len(m.target.Views))
+	for _, v := range m.target.Views {
+		targets[v.Name] = v
+	}
+	for _, v := range m.source.Views {
+		if t, ok := targets[v.Name]; ok && len(m.c.viewFields(v, t)) == 0 {
+			continue
+		}
+		// SQLite keeps the whole CREATE VIEW statement.
+		definition := m.retarget(v.Definition)
+		if !hasPrefixFold(definition, "CREATE") {
+			definition = "CREATE VIEW " + m.table(v.Name) + " AS " + definition
+		}
+		m.add(definition)
+	}
+}
+
+// dropForeignKeys drops the foreign keys of target table t that source
+// table s lacks or declares differently. s is nil for a table that is
+// dropped altogether; its foreign keys go first so tables can be dropped
+// in any order.
+func (m *migration) dropForeignKeys(s, t *Table) {
+	sources := make(map[string]ForeignKey)
+	if s != nil {
+		for _, fk := range s.ForeignKeys {
+			sources[foreignKeyKey(fk)] = fk
+		}
+	}
+	for _, fk := range t.ForeignKeys {
+		if sfk, ok := sources[foreignKeyKey(fk)]; ok && len(m.c.foreignKeyFields(sfk, fk)) == 0 {
+			continue
+		}
+		drop := m.d.DropConstraintDDL(m.target.Name, t.Name, fk.Name, "FOREIGN KEY")
+		if s == nil {
+			m.add(drop)
+		} else {
+			m.addOr(drop, "%s: drop foreign key %s by rebuilding the table", t.Name, fk.Name)
+		}
+	}
+}
+
+// addForeignKeys adds the foreign keys of source table s that target table
+// t lacks or declares differently. t is nil for a table created by this
+// script.
+func (m *migration) addForeignKeys(s, t *Table) {
+	if t == nil && m.inlineForeignKeys() {
+		return
+	}
+	targets := make(map[string]ForeignKey)
+	if t != nil {
+		for _, fk := range t.ForeignKeys {
+			targets[foreignKeyKey(fk)] = fk
+		}
+	}
+	for _, fk := range s.ForeignKeys {
+		if tfk, ok := targets[foreignKeyKey(fk)]; ok && len(m.c.foreignKeyFields(fk, tfk)) == 0 {
+			continue
+		}
+		m.addOr(m.d.AddConstraintDDL(m.target.Name, s.Name, m.foreignKeyClause(fk)),
+			"%s: add foreign key %s by rebuilding the table", s.Name, fk.Name)
+	}
+}
+
+func (m *migration) createTable(s *Table) {
+	lines := make([]string, 0, len(s.Columns)+2)
+	for _, c := range s.Columns {
+		m.createSequence(c)
+		lines = append(lines, m.columnDefinition(c))
+	}
+	if pk := primaryKey(s); len(pk) > 0 {
+		lines = append(lines, "PRIMARY KEY ("+m.columnList(pk)+")")
+	}
+	for _, c := range s.CheckConstraints {
+		lines = append(lines, m.checkClause(c))
+	}
+	if m.inlineForeignKeys() {
+		for _, fk := range s.ForeignKeys {
+			lines = append(lines, m.foreignKeyClause(fk))
+		}
+	}
+	m.add("CREATE TABLE " + m.table(s.Name) + " (\n\t" + strings.Join(lines, ",\n\t") + "\n)")
+	for _, c := range s.Columns {
+		m.ownSequence(s.Name, c)
+	}
+
+	for _, idx := range secondaryIndexes(s.Indexes) {
+		m.createIndex(s.Name, idx)
+	}
+}
+
+func (m *migration) alterTable(s, t *Table) {
+	sourceIndexes := indexesByName(secondaryIndexes(s.Indexes))
+	targetIndexes := indexesByName(secondaryIndexes(t.Indexes))
+	sourceChecks := checksByName(s.CheckConstraints)
+	targetChecks := checksByName(t.CheckConstraints)
+
+	for _, idx := range secondaryIndexes(t.Indexes) {
+		if si, ok := sourceIndexes[idx.Name]; !ok || len(m.c.indexFields(si, idx)) > 0 {
+			m.dropIndex(t.Name, idx)
+		}
+	}
+	for _, c := range t.CheckConstraints {
+		if sc, ok := sourceChecks[c.Name]; !ok || len(m.c.checkFields(sc, c)) > 0 {
+			m.addOr(m.d.DropConstraintDDL(m.target.Name, t.Name, c.Name, "CHECK"),
+				"%s: drop check constraint %s by rebuilding the table", t.Name, c.Name)
+		}
+	}
+
+	sourcePK, targetPK := primaryKey(s), primaryKey(t)
+	pkChanged := strings.Join(sourcePK, ",") != strings.Join(targetPK, ",")
+	if pkChanged && len(targetPK) > 0 {
+		m.addOr(m.d.DropConstraintDDL(m.target.Name, t.Name, primaryKeyName(t), "PRIMARY KEY"),
+			"%s: drop the primary key (%s) by rebuilding the table", t.Name, strings.Join(targetPK, ", "))
+	}
+
+	targetColumns := make(map[string]Column, len(t.Columns))
+	for _, c := range t.Columns {
+		targetColumns[c.Name] = c
+	}
+	sourceColumns := make(map[string]bool, len(s.Columns))
+	for _, c := range s.Columns {
+		sourceColumns[c.Name] = true
+		tc, ok := targetColumns[c.Name]
+		if !ok {
+			m.createSequence(c)
+			m.add("ALTER TABLE " + m.table(t.Name) + " ADD COLUMN " + m.columnDefinition(c))
+			m.ownSequence(t.Name, c)
+			continue
+		}
+		if !columnDefinitionChanged(m.c.columnFields(c, tc)) {
+			continue
+		}
+		alter := m.d.AlterColumnDDL(m.target.Name, t.Name, c.Name, columnTypeOf(c), c.IsNullable, c.DefaultValue)
+		if len(alter) == 0 {
+			m.note("%s: change column %s to %s by rebuilding the table", t.Name, c.Name, m.d.ColumnDefinition(c.Name, columnTypeOf(c), c.IsNullable, c.DefaultValue))
+		}
+		m.add(alter...)
+	}
+	for _, c := range t.Columns {
+		if !sourceColumns[c.Name] {
+			m.add("ALTER TABLE " + m.table(t.Name) + " DROP COLUMN " + m.d.QuoteIdentifier(c.Name))
+		}
+	}
+
+	if pkChanged && len(sourcePK) > 0 {
+		m.addOr(m.d.AddConstraintDDL(m.target.Name, t.Name, "PRIMARY KEY ("+m.columnList(sourcePK)+")"),
+			"%s: add the primary key (%s) by rebuilding the table", t.Name, strings.Join(sourcePK, ", "))
+	}
+	for _, c := range s.CheckConstraints {
+		if tc, ok := targetChecks[c.Name]; !ok || len(m.c.checkFields(c, tc)) > 0 {
+			m.addOr(m.d.AddConstraintDDL(m.target.Name, t.Name, m.checkClause(c)),
+				"%s: add check constraint %s by rebuilding the table", t.Name, c.Name)
+		}
+	}
+	for _, idx := range secondaryIndexes(s.Indexes) {
+		if ti, ok := targetIndexes[idx.Name]; !ok || len(m.c.indexFields(idx, ti)) > 0 {
+			m.createIndex(t.Name, idx)
+		}
+	}
+}
+
+// columnDefinition declares c with its identity clause, if any.
+func (m *migration) columnDefinition(c Column) string {
+	columnType := columnTypeOf(c)
+	if c.Identity != "" {
+		columnType += " " + c.Identity
+	}
+	return m.d.ColumnDefinition(c.Name, columnType, c.IsNullable, c.DefaultValue)
+}
+
+// createSequence creates the sequence a serial column's default draws
+// from, which has to exist before the column.
+func (m *migration) createSequence(c Column) {
+	if c.Sequence != "" {
+		m.add("CREATE SEQUENCE IF NOT EXISTS " + m.table(c.Sequence))
+	}
+}
+
+// ownSequence ties a serial column's sequence to the column, so dropping
+// one drops the other as in the source.
+func (m *migration) ownSequence(table string, c Column) {
+	if c.Sequence != "" {
+		m.add("ALTER SEQUENCE " + m.table(c.Sequence) + " OWNED BY " + m.table(table) + "." + m.d.QuoteIdentifier(c.Name))
+	}
+}
+
+// createIndex uses the index definition the source reported, and builds
+// one from the column list otherwise (MySQL). Indexes backing a unique
+// constraint are added as the constraint.
+func (m *migration) createIndex(table string, idx Index) {
+	if strings.HasPrefix(idx.Name, "sqlite_autoindex_") {
+		m.note("%s: unique constraint on (%s) is part of the table definition; rebuild the table to add it", table, strings.Join(idx.Columns, ", "))
+		return
+	}
+	switch idx.Constraint {
+	case "UNIQUE":
+		m.add(m.d.AddConstraintDDL(m.target.Name, table, "CONSTRAINT "+m.d.QuoteIdentifier(idx.Name)+" UNIQUE ("+m.columnList(idx.Columns)+")"))
+		return
+	case "EXCLUDE":
+		m.note("%s: add exclusion constraint %s from get_ddl on the source", table, idx.Name)
+		return
+	}
+	if idx.Definition != "" {
+		m.add(m.retarget(idx.Definition))
+		return
+	}
+	if idx.IsExpression {
+		m.note("%s: create expression index %s from SHOW CREATE TABLE on the source", table, idx.Name)
+		return
+	}
+	create := "CREATE INDEX "
+	if idx.IsUnique {
+		create = "CREATE UNIQUE INDEX "
+	}
+	m.add(create + m.d.QuoteIdentifier(idx.Name) + " ON " + m.table(table) + " (" + m.columnList(idx.Columns) + ")")
+}
+
+func (m *migration) dropIndex(table string, idx Index) {
+	if strings.HasPrefix(idx.Name, "sqlite_autoindex_") {
+		m.note("%s: unique constraint on (%s) is part of the table definition; rebuild the table to drop it", table, strings.Join(idx.Columns, ", "))
+		return
+	}
+	// The index goes with its constraint; DROP INDEX would be refused.
+	if idx.Constraint != "" {
+		m.add(m.d.DropConstraintDDL(m.target.Name, table, idx.Name, idx.Constraint))
+		return
+	}
+	m.add(m.d.DropIndexDDL(m.target.Name, table, idx.Name))
+}
+
+// syncRoutines drops the routines target has beyond source and creates
+// the missing and changed ones from their source definition. Only
+// Postgres reports the full CREATE statement; elsewhere it is a note.
+func (m *migration) syncRoutines() {
+	sources := make(map[string]Routine, len(m.source.Routines))
+	for _, r := range m.source.Routines {
+		sources[r.Signature()] = r
+	}
+	targets := make(map[string]Routine, len(m.target.Routines))
+	for _, r := range m.target.Routines {
+		targets[r.Signature()] = r
+	}
+
+	for _, r := range m.target.Routines {
+		if _, ok := sources[r.Signature()]; !ok {
+			m.addOr(m.d.DropRoutineDDL(m.target.Name, r.Kind, r.Name, r.Arguments),
+				"drop %s %s", strings.ToLower(r.Kind), r.Signature())
+		}
+	}
+	for _, r := range m.source.Routines {
+		if t, ok := targets[r.Signature()]; ok && len(m.c.routineFields(r, t)) == 0 {
+			continue
+		}
+		definition := m.retarget(r.Definition)
+		if !hasPrefixFold(definition, "CREATE") {
+			m.note("%s %s: recreate it from SHOW CREATE %s on the source", strings.ToLower(r.Kind), r.Signature(), r.Kind)
+			continue
+		}
+		m.add(definition)
+	}
+}
+
+func (m *migration) columnList(columns []string) string {
+	quoted := make([]string, len(columns))
+	for i, c := range columns {
+		quoted[i] = m.d.QuoteIdentifier(c)
+	}
+	return strings.Join(quoted, ", ")
+}
+
+func (m *migration) foreignKeyClause(fk ForeignKey) string {
+	// SQLite only takes a bare table name, in the same schema.
+	referenced := m.d.QuoteIdentifier(fk.ReferencedTable)
+	if !m.inlineForeignKeys() {
+		referenced = m.table(fk.ReferencedTable)
+		if fk.ReferencedSchema != m.source.Name {
+			referenced = m.d.QualifiedName(fk.ReferencedSchema, fk.ReferencedTable)
+		}
+	}
+	clause := fmt.Sprintf("FOREIGN KEY (%s) REFERENCES %s (%s)", m.columnList(fk.Columns), referenced, m.columnList(fk.ReferencedColumns))
+	if fk.OnUpdate != "" && fk.OnUpdate != "NO ACTION" {
+		clause += " ON UPDATE " + fk.OnUpdate
+	}
+	if fk.OnDelete != "" && fk.OnDelete != "NO ACTION" {
+		clause += " ON DELETE " + fk.OnDelete
+	}
+	if strings.HasPrefix(fk.Name, "fk_") && m.inlineForeignKeys() {
+		// Generated SQLite names; the engine would not keep them anyway.
+		return clause
+	}
+	return "CONSTRAINT " + m.d.QuoteIdentifier(fk.Name) + " " + clause
+}
+
+// checkClause accepts both the CHECK (...) form Postgres reports and the
+// bare expression MySQL does.
+func (m *migration) checkClause(c CheckConstraint) string {
+	expression := c.Expression
+	if !hasPrefixFold(expression, "CHECK") {
+		expression = "CHECK (" + expression + ")"
+	}
+	return "CONSTRAINT " + m.d.QuoteIdentifier(c.Name) + " " + expression
+}
+
+// primaryKey lists the primary key columns in key order, which the index
+// backing the key reports. Without one (SQLite rowid tables) it falls back
+// to column order.
+func primaryKey(t *Table) []string {
+	for _, idx := range t.Indexes {
+		if idx.IsPrimary && !idx.IsExpression {
+			return idx.Columns
+		}
+	}
+	var columns []string
+	for _, c := range t.Columns {
+		if c.IsPrimaryKey {
+			columns = append(columns, c.Name)
+		}
+	}
+	return columns
+}
+
+// primaryKeyName is the name of the index backing the primary key, which
+// on Postgres is also the constraint's.
+func primaryKeyName(t *Table) string {
+	for _, idx := range t.Indexes {
+		if idx.IsPrimary {
+			return idx.Name
+		}
+	}
+	return t.Name + "_pkey"
+}
+
+// columnDefinitionChanged reports whether fields hold more than a primary
+// key change, which is made on the table instead.
+func columnDefinitionChanged(fields []FieldDiff) bool {
+	for _, f := range fields {
+		if f.Field != "primary_key" {
+			return true
+		}
+	}
+	return false
+}
+
+func indexesByName(indexes []Index) map[string]Index {
+	byName := make(map[string]Index, len(indexes))
+	for _, idx := range indexes {
+		byName[idx.Name] = idx
+	}
+	return byName
+}
+
+func checksByName(checks []CheckConstraint) map[string]CheckConstraint {
+	byName := make(map[string]CheckConstraint, len(checks))
+	for _, c := range checks {
+		byName[c.Name] = c
+	}
+	return byName
+}
+
+func hasPrefixFold(s, prefix string) bool {
+	return len(s) >= len(prefix) && strings.EqualFold(s[:len(prefix)], prefix)
+}
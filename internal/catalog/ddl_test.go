@@ -0,0 +1,89 @@
+package catalog
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/AbdelilahOu/DBMcp/internal/dialect"
+)
+
+func TestDDLCreateTable(t *testing.T) {
+	source := &Schema{Name: "public", Dialect: "postgres", Tables: []Table{{
+		Name: "line_items",
+		Columns: []Column{
+			{Name: "a", ColumnType: "integer", IsPrimaryKey: true},
+			{Name: "b", ColumnType: "integer", IsPrimaryKey: true},
+			{Name: "id", ColumnType: "bigint", Identity: "GENERATED ALWAYS AS IDENTITY"},
+			{Name: "seq", ColumnType: "integer", DefaultValue: "nextval('line_items_seq_seq'::regclass)", Sequence: "line_items_seq_seq"},
+		},
+		Indexes: []Index{{Name: "line_items_pkey", Columns: []string{"b", "a"}, IsUnique: true, IsPrimary: true}},
+	}}}
+	target := &Schema{Name: "public", Dialect: "postgres"}
+
+	script, err := DDL(dialect.Postgres{}, source, target)
+	if err != nil {
+		t.Fatalf("DDL: %v", err)
+	}
+	for _, want := range []string{
+		`CREATE SEQUENCE IF NOT EXISTS "public"."line_items_seq_seq";`,
+		`"id" bigint GENERATED ALWAYS AS IDENTITY NOT NULL`,
+		`PRIMARY KEY ("b", "a")`,
+		`ALTER SEQUENCE "public"."line_items_seq_seq" OWNED BY "public"."line_items"."seq";`,
+	} {
+		if !strings.Contains(script, want) {
+			t.Errorf("script lacks %s:\n%s", want, script)
+		}
+	}
+	if strings.Index(script, "CREATE SEQUENCE") > strings.Index(script, "CREATE TABLE") {
+		t.Errorf("sequence is created after the table that uses it:\n%s", script)
+	}
+}
+
+func TestDDLDropsConstraintIndexes(t *testing.T) {
+	columns := []Column{{Name: "email", ColumnType: "text"}}
+	source := &Schema{Name: "public", Dialect: "postgres", Tables: []Table{{Name: "users", Columns: columns}}}
+	target := &Schema{Name: "public", Dialect: "postgres", Tables: []Table{{
+		Name:    "users",
+		Columns: columns,
+		Indexes: []Index{
+			{Name: "users_email_key", Columns: []string{"email"}, IsUnique: true, Constraint: "UNIQUE"},
+			{Name: "users_email_idx", Columns: []string{"email"}},
+		},
+	}}}
+
+	script, err := DDL(dialect.Postgres{}, source, target)
+	if err != nil {
+		t.Fatalf("DDL: %v", err)
+	}
+	for _, want := range []string{
+		`ALTER TABLE "public"."users" DROP CONSTRAINT "users_email_key";`,
+		`DROP INDEX "public"."users_email_idx";`,
+	} {
+		if !strings.Contains(script, want) {
+			t.Errorf("script lacks %s:\n%s", want, script)
+		}
+	}
+	if strings.Contains(script, `DROP INDEX "public"."users_email_key"`) {
+		t.Errorf("script drops a constraint's index directly:\n%s", script)
+	}
+}
+
+func TestDDLPrimaryKeyOrderChange(t *testing.T) {
+	columns := []Column{
+		{Name: "a", ColumnType: "integer", IsPrimaryKey: true},
+		{Name: "b", ColumnType: "integer", IsPrimaryKey: true},
+	}
+	table := func(order ...string) Table {
+		return Table{Name: "t", Columns: columns, Indexes: []Index{{Name: "t_pkey", Columns: order, IsUnique: true, IsPrimary: true}}}
+	}
+	source := &Schema{Name: "public", Dialect: "postgres", Tables: []Table{table("b", "a")}}
+	target := &Schema{Name: "public", Dialect: "postgres", Tables: []Table{table("a", "b")}}
+
+	script, err := DDL(dialect.Postgres{}, source, target)
+	if err != nil {
+		t.Fatalf("DDL: %v", err)
+	}
+	if !strings.Contains(script, `ADD PRIMARY KEY ("b", "a")`) {
+		t.Errorf("script does not rebuild the primary key in key order:\n%s", script)
+	}
+}
@@ -0,0 +1,293 @@
+package catalog
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Diff statuses.
+const (
+	OnlyInSource = "only_in_source"
+	OnlyInTarget = "only_in_target"
+	Changed      = "changed"
+)
+
+// Change statuses, used by Changes in place of OnlyInTarget and
+// OnlyInSource.
+const (
+	Added   = "added"
+	Removed = "removed"
+)
+
+// FieldDiff is one property that differs between the source and target
+// version of an object.
+type FieldDiff struct {
+	Field  string `json:"field" jsonschema_description:"Property that differs"`
+	Source string `json:"source" jsonschema_description:"Value in the source schema"`
+	Target string `json:"target" jsonschema_description:"Value in the target schema"`
+}
+
+// ObjectDiff is a column, index, constraint, view or routine that is
+// missing on one side or differs.
+type ObjectDiff struct {
+	Name   string      `json:"name" jsonschema_description:"Object name"`
+	Status string      `json:"status" jsonschema_description:"only_in_source, only_in_target or changed"`
+	Fields []FieldDiff `json:"fields,omitempty" jsonschema_description:"Properties that differ, for changed objects"`
+}
+
+type TableDiff struct {
+	Name             string       `json:"name" jsonschema_description:"Table name"`
+	Status           string       `json:"status" jsonschema_description:"only_in_source, only_in_target or changed"`
+	Fields           []FieldDiff  `json:"fields,omitempty" jsonschema_description:"Table properties that differ: primary_key, the key columns in key order"`
+	Columns          []ObjectDiff `json:"columns,omitempty" jsonschema_description:"Column differences (type, nullability, default, primary key)"`
+	Indexes          []ObjectDiff `json:"indexes,omitempty" jsonschema_description:"Index differences, by index name"`
+	ForeignKeys      []ObjectDiff `json:"foreign_keys,omitempty" jsonschema_description:"Foreign key differences, matched on columns and referenced table"`
+	CheckConstraints []ObjectDiff `json:"check_constraints,omitempty" jsonschema_description:"Check constraint differences, by name"`
+}
+
+type Diff struct {
+	Tables   []TableDiff  `json:"tables" jsonschema_description:"Tables that are missing on one side or differ"`
+	Views    []ObjectDiff `json:"views" jsonschema_description:"Views that are missing on one side or differ"`
+	Routines []ObjectDiff `json:"routines" jsonschema_description:"Functions and procedures that are missing on one side or differ, by signature"`
+}
+
+// Empty reports whether the schemas compared equal.
+func (d *Diff) Empty() bool {
+	return len(d.Tables) == 0 && len(d.Views) == 0 && len(d.Routines) == 0
+}
+
+// Compare lists what differs between source and target. Objects are
+// matched by name, except foreign keys, whose names are often generated:
+// those are matched on their columns and referenced table. Primary key
+// indexes are compared as the table's primary_key field, in key order.
+func Compare(source, target *Schema) *Diff {
+	c := comparer{source: source, target: target}
+	diff := &Diff{
+		Tables:   make([]TableDiff, 0),
+		Views:    make([]ObjectDiff, 0),
+		Routines: make([]ObjectDiff, 0),
+	}
+
+	for _, pair := range matchTables(source.Tables, target.Tables) {
+		s, t := pair[0], pair[1]
+		switch {
+		case t == nil:
+			diff.Tables = append(diff.Tables, TableDiff{Name: s.Name, Status: OnlyInSource})
+		case s == nil:
+			diff.Tables = append(diff.Tables, TableDiff{Name: t.Name, Status: OnlyInTarget})
+		default:
+			td := TableDiff{
+				Name:             s.Name,
+				Status:           Changed,
+				Fields:           c.tableFields(s, t),
+				Columns:          compareObjects(s.Columns, t.Columns, columnKey, columnKey, c.columnFields),
+				Indexes:          compareObjects(secondaryIndexes(s.Indexes), secondaryIndexes(t.Indexes), indexKey, indexKey, c.indexFields),
+				ForeignKeys:      compareObjects(s.ForeignKeys, t.ForeignKeys, foreignKeyKey, foreignKeyName, c.foreignKeyFields),
+				CheckConstraints: compareObjects(s.CheckConstraints, t.CheckConstraints, checkKey, checkKey, c.checkFields),
+			}
+			if len(td.Fields)+len(td.Columns)+len(td.Indexes)+len(td.ForeignKeys)+len(td.CheckConstraints) > 0 {
+				diff.Tables = append(diff.Tables, td)
+			}
+		}
+	}
+
+	diff.Views = compareObjects(source.Views, target.Views, viewKey, viewKey, c.viewFields)
+	diff.Routines = compareObjects(source.Routines, target.Routines, Routine.Signature, Routine.Signature, c.routineFields)
+	return diff
+}
+
+// Changes compares an older and a newer version of a schema. Objects are
+// added, removed or changed; in field differences, source is the older
+// value and target the newer one.
+func Changes(older, newer *Schema) *Diff {
+	diff := Compare(older, newer)
+	for i := range diff.Tables {
+		t := &diff.Tables[i]
+		t.Status = changeStatus(t.Status)
+		relabel(t.Columns)
+		relabel(t.Indexes)
+		relabel(t.ForeignKeys)
+		relabel(t.CheckConstraints)
+	}
+	relabel(diff.Views)
+	relabel(diff.Routines)
+	return diff
+}
+
+func relabel(diffs []ObjectDiff) {
+	for i := range diffs {
+		diffs[i].Status = changeStatus(diffs[i].Status)
+	}
+}
+
+func changeStatus(status string) string {
+	switch status {
+	case OnlyInSource:
+		return Removed
+	case OnlyInTarget:
+		return Added
+	}
+	return status
+}
+
+// comparer holds the two schemas so definitions can be compared without
+// the schema names they embed.
+type comparer struct {
+	source, target *Schema
+}
+
+func (c comparer) tableFields(s, t *Table) []FieldDiff {
+	return appendField(nil, "primary_key", strings.Join(primaryKey(s), ", "), strings.Join(primaryKey(t), ", "))
+}
+
+func (c comparer) columnFields(s, t Column) []FieldDiff {
+	var fields []FieldDiff
+	fields = appendField(fields, "type", columnTypeOf(s), columnTypeOf(t))
+	fields = appendField(fields, "nullable", strconv.FormatBool(s.IsNullable), strconv.FormatBool(t.IsNullable))
+	fields = appendField(fields, "default", s.DefaultValue, t.DefaultValue)
+	fields = appendField(fields, "primary_key", strconv.FormatBool(s.IsPrimaryKey), strconv.FormatBool(t.IsPrimaryKey))
+	return fields
+}
+
+func (c comparer) indexFields(s, t Index) []FieldDiff {
+	var fields []FieldDiff
+	fields = appendField(fields, "columns", strings.Join(s.Columns, ", "), strings.Join(t.Columns, ", "))
+	fields = appendField(fields, "unique", strconv.FormatBool(s.IsUnique), strconv.FormatBool(t.IsUnique))
+	fields = appendField(fields, "predicate", s.Predicate, t.Predicate)
+	fields = appendField(fields, "constraint", s.Constraint, t.Constraint)
+	if s.Definition != "" && t.Definition != "" {
+		fields = appendField(fields, "definition", c.source.unqualify(s.Definition), c.target.unqualify(t.Definition))
+	}
+	return fields
+}
+
+func (c comparer) foreignKeyFields(s, t ForeignKey) []FieldDiff {
+	var fields []FieldDiff
+	fields = appendField(fields, "referenced_columns", strings.Join(s.ReferencedColumns, ", "), strings.Join(t.ReferencedColumns, ", "))
+	fields = appendField(fields, "on_update", s.OnUpdate, t.OnUpdate)
+	fields = appendField(fields, "on_delete", s.OnDelete, t.OnDelete)
+	return fields
+}
+
+func (c comparer) checkFields(s, t CheckConstraint) []FieldDiff {
+	return appendField(nil, "expression", s.Expression, t.Expression)
+}
+
+func (c comparer) viewFields(s, t View) []FieldDiff {
+	return appendField(nil, "definition", c.source.unqualify(s.Definition), c.target.unqualify(t.Definition))
+}
+
+func (c comparer) routineFields(s, t Routine) []FieldDiff {
+	var fields []FieldDiff
+	fields = appendField(fields, "kind", s.Kind, t.Kind)
+	fields = appendField(fields, "definition", c.source.unqualify(s.Definition), c.target.unqualify(t.Definition))
+	return fields
+}
+
+// appendField adds a FieldDiff when source and target differ, ignoring
+// differences in whitespace.
+func appendField(fields []FieldDiff, field, source, target string) []FieldDiff {
+	if normalizeSpace(source) == normalizeSpace(target) {
+		return fields
+	}
+	return append(fields, FieldDiff{Field: field, Source: source, Target: target})
+}
+
+func normalizeSpace(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// unqualify drops references to the schema itself from a definition, so
+// the same object compares equal in two differently named schemas.
+func (s *Schema) unqualify(definition string) string {
+	if s.Name == "" {
+		return definition
+	}
+	name := regexp.QuoteMeta(s.Name)
+	return regexp.MustCompile(`(\b`+name+`|"`+name+`"|`+"`"+name+"`"+`)\.`).ReplaceAllString(definition, "")
+}
+
+// compareObjects matches source and target objects on key and reports
+// those missing on one side or whose fields differ, in source order then
+// target order.
+func compareObjects[T any](source, target []T, key, name func(T) string, fields func(s, t T) []FieldDiff) []ObjectDiff {
+	targets := make(map[string]T, len(target))
+	for _, t := range target {
+		targets[key(t)] = t
+	}
+
+	var diffs []ObjectDiff
+	seen := make(map[string]bool, len(source))
+	for _, s := range source {
+		k := key(s)
+		seen[k] = true
+		t, ok := targets[k]
+		if !ok {
+			diffs = append(diffs, ObjectDiff{Name: name(s), Status: OnlyInSource})
+		} else if f := fields(s, t); len(f) > 0 {
+			diffs = append(diffs, ObjectDiff{Name: name(s), Status: Changed, Fields: f})
+		}
+	}
+	for _, t := range target {
+		if !seen[key(t)] {
+			diffs = append(diffs, ObjectDiff{Name: name(t), Status: OnlyInTarget})
+		}
+	}
+	if diffs == nil {
+		diffs = make([]ObjectDiff, 0)
+	}
+	return diffs
+}
+
+// matchTables pairs the tables of both schemas by name: source order first,
+// then the tables only in target. One side of a pair is nil when the table
+// is missing there.
+func matchTables(source, target []Table) [][2]*Table {
+	targets := make(map[string]*Table, len(target))
+	for i := range target {
+		targets[target[i].Name] = &target[i]
+	}
+
+	pairs := make([][2]*Table, 0, len(source))
+	for i := range source {
+		pairs = append(pairs, [2]*Table{&source[i], targets[source[i].Name]})
+	}
+	sources := make(map[string]bool, len(source))
+	for _, s := range source {
+		sources[s.Name] = true
+	}
+	for i := range target {
+		if !sources[target[i].Name] {
+			pairs = append(pairs, [2]*Table{nil, &target[i]})
+		}
+	}
+	return pairs
+}
+
+func secondaryIndexes(indexes []Index) []Index {
+	kept := make([]Index, 0, len(indexes))
+	for _, idx := range indexes {
+		if !idx.IsPrimary {
+			kept = append(kept, idx)
+		}
+	}
+	return kept
+}
+
+func columnTypeOf(c Column) string {
+	if c.ColumnType != "" {
+		return c.ColumnType
+	}
+	return c.DataType
+}
+
+func columnKey(c Column) string          { return c.Name }
+func indexKey(i Index) string            { return i.Name }
+func checkKey(c CheckConstraint) string  { return c.Name }
+func viewKey(v View) string              { return v.Name }
+func foreignKeyName(f ForeignKey) string { return f.Name }
+
+func foreignKeyKey(fk ForeignKey) string {
+	return strings.Join(fk.Columns, ",") + "\x00" + fk.ReferencedTable
+}
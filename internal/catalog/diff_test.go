@@ -0,0 +1,109 @@
+package catalog
+
+import (
+	"reflect"
+	"testing"
+)
+
+func pkTable(order ...string) Table {
+	return Table{
+		Name: "t",
+		Columns: []Column{
+			{Name: "a", ColumnType: "integer", IsPrimaryKey: true},
+			{Name: "b", ColumnType: "integer", IsPrimaryKey: true},
+		},
+		Indexes: []Index{{Name: "t_pkey", Columns: order, IsUnique: true, IsPrimary: true}},
+	}
+}
+
+func TestComparePrimaryKeyOrder(t *testing.T) {
+	source := &Schema{Name: "public", Dialect: "postgres", Tables: []Table{pkTable("b", "a")}}
+	target := &Schema{Name: "public", Dialect: "postgres", Tables: []Table{pkTable("a", "b")}}
+
+	diff := Compare(source, target)
+	if diff.Empty() {
+		t.Fatal("schemas whose primary keys differ in order compare equal")
+	}
+	want := []FieldDiff{{Field: "primary_key", Source: "b, a", Target: "a, b"}}
+	if len(diff.Tables) != 1 || !reflect.DeepEqual(diff.Tables[0].Fields, want) {
+		t.Errorf("tables = %+v, want t with fields %+v", diff.Tables, want)
+	}
+	if len(diff.Tables[0].Columns) != 0 || len(diff.Tables[0].Indexes) != 0 {
+		t.Errorf("primary key order is also reported on columns or indexes: %+v", diff.Tables[0])
+	}
+
+	changes := Changes(target, source)
+	if len(changes.Tables) != 1 || changes.Tables[0].Status != Changed || len(changes.Tables[0].Fields) != 1 {
+		t.Errorf("changes = %+v, want the primary key change", changes.Tables)
+	}
+}
+
+func TestCompare(t *testing.T) {
+	source := &Schema{Name: "app", Dialect: "postgres", Tables: []Table{
+		{
+			Name: "orders",
+			Columns: []Column{
+				{Name: "id", ColumnType: "bigint", IsPrimaryKey: true},
+				{Name: "status", ColumnType: "text"},
+				{Name: "note", ColumnType: "text", IsNullable: true},
+			},
+			Indexes: []Index{
+				{Name: "orders_pkey", Columns: []string{"id"}, IsUnique: true, IsPrimary: true},
+				{Name: "orders_status_idx", Columns: []string{"status"}},
+			},
+			ForeignKeys:      []ForeignKey{{Name: "orders_customer_fkey", Columns: []string{"customer_id"}, ReferencedTable: "customers", ReferencedColumns: []string{"id"}, OnDelete: "CASCADE"}},
+			CheckConstraints: []CheckConstraint{{Name: "orders_status_check", Expression: "status <> ''"}},
+		},
+		{Name: "only_source"},
+	}, Views: []View{{Name: "open_orders", Definition: "SELECT * FROM app.orders WHERE status = 'open'"}}}
+	target := &Schema{Name: "staging", Dialect: "postgres", Tables: []Table{
+		{
+			Name: "orders",
+			Columns: []Column{
+				{Name: "id", ColumnType: "bigint", IsPrimaryKey: true},
+				{Name: "status", ColumnType: "varchar(20)"},
+			},
+			Indexes: []Index{
+				{Name: "orders_pkey", Columns: []string{"id"}, IsUnique: true, IsPrimary: true},
+				{Name: "orders_status_idx", Columns: []string{"status"}, IsUnique: true},
+			},
+			ForeignKeys:      []ForeignKey{{Name: "fk_1", Columns: []string{"customer_id"}, ReferencedTable: "customers", ReferencedColumns: []string{"id"}, OnDelete: "NO ACTION"}},
+			CheckConstraints: []CheckConstraint{{Name: "orders_status_check", Expression: "status  <>  ''"}},
+		},
+		{Name: "only_target"},
+	}, Views: []View{{Name: "open_orders", Definition: "SELECT * FROM staging.orders WHERE status = 'open'"}}}
+
+	diff := Compare(source, target)
+	want := []TableDiff{
+		{
+			Name:   "orders",
+			Status: Changed,
+			Columns: []ObjectDiff{
+				{Name: "status", Status: Changed, Fields: []FieldDiff{{Field: "type", Source: "text", Target: "varchar(20)"}}},
+				{Name: "note", Status: OnlyInSource},
+			},
+			Indexes: []ObjectDiff{
+				{Name: "orders_status_idx", Status: Changed, Fields: []FieldDiff{{Field: "unique", Source: "false", Target: "true"}}},
+			},
+			ForeignKeys: []ObjectDiff{
+				{Name: "orders_customer_fkey", Status: Changed, Fields: []FieldDiff{{Field: "on_delete", Source: "CASCADE", Target: "NO ACTION"}}},
+			},
+			CheckConstraints: []ObjectDiff{},
+		},
+		{Name: "only_source", Status: OnlyInSource},
+		{Name: "only_target", Status: OnlyInTarget},
+	}
+	if !reflect.DeepEqual(diff.Tables, want) {
+		t.Errorf("tables =\n%+v\nwant\n%+v", diff.Tables, want)
+	}
+	if len(diff.Views) != 0 {
+		t.Errorf("views differing only in their schema name are reported: %+v", diff.Views)
+	}
+}
+
+func TestCompareIdentical(t *testing.T) {
+	schema := &Schema{Name: "public", Dialect: "postgres", Tables: []Table{pkTable("a", "b")}}
+	if diff := Compare(schema, schema); !diff.Empty() {
+		t.Errorf("a schema differs from itself: %+v", diff)
+	}
+}
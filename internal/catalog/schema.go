@@ -0,0 +1,73 @@
+package catalog
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/AbdelilahOu/DBMcp/internal/dialect"
+)
+
+// Table is a table with everything describe_table reports about it,
+// except the foreign keys pointing at it.
+type Table struct {
+	Name             string            `json:"name" jsonschema_description:"Table name"`
+	Columns          []Column          `json:"columns" jsonschema_description:"Columns, in order"`
+	Indexes          []Index           `json:"indexes" jsonschema_description:"Indexes"`
+	ForeignKeys      []ForeignKey      `json:"foreign_keys" jsonschema_description:"Foreign keys from this table"`
+	CheckConstraints []CheckConstraint `json:"check_constraints" jsonschema_description:"Check constraints"`
+}
+
+// Schema is the structure of one schema, as compared by Diff.
+type Schema struct {
+	Name     string    `json:"name" jsonschema_description:"Schema name"`
+	Dialect  string    `json:"dialect" jsonschema_description:"Database type"`
+	Tables   []Table   `json:"tables" jsonschema_description:"Tables, by name"`
+	Views    []View    `json:"views" jsonschema_description:"Views, by name"`
+	Routines []Routine `json:"routines" jsonschema_description:"Functions and procedures"`
+}
+
+// Load reads the structure of schema. It makes a few catalog queries per
+// table, so it is meant for whole-schema tools, not per-call lookups.
+func Load(ctx context.Context, db *sql.DB, d dialect.Dialect, schema string) (*Schema, error) {
+	names, err := Tables(ctx, db, d, schema)
+	if err != nil {
+		return nil, err
+	}
+	foreignKeys, err := ForeignKeys(ctx, db, d, schema, "")
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Schema{
+		Name:    schema,
+		Dialect: d.Name(),
+		Tables:  make([]Table, 0, len(names)),
+	}
+	for _, name := range names {
+		t := Table{Name: name, ForeignKeys: make([]ForeignKey, 0)}
+		if t.Columns, err = Columns(ctx, db, d, schema, name); err != nil {
+			return nil, fmt.Errorf("table %s: %v", name, err)
+		}
+		if t.Indexes, err = Indexes(ctx, db, d, schema, name); err != nil {
+			return nil, fmt.Errorf("table %s: %v", name, err)
+		}
+		if t.CheckConstraints, err = CheckConstraints(ctx, db, d, schema, name); err != nil {
+			return nil, fmt.Errorf("table %s: %v", name, err)
+		}
+		for _, fk := range foreignKeys {
+			if fk.Schema == schema && fk.Table == name {
+				t.ForeignKeys = append(t.ForeignKeys, fk)
+			}
+		}
+		s.Tables = append(s.Tables, t)
+	}
+
+	if s.Views, err = Views(ctx, db, d, schema); err != nil {
+		return nil, err
+	}
+	if s.Routines, err = Routines(ctx, db, d, schema); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
@@ -0,0 +1,625 @@
+package classifier
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/AbdelilahOu/DBMcp/internal/dialect"
+)
+
+type Category string
+
+const (
+	Read  Category = "read"
+	DML   Category = "dml"
+	DDL   Category = "ddl"
+	DCL   Category = "dcl"
+	Admin Category = "admin"
+)
+
+type Statement struct {
+	Text     string
+	Keyword  string
+	Category Category
+	// Destructive is set for statements that discard data or objects
+	// wholesale, such as DROP and TRUNCATE.
+	Destructive bool
+}
+
+var leadingKeywords = map[string]Category{
+	"SELECT":   Read,
+	"VALUES":   Read,
+	"TABLE":    Read,
+	"SHOW":     Read,
+	"DESCRIBE": Read,
+	"DESC":     Read,
+	"FETCH":    Read,
+
+	"INSERT":  DML,
+	"UPDATE":  DML,
+	"DELETE":  DML,
+	"MERGE":   DML,
+	"REPLACE": DML,
+	"UPSERT":  DML,
+	// COPY is DML only to and from the client; see classifyCopy.
+	"COPY": DML,
+	// CALL runs whatever the procedure body does, so it is treated as a
+	// write.
+	"CALL":    DML,
+	"HANDLER": DML,
+
+	// DO runs an anonymous code block (Postgres) that can do anything, and
+	// LOAD DATA reads files on the server or client.
+	"DO":   Admin,
+	"LOAD": Admin,
+
+	"CREATE":   DDL,
+	"ALTER":    DDL,
+	"DROP":     DDL,
+	"TRUNCATE": DDL,
+	"RENAME":   DDL,
+	"COMMENT":  DDL,
+	"IMPORT":   DDL,
+	"SECURITY": DDL,
+
+	"GRANT":  DCL,
+	"REVOKE": DCL,
+}
+
+// adminFunctions are functions with effects outside the statement's own
+// transaction: signalling other backends, reloading configuration, clearing
+// server-wide statistics, taking session-level locks, reading or writing
+// files on the database host, or running SQL elsewhere. A read-only
+// transaction does not stop any of them, and a session lock outlives its
+// rollback on the pooled connection, so a statement calling one is Admin
+// whatever its leading keyword.
+var adminFunctions = map[string]bool{
+	"pg_cancel_backend":    true,
+	"pg_terminate_backend": true,
+	"pg_reload_conf":       true,
+	"pg_rotate_logfile":    true,
+	"set_config":           true,
+	"pg_notify":            true,
+
+	"pg_advisory_lock":            true,
+	"pg_advisory_lock_shared":     true,
+	"pg_try_advisory_lock":        true,
+	"pg_try_advisory_lock_shared": true,
+	"pg_advisory_unlock":          true,
+	"pg_advisory_unlock_shared":   true,
+	"pg_advisory_unlock_all":      true,
+	"get_lock":                    true,
+	"release_lock":                true,
+	"release_all_locks":           true,
+
+	"pg_stat_statements_reset":               true,
+	"pg_stat_reset":                          true,
+	"pg_stat_reset_shared":                   true,
+	"pg_stat_reset_single_table_counters":    true,
+	"pg_stat_reset_single_function_counters": true,
+	"pg_stat_reset_slru":                     true,
+	"pg_stat_reset_replication_slot":         true,
+	"pg_stat_reset_subscription_stats":       true,
+	"ps_truncate_all_tables":                 true,
+
+	"lo_import":           true,
+	"lo_export":           true,
+	"pg_read_file":        true,
+	"pg_read_binary_file": true,
+	"pg_ls_dir":           true,
+	"pg_stat_file":        true,
+	"dblink":              true,
+	"dblink_exec":         true,
+	"load_file":           true,
+	"load_extension":      true,
+}
+
+var dclObjects = map[string]bool{
+	"USER":  true,
+	"ROLE":  true,
+	"GROUP": true,
+}
+
+// Classify splits query into statements and labels each one. Comments,
+// string literals, quoted identifiers and dollar-quoted bodies are tokenized
+// following the rules of the given dialect, so their contents never affect
+// the result.
+func Classify(d dialect.Dialect, query string) ([]Statement, error) {
+	tokens, err := tokenize(query, optionsFor(d.Name()))
+	if err != nil {
+		return nil, err
+	}
+
+	split, err := splitStatements(tokens, d.Name())
+	if err != nil {
+		return nil, err
+	}
+
+	var statements []Statement
+	for _, stmt := range split {
+		category, keyword, destructive := classifyTokens(stmt, d.Name())
+		if callsAdminFunction(stmt) {
+			category = Admin
+		}
+		statements = append(statements, Statement{
+			Text:        query[stmt[0].start:stmt[len(stmt)-1].end],
+			Keyword:     keyword,
+			Category:    category,
+			Destructive: destructive,
+		})
+	}
+	return statements, nil
+}
+
+// ClassifySingle is Classify for callers that accept exactly one statement.
+func ClassifySingle(d dialect.Dialect, query string) (Statement, error) {
+	statements, err := Classify(d, query)
+	if err != nil {
+		return Statement{}, fmt.Errorf("failed to parse query: %v", err)
+	}
+	switch len(statements) {
+	case 0:
+		return Statement{}, fmt.Errorf("query is empty")
+	case 1:
+		return statements[0], nil
+	default:
+		return Statement{}, fmt.Errorf("multiple statements are not allowed (found %d); send one statement per call", len(statements))
+	}
+}
+
+// routineKinds are the objects whose body may be a BEGIN ... END block with
+// semicolons inside.
+var routineKinds = map[string]bool{
+	"FUNCTION":  true,
+	"PROCEDURE": true,
+	"TRIGGER":   true,
+	"EVENT":     true,
+}
+
+// splitStatements splits on top-level semicolons. Semicolons inside the
+// BEGIN ... END body of CREATE FUNCTION/PROCEDURE/TRIGGER/EVENT do not end
+// the statement. Postgres quotes routine bodies, so there every semicolon
+// outside a literal ends one. A body left open at the end of the input is an
+// error rather than one long statement.
+func splitStatements(tokens []token, dialectName string) ([][]token, error) {
+	var statements [][]token
+	var current []token
+	blockDepth := 0
+	routine := false
+
+	for i := 0; i < len(tokens); i++ {
+		t := tokens[i]
+		if t.kind == tokenSemicolon && blockDepth == 0 {
+			if len(current) > 0 {
+				statements = append(statements, current)
+			}
+			current = nil
+			continue
+		}
+		if len(current) == 0 {
+			routine = dialectName != "postgres" && isRoutineDefinition(tokens[i:])
+		}
+
+		if routine && t.kind == tokenWord {
+			switch t.upper() {
+			case "BEGIN", "CASE":
+				blockDepth++
+			case "IF", "LOOP", "WHILE", "REPEAT":
+				// IF() and REPEAT() are also functions; as statements they
+				// start a statement of the body.
+				if blockDepth > 0 && startsBodyStatement(tokens[i-1]) {
+					blockDepth++
+				}
+			case "END":
+				if blockDepth > 0 {
+					blockDepth--
+				}
+				// END IF, END LOOP, ... close the block opened above; skip the
+				// trailing word so it does not open a new one.
+				if i+1 < len(tokens) {
+					switch tokens[i+1].upper() {
+					case "IF", "LOOP", "WHILE", "REPEAT", "CASE":
+						current = append(current, t)
+						i++
+						t = tokens[i]
+					}
+				}
+			}
+		}
+
+		current = append(current, t)
+	}
+	if blockDepth != 0 {
+		return nil, fmt.Errorf("unterminated BEGIN ... END block")
+	}
+	if len(current) > 0 {
+		statements = append(statements, current)
+	}
+	return statements, nil
+}
+
+// startsBodyStatement reports whether the token after prev starts a
+// statement inside a routine body.
+func startsBodyStatement(prev token) bool {
+	if prev.kind == tokenSemicolon || prev.isPunct(":") {
+		return true
+	}
+	switch prev.upper() {
+	case "BEGIN", "THEN", "ELSE", "DO", "LOOP", "REPEAT":
+		return prev.kind == tokenWord
+	}
+	return false
+}
+
+// isRoutineDefinition reports whether tokens start with CREATE [OR REPLACE]
+// [DEFINER = user] [TEMP | TEMPORARY | AGGREGATE] followed by one of
+// routineKinds.
+func isRoutineDefinition(tokens []token) bool {
+	if len(tokens) == 0 || !tokens[0].is("CREATE") {
+		return false
+	}
+	i := 1
+	if i+1 < len(tokens) && tokens[i].is("OR") && tokens[i+1].is("REPLACE") {
+		i += 2
+	}
+	// MySQL: DEFINER = 'user'@'host', user@host or CURRENT_USER[()].
+	if i+2 < len(tokens) && tokens[i].is("DEFINER") && tokens[i+1].isPunct("=") {
+		i += 3
+		for i+1 < len(tokens) && tokens[i].isPunct("@") {
+			i += 2
+		}
+		if i+1 < len(tokens) && tokens[i].isPunct("(") && tokens[i+1].isPunct(")") {
+			i += 2
+		}
+	}
+	for i < len(tokens) && (tokens[i].is("TEMP") || tokens[i].is("TEMPORARY") || tokens[i].is("AGGREGATE")) {
+		i++
+	}
+	return i < len(tokens) && tokens[i].kind == tokenWord && routineKinds[tokens[i].upper()]
+}
+
+func classifyTokens(tokens []token, dialectName string) (Category, string, bool) {
+	// Parenthesized queries such as "(SELECT 1) UNION (SELECT 2)".
+	for len(tokens) > 0 && tokens[0].isPunct("(") {
+		tokens = tokens[1:]
+	}
+	if len(tokens) == 0 {
+		return Admin, "", false
+	}
+
+	first := tokens[0]
+	keyword := first.upper()
+	if first.kind != tokenWord {
+		return Admin, keyword, false
+	}
+
+	switch keyword {
+	case "WITH":
+		return classifyWith(tokens, dialectName), keyword, false
+	case "SELECT":
+		return classifySelect(tokens, dialectName), keyword, false
+	case "EXPLAIN":
+		return classifyExplain(tokens, dialectName), keyword, false
+	case "PRAGMA":
+		return classifyPragma(tokens), keyword, false
+	case "CREATE", "ALTER", "DROP":
+		if keyword == "ALTER" && len(tokens) > 2 && tokens[1].is("DEFAULT") && tokens[2].is("PRIVILEGES") {
+			return DCL, keyword, false
+		}
+		for _, t := range tokens[1:] {
+			if t.kind != tokenWord {
+				break
+			}
+			if dclObjects[t.upper()] {
+				return DCL, keyword, keyword == "DROP"
+			}
+			if t.is("TABLE") || t.is("VIEW") || t.is("INDEX") || t.is("SCHEMA") || t.is("DATABASE") {
+				break
+			}
+		}
+		return DDL, keyword, keyword == "DROP"
+	case "TRUNCATE":
+		return DDL, keyword, true
+	case "COPY":
+		return classifyCopy(tokens), keyword, false
+	case "SET":
+		if len(tokens) > 1 && (tokens[1].is("ROLE") || tokens[1].is("PASSWORD") || tokens[1].is("SESSION") && len(tokens) > 2 && tokens[2].is("AUTHORIZATION")) {
+			return DCL, keyword, false
+		}
+		return Admin, keyword, false
+	}
+
+	if category, ok := leadingKeywords[keyword]; ok {
+		return category, keyword, false
+	}
+	return Admin, keyword, false
+}
+
+// classifyWith skips over the common table expressions and classifies the
+// main statement. A data-modifying CTE (Postgres "WITH d AS (DELETE ...)")
+// makes the whole statement DML.
+func classifyWith(tokens []token, dialectName string) Category {
+	i := 1
+	if i < len(tokens) && tokens[i].is("RECURSIVE") {
+		i++
+	}
+
+	worst := Read
+	for i < len(tokens) {
+		// name [ (columns) ] AS [ [NOT] MATERIALIZED ] ( body )
+		i++
+		if i < len(tokens) && tokens[i].isPunct("(") {
+			i = skipParens(tokens, i)
+		}
+		if i >= len(tokens) || !tokens[i].is("AS") {
+			return Admin
+		}
+		i++
+		for i < len(tokens) && (tokens[i].is("NOT") || tokens[i].is("MATERIALIZED")) {
+			i++
+		}
+		if i >= len(tokens) || !tokens[i].isPunct("(") {
+			return Admin
+		}
+		end := skipParens(tokens, i)
+		body := tokens[i+1 : end-1]
+		if len(body) > 0 {
+			if category, _, _ := classifyTokens(body, dialectName); category != Read {
+				worst = category
+			}
+		}
+		i = end
+		if i < len(tokens) && tokens[i].isPunct(",") {
+			i++
+			continue
+		}
+		break
+	}
+
+	if i >= len(tokens) {
+		return Admin
+	}
+	category, _, _ := classifyTokens(tokens[i:], dialectName)
+	if category != Read {
+		return category
+	}
+	return worst
+}
+
+// callsAdminFunction reports whether tokens call one of adminFunctions,
+// bare, schema-qualified or quoted.
+func callsAdminFunction(tokens []token) bool {
+	for i := 0; i+1 < len(tokens); i++ {
+		if !tokens[i+1].isPunct("(") {
+			continue
+		}
+		name := tokens[i].text
+		switch tokens[i].kind {
+		case tokenWord:
+		case tokenQuotedIdent:
+			name = name[1 : len(name)-1]
+		default:
+			continue
+		}
+		if adminFunctions[strings.ToLower(name)] {
+			return true
+		}
+	}
+	return false
+}
+
+// classifySelect catches SELECT ... INTO, which creates a table on Postgres
+// and can write files on MySQL.
+func classifySelect(tokens []token, dialectName string) Category {
+	depth := 0
+	for i, t := range tokens {
+		switch {
+		case t.isPunct("("):
+			depth++
+		case t.isPunct(")"):
+			depth--
+		case depth == 0 && t.is("INTO"):
+			if dialectName == "mysql" {
+				if i+1 < len(tokens) && (tokens[i+1].is("OUTFILE") || tokens[i+1].is("DUMPFILE")) {
+					return Admin
+				}
+				continue
+			}
+			return DDL
+		}
+	}
+	return Read
+}
+
+// readPragmas are the SQLite pragmas that only report something, whatever
+// their argument.
+var readPragmas = map[string]bool{
+	"table_info":        true,
+	"table_xinfo":       true,
+	"table_list":        true,
+	"index_info":        true,
+	"index_xinfo":       true,
+	"index_list":        true,
+	"foreign_key_list":  true,
+	"foreign_key_check": true,
+	"integrity_check":   true,
+	"quick_check":       true,
+	"database_list":     true,
+	"collation_list":    true,
+	"function_list":     true,
+	"module_list":       true,
+	"pragma_list":       true,
+	"compile_options":   true,
+	"data_version":      true,
+	"freelist_count":    true,
+	"page_count":        true,
+	"schema_version":    true,
+}
+
+// settingPragmas report a setting when given no argument and change it when
+// given one, as "PRAGMA journal_mode = WAL" or "PRAGMA journal_mode(WAL)".
+var settingPragmas = map[string]bool{
+	"application_id":      true,
+	"auto_vacuum":         true,
+	"busy_timeout":        true,
+	"cache_size":          true,
+	"case_sensitive_like": true,
+	"encoding":            true,
+	"foreign_keys":        true,
+	"journal_mode":        true,
+	"journal_size_limit":  true,
+	"locking_mode":        true,
+	"max_page_count":      true,
+	"mmap_size":           true,
+	"page_size":           true,
+	"query_only":          true,
+	"recursive_triggers":  true,
+	"secure_delete":       true,
+	"synchronous":         true,
+	"temp_store":          true,
+	"user_version":        true,
+	"wal_autocheckpoint":  true,
+}
+
+// classifyPragma treats PRAGMA [schema.]name as a read only for readPragmas,
+// and for settingPragmas without an argument. Anything else, such as
+// wal_checkpoint, optimize or incremental_vacuum, changes the database.
+func classifyPragma(tokens []token) Category {
+	i := 1
+	if i+1 < len(tokens) && tokens[i+1].isPunct(".") {
+		i += 2
+	}
+	if i >= len(tokens) || tokens[i].kind != tokenWord {
+		return Admin
+	}
+	name := strings.ToLower(tokens[i].text)
+	hasArgument := i+1 < len(tokens)
+	switch {
+	case readPragmas[name] && !(hasArgument && tokens[i+1].isPunct("=")):
+		return Read
+	case settingPragmas[name] && !hasArgument:
+		return Read
+	}
+	return Admin
+}
+
+// classifyCopy treats COPY ... FROM STDIN and COPY ... TO STDOUT as DML.
+// Copying from or to a file or PROGRAM reads, writes or runs something on
+// the database host.
+func classifyCopy(tokens []token) Category {
+	depth := 0
+	for i, t := range tokens {
+		switch {
+		case t.isPunct("("):
+			depth++
+		case t.isPunct(")"):
+			depth--
+		case depth == 0 && (t.is("FROM") || t.is("TO")):
+			if i+1 < len(tokens) && (tokens[i+1].is("STDIN") || tokens[i+1].is("STDOUT")) {
+				return DML
+			}
+			return Admin
+		}
+	}
+	return Admin
+}
+
+// classifyExplain treats a plain EXPLAIN as a read, and EXPLAIN ANALYZE as
+// whatever the explained statement is, since ANALYZE executes it.
+func classifyExplain(tokens []token, dialectName string) Category {
+	i, analyze := explainBody(tokens, dialectName)
+	if !analyze || i >= len(tokens) {
+		return Read
+	}
+	category, _, _ := classifyTokens(tokens[i:], dialectName)
+	return category
+}
+
+// StripExplain returns the statement a leading EXPLAIN applies to, without
+// the EXPLAIN keyword and its options, and whether those options ask for
+// ANALYZE. Other statements are returned as they are.
+func StripExplain(d dialect.Dialect, query string) (string, bool, error) {
+	tokens, err := tokenize(query, optionsFor(d.Name()))
+	if err != nil {
+		return "", false, fmt.Errorf("failed to parse query: %v", err)
+	}
+	if len(tokens) == 0 || !tokens[0].is("EXPLAIN") {
+		return query, false, nil
+	}
+	i, analyze := explainBody(tokens, d.Name())
+	if i >= len(tokens) {
+		return "", false, fmt.Errorf("EXPLAIN is not followed by a statement")
+	}
+	return query[tokens[i].start:], analyze, nil
+}
+
+// explainBody returns the index of the statement an EXPLAIN at tokens[0]
+// applies to, past its options, and whether the options ask for ANALYZE.
+func explainBody(tokens []token, dialectName string) (int, bool) {
+	i := 1
+	analyze := false
+
+	if dialectName == "postgres" && i < len(tokens) && tokens[i].isPunct("(") {
+		end := skipParens(tokens, i)
+		for j := i + 1; j < end-1; j++ {
+			if tokens[j].is("ANALYZE") || tokens[j].is("ANALYSE") {
+				analyze = true
+				if j+1 < end-1 {
+					switch tokens[j+1].upper() {
+					case "FALSE", "OFF", "0":
+						analyze = false
+					}
+				}
+			}
+		}
+		i = end
+	}
+
+	for i < len(tokens) && tokens[i].kind == tokenWord {
+		if _, ok := leadingKeywords[tokens[i].upper()]; ok || tokens[i].is("WITH") {
+			break
+		}
+		if tokens[i].is("ANALYZE") || tokens[i].is("ANALYSE") {
+			analyze = true
+		}
+		i++
+	}
+	// MySQL: EXPLAIN FORMAT=JSON ...
+	for i < len(tokens) && tokens[i].isPunct("=") && i+1 < len(tokens) {
+		i += 2
+		for i < len(tokens) && tokens[i].kind == tokenWord {
+			if _, ok := leadingKeywords[tokens[i].upper()]; ok || tokens[i].is("WITH") {
+				break
+			}
+			i++
+		}
+	}
+	return i, analyze
+}
+
+// skipParens returns the index just past the parenthesis that closes the one
+// at tokens[open].
+func skipParens(tokens []token, open int) int {
+	depth := 0
+	for i := open; i < len(tokens); i++ {
+		if tokens[i].isPunct("(") {
+			depth++
+		} else if tokens[i].isPunct(")") {
+			depth--
+			if depth == 0 {
+				return i + 1
+			}
+		}
+	}
+	return len(tokens)
+}
+
+// IsQuery reports whether the statement returns rows from a plain query, as
+// opposed to SHOW, EXPLAIN or PRAGMA.
+func (s Statement) IsQuery() bool {
+	switch strings.ToUpper(s.Keyword) {
+	case "SELECT", "WITH", "VALUES", "TABLE":
+		return true
+	}
+	return false
+}
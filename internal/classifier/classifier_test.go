@@ -0,0 +1,309 @@
+package classifier
+
+import (
+	"testing"
+
+	"github.com/AbdelilahOu/DBMcp/internal/dialect"
+)
+
+type want struct {
+	keyword     string
+	category    Category
+	destructive bool
+}
+
+func TestClassifySplitsStatements(t *testing.T) {
+	tests := []struct {
+		name    string
+		dialect dialect.Dialect
+		query   string
+		want    []want
+	}{
+		{
+			name:    "column named begin",
+			dialect: dialect.Postgres{},
+			query:   "CREATE TABLE t (begin int); DROP TABLE users",
+			want:    []want{{"CREATE", DDL, false}, {"DROP", DDL, true}},
+		},
+		{
+			name:    "columns named begin and end",
+			dialect: dialect.Postgres{},
+			query:   `CREATE TABLE events (id int, begin timestamptz, "end" timestamptz); DROP DATABASE prod`,
+			want:    []want{{"CREATE", DDL, false}, {"DROP", DDL, true}},
+		},
+		{
+			name:    "column named begin on mysql",
+			dialect: dialect.MySQL{},
+			query:   "CREATE TABLE t (begin int); DROP TABLE users",
+			want:    []want{{"CREATE", DDL, false}, {"DROP", DDL, true}},
+		},
+		{
+			name:    "column named case on sqlite",
+			dialect: dialect.SQLite{},
+			query:   "CREATE TABLE t (`case` int, begin int); DELETE FROM users",
+			want:    []want{{"CREATE", DDL, false}, {"DELETE", DML, false}},
+		},
+		{
+			name:    "mysql procedure body",
+			dialect: dialect.MySQL{},
+			query: `CREATE DEFINER='admin'@'%' PROCEDURE p()
+BEGIN
+	IF (SELECT 1) THEN DELETE FROM t; END IF;
+	WHILE 0 DO SET @x = 1; END WHILE;
+	SELECT CASE WHEN 1 THEN 2 END;
+END; DROP TABLE users`,
+			want: []want{{"CREATE", DDL, false}, {"DROP", DDL, true}},
+		},
+		{
+			name:    "mysql trigger with current_user definer",
+			dialect: dialect.MySQL{},
+			query:   "CREATE DEFINER = CURRENT_USER() TRIGGER trg BEFORE INSERT ON t FOR EACH ROW BEGIN SET NEW.a = 1; END",
+			want:    []want{{"CREATE", DDL, false}},
+		},
+		{
+			name:    "sqlite trigger body",
+			dialect: dialect.SQLite{},
+			query:   "CREATE TEMP TRIGGER trg AFTER INSERT ON t BEGIN UPDATE t SET a = 1; DELETE FROM u; END; SELECT 1",
+			want:    []want{{"CREATE", DDL, false}, {"SELECT", Read, false}},
+		},
+		{
+			name:    "postgres dollar-quoted body",
+			dialect: dialect.Postgres{},
+			query:   "CREATE FUNCTION f() RETURNS void AS $body$ BEGIN DELETE FROM t; END $body$ LANGUAGE plpgsql; SELECT 1",
+			want:    []want{{"CREATE", DDL, false}, {"SELECT", Read, false}},
+		},
+		{
+			name:    "postgres never counts blocks",
+			dialect: dialect.Postgres{},
+			query:   "CREATE FUNCTION f() RETURNS int LANGUAGE sql BEGIN ATOMIC SELECT 1; END",
+			want:    []want{{"CREATE", DDL, false}, {"END", Admin, false}},
+		},
+		{
+			name:    "semicolons in literals and comments",
+			dialect: dialect.Postgres{},
+			query:   "SELECT ';' /* ; */ -- ;\n, $$;$$, \";\" FROM t; ; DELETE FROM t",
+			want:    []want{{"SELECT", Read, false}, {"DELETE", DML, false}},
+		},
+		{
+			name:    "mysql comments",
+			dialect: dialect.MySQL{},
+			query:   "SELECT 1 # ; DROP TABLE t\n; SELECT \"a;b\" -- x; DROP TABLE u",
+			want:    []want{{"SELECT", Read, false}, {"SELECT", Read, false}},
+		},
+		{
+			name:    "mysql executable comment",
+			dialect: dialect.MySQL{},
+			query:   "SELECT 1 /*!50000 ; DROP TABLE users */",
+			want:    []want{{"SELECT", Read, false}, {"DROP", DDL, true}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Classify(tt.dialect, tt.query)
+			if err != nil {
+				t.Fatalf("Classify: %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d statements %+v, want %d", len(got), got, len(tt.want))
+			}
+			for i, w := range tt.want {
+				g := got[i]
+				if g.Keyword != w.keyword || g.Category != w.category || g.Destructive != w.destructive {
+					t.Errorf("statement %d = %s %s destructive=%v, want %s %s destructive=%v",
+						i+1, g.Keyword, g.Category, g.Destructive, w.keyword, w.category, w.destructive)
+				}
+			}
+		})
+	}
+}
+
+func TestClassifyRejectsUnterminatedInput(t *testing.T) {
+	tests := []struct {
+		name    string
+		dialect dialect.Dialect
+		query   string
+	}{
+		{"open trigger body", dialect.MySQL{}, "CREATE TRIGGER trg BEFORE INSERT ON t FOR EACH ROW BEGIN SET NEW.a = 1; DROP TABLE users"},
+		{"open sqlite trigger body", dialect.SQLite{}, "CREATE TRIGGER trg AFTER INSERT ON t BEGIN DELETE FROM u; DROP TABLE users"},
+		{"open string", dialect.Postgres{}, "SELECT 'a; DROP TABLE users"},
+		{"open dollar quote", dialect.Postgres{}, "SELECT $x$ a; DROP TABLE users"},
+		{"open comment", dialect.Postgres{}, "SELECT 1 /* ; DROP TABLE users"},
+		{"open nested comment", dialect.Postgres{}, "SELECT 1 /* /* */ ; DROP TABLE users"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, err := Classify(tt.dialect, tt.query); err == nil {
+				t.Fatalf("Classify = %+v, want an error", got)
+			}
+		})
+	}
+}
+
+func TestClassifyCategories(t *testing.T) {
+	tests := []struct {
+		dialect dialect.Dialect
+		query   string
+		want    Category
+	}{
+		{dialect.Postgres{}, "COPY t FROM STDIN", DML},
+		{dialect.Postgres{}, "COPY t (a, b) FROM stdin WITH (FORMAT csv)", DML},
+		{dialect.Postgres{}, "COPY (SELECT * FROM t WHERE a IN (SELECT a FROM u)) TO STDOUT", DML},
+		{dialect.Postgres{}, "COPY t FROM PROGRAM 'rm -rf /'", Admin},
+		{dialect.Postgres{}, "COPY t TO '/tmp/t.csv'", Admin},
+		{dialect.Postgres{}, "COPY (SELECT 1) TO '/tmp/out'", Admin},
+		{dialect.Postgres{}, "COPY t FROM '/etc/passwd'", Admin},
+		{dialect.Postgres{}, "DO $$ BEGIN EXECUTE 'DROP TABLE users'; END $$", Admin},
+		{dialect.MySQL{}, "DO SLEEP(1)", Admin},
+		{dialect.MySQL{}, "LOAD DATA INFILE '/etc/passwd' INTO TABLE t", Admin},
+		{dialect.Postgres{}, "CALL refresh_totals()", DML},
+		{dialect.MySQL{}, "CALL p(1)", DML},
+		{dialect.Postgres{}, "WITH d AS (DELETE FROM t RETURNING *) SELECT * FROM d", DML},
+		{dialect.Postgres{}, "SELECT * INTO new_t FROM t", DDL},
+		{dialect.MySQL{}, "SELECT * FROM t INTO OUTFILE '/tmp/x'", Admin},
+		{dialect.Postgres{}, "EXPLAIN ANALYZE DELETE FROM t", DML},
+		{dialect.Postgres{}, "EXPLAIN (ANALYZE false) DELETE FROM t", Read},
+		{dialect.Postgres{}, "GRANT SELECT ON t TO bob", DCL},
+		{dialect.Postgres{}, "CREATE ROLE bob", DCL},
+		{dialect.SQLite{}, "PRAGMA table_info(t)", Read},
+		{dialect.SQLite{}, "PRAGMA journal_mode = WAL", Admin},
+		{dialect.SQLite{}, "PRAGMA main.index_list('t')", Read},
+		{dialect.SQLite{}, "PRAGMA foreign_key_list(t)", Read},
+		{dialect.SQLite{}, "PRAGMA journal_mode", Read},
+		{dialect.SQLite{}, "PRAGMA journal_mode(DELETE)", Admin},
+		{dialect.SQLite{}, "PRAGMA main.journal_mode(DELETE)", Admin},
+		{dialect.SQLite{}, "PRAGMA wal_checkpoint(TRUNCATE)", Admin},
+		{dialect.SQLite{}, "PRAGMA wal_checkpoint", Admin},
+		{dialect.SQLite{}, "PRAGMA optimize", Admin},
+		{dialect.SQLite{}, "PRAGMA incremental_vacuum", Admin},
+		{dialect.SQLite{}, "PRAGMA incremental_vacuum(10)", Admin},
+		{dialect.SQLite{}, "PRAGMA writable_schema = ON", Admin},
+		{dialect.Postgres{}, "ALTER DEFAULT PRIVILEGES IN SCHEMA public GRANT SELECT ON TABLES TO bob", DCL},
+		{dialect.Postgres{}, "ALTER DEFAULT PRIVILEGES FOR ROLE admin REVOKE ALL ON TABLES FROM PUBLIC", DCL},
+		{dialect.Postgres{}, "/* DROP TABLE t */ SELECT 1 -- DROP TABLE t", Read},
+		{dialect.Postgres{}, "SELECT $tag$ ; DROP TABLE t $tag$", Read},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			got, err := ClassifySingle(tt.dialect, tt.query)
+			if err != nil {
+				t.Fatalf("ClassifySingle: %v", err)
+			}
+			if got.Category != tt.want {
+				t.Errorf("category = %s, want %s", got.Category, tt.want)
+			}
+		})
+	}
+}
+
+func TestClassifyAdminFunctions(t *testing.T) {
+	tests := []struct {
+		dialect dialect.Dialect
+		query   string
+	}{
+		{dialect.Postgres{}, "SELECT pg_terminate_backend(123)"},
+		{dialect.Postgres{}, "SELECT pg_cancel_backend(pid) FROM pg_stat_activity WHERE state = 'active'"},
+		{dialect.Postgres{}, "SELECT pg_catalog.pg_terminate_backend(123)"},
+		{dialect.Postgres{}, `SELECT "pg_terminate_backend"(123)`},
+		{dialect.Postgres{}, "select PG_RELOAD_CONF()"},
+		{dialect.Postgres{}, "SELECT pg_rotate_logfile()"},
+		{dialect.Postgres{}, "SELECT set_config('default_transaction_read_only', 'off', false)"},
+		{dialect.Postgres{}, "SELECT pg_notify('jobs', 'run')"},
+		{dialect.Postgres{}, "SELECT pg_advisory_lock(42)"},
+		{dialect.Postgres{}, "SELECT pg_try_advisory_lock_shared(1, 2)"},
+		{dialect.Postgres{}, "SELECT pg_catalog.pg_advisory_unlock_all()"},
+		{dialect.MySQL{}, "SELECT GET_LOCK('deploy', 10)"},
+		{dialect.MySQL{}, "SELECT RELEASE_ALL_LOCKS()"},
+		{dialect.Postgres{}, "SELECT pg_stat_statements_reset()"},
+		{dialect.Postgres{}, "SELECT public.pg_stat_statements_reset(0, 0, 0)"},
+		{dialect.Postgres{}, "SELECT pg_stat_reset()"},
+		{dialect.Postgres{}, "SELECT pg_stat_reset_shared('bgwriter')"},
+		{dialect.MySQL{}, "CALL sys.ps_truncate_all_tables(FALSE)"},
+		{dialect.Postgres{}, "SELECT lo_import('/etc/passwd')"},
+		{dialect.Postgres{}, "SELECT lo_export(16409, '/tmp/out')"},
+		{dialect.Postgres{}, "SELECT pg_read_file('postgresql.conf')"},
+		{dialect.Postgres{}, "SELECT pg_read_binary_file('/etc/passwd')"},
+		{dialect.Postgres{}, "SELECT * FROM pg_ls_dir('.')"},
+		{dialect.Postgres{}, "SELECT dblink_exec('dbname=prod', 'DROP TABLE users')"},
+		{dialect.Postgres{}, "SELECT * FROM dblink('dbname=prod', 'DELETE FROM t RETURNING id') AS t(id int)"},
+		{dialect.Postgres{}, "WITH x AS (SELECT pg_terminate_backend(123)) SELECT * FROM x"},
+		{dialect.Postgres{}, "VALUES (pg_cancel_backend(1))"},
+		{dialect.Postgres{}, "UPDATE t SET a = pg_terminate_backend(1)"},
+		{dialect.MySQL{}, "SELECT LOAD_FILE('/etc/passwd')"},
+		{dialect.SQLite{}, "SELECT load_extension('evil.so')"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			got, err := ClassifySingle(tt.dialect, tt.query)
+			if err != nil {
+				t.Fatalf("ClassifySingle: %v", err)
+			}
+			if got.Category != Admin {
+				t.Errorf("category = %s, want %s", got.Category, Admin)
+			}
+		})
+	}
+}
+
+func TestClassifyAdminFunctionNamesOutsideCalls(t *testing.T) {
+	tests := []struct {
+		dialect dialect.Dialect
+		query   string
+	}{
+		{dialect.Postgres{}, "SELECT 'pg_terminate_backend(1)'"},
+		{dialect.Postgres{}, "SELECT pg_terminate_backend FROM t"},
+		{dialect.Postgres{}, "SELECT 1 -- pg_reload_conf()"},
+		{dialect.Postgres{}, "SELECT proname FROM pg_proc WHERE proname = 'pg_cancel_backend'"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			got, err := ClassifySingle(tt.dialect, tt.query)
+			if err != nil {
+				t.Fatalf("ClassifySingle: %v", err)
+			}
+			if got.Category != Read {
+				t.Errorf("category = %s, want %s", got.Category, Read)
+			}
+		})
+	}
+}
+
+func TestStripExplain(t *testing.T) {
+	tests := []struct {
+		dialect dialect.Dialect
+		query   string
+		want    string
+		analyze bool
+	}{
+		{dialect.Postgres{}, "SELECT 1", "SELECT 1", false},
+		{dialect.Postgres{}, "EXPLAIN SELECT 1", "SELECT 1", false},
+		{dialect.Postgres{}, "explain\nanalyze\tSELECT 1", "SELECT 1", true},
+		{dialect.Postgres{}, "EXPLAIN(ANALYZE) SELECT 1", "SELECT 1", true},
+		{dialect.Postgres{}, "EXPLAIN (ANALYZE false, FORMAT JSON) WITH x AS (SELECT 1) SELECT * FROM x", "WITH x AS (SELECT 1) SELECT * FROM x", false},
+		{dialect.Postgres{}, "EXPLAIN ANALYZE VERBOSE UPDATE t SET a = 1", "UPDATE t SET a = 1", true},
+		{dialect.Postgres{}, "/* plan */ EXPLAIN VERBOSE SELECT 1", "SELECT 1", false},
+		{dialect.MySQL{}, "EXPLAIN FORMAT=JSON SELECT 1", "SELECT 1", false},
+		{dialect.MySQL{}, "EXPLAIN ANALYZE SELECT 1", "SELECT 1", true},
+		{dialect.SQLite{}, "EXPLAIN QUERY PLAN SELECT 1", "SELECT 1", false},
+	}
+	for _, tt := range tests {
+		got, analyze, err := StripExplain(tt.dialect, tt.query)
+		if err != nil {
+			t.Errorf("StripExplain(%q): %v", tt.query, err)
+			continue
+		}
+		if got != tt.want || analyze != tt.analyze {
+			t.Errorf("StripExplain(%q) = %q, %v, want %q, %v", tt.query, got, analyze, tt.want, tt.analyze)
+		}
+	}
+
+	for _, query := range []string{"EXPLAIN", "EXPLAIN (ANALYZE)", "EXPLAIN 'x"} {
+		if got, _, err := StripExplain(dialect.Postgres{}, query); err == nil {
+			t.Errorf("StripExplain(%q) = %q, want an error", query, got)
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package classifier
+
+import (
+	"fmt"
+
+	"github.com/AbdelilahOu/DBMcp/internal/dialect"
+)
+
+// NamedPlaceholder is a ":name" placeholder and its byte range in the query.
+type NamedPlaceholder struct {
+	Name  string
+	Start int
+	End   int
+}
+
+// NamedPlaceholders returns the ":name" placeholders of query in order. A
+// colon only starts a placeholder when the name follows it directly, so
+// Postgres casts ("x::int") and MySQL assignments (":=") are not matched,
+// nor is anything inside literals, quoted identifiers or comments.
+func NamedPlaceholders(d dialect.Dialect, query string) ([]NamedPlaceholder, error) {
+	tokens, err := tokenize(query, optionsFor(d.Name()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse query: %v", err)
+	}
+
+	var placeholders []NamedPlaceholder
+	for i := 0; i+1 < len(tokens); i++ {
+		colon, name := tokens[i], tokens[i+1]
+		if !colon.isPunct(":") || name.kind != tokenWord || name.start != colon.end {
+			continue
+		}
+		if i > 0 && tokens[i-1].isPunct(":") && tokens[i-1].end == colon.start {
+			continue
+		}
+		placeholders = append(placeholders, NamedPlaceholder{
+			Name:  name.text,
+			Start: colon.start,
+			End:   name.end,
+		})
+		i++
+	}
+	return placeholders, nil
+}
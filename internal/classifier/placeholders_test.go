@@ -0,0 +1,53 @@
+package classifier
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/AbdelilahOu/DBMcp/internal/dialect"
+)
+
+func TestNamedPlaceholders(t *testing.T) {
+	tests := []struct {
+		name    string
+		dialect dialect.Dialect
+		query   string
+		want    []string
+	}{
+		{"plain", dialect.Postgres{}, "SELECT * FROM t WHERE a = :a AND b > :b", []string{"a", "b"}},
+		{"repeated", dialect.MySQL{}, "SELECT :id, :id", []string{"id", "id"}},
+		{"cast", dialect.Postgres{}, "SELECT :v::int, created_at::date FROM t", []string{"v"}},
+		{"assignment", dialect.MySQL{}, "SELECT @n := :start", []string{"start"}},
+		{"colon and space", dialect.Postgres{}, "SELECT arr[1 : 2], : x FROM t", nil},
+		{"string literal", dialect.Postgres{}, "SELECT ':a', E':b\\'' WHERE c = :c", []string{"c"}},
+		{"dollar quote", dialect.Postgres{}, "SELECT $$ :a $$, $tag$:b$tag$, :c", []string{"c"}},
+		{"quoted identifier", dialect.Postgres{}, `SELECT ":a" FROM t WHERE b = :b`, []string{"b"}},
+		{"comments", dialect.Postgres{}, "SELECT /* :a /* :b */ */ 1 -- :c\nWHERE d = :d", []string{"d"}},
+		{"mysql strings", dialect.MySQL{}, `SELECT ":a", 'it\'s :b' WHERE c = :c # :d`, []string{"c"}},
+		{"sqlite brackets", dialect.SQLite{}, "SELECT [:a], `:b` FROM t WHERE c = :c", []string{"c"}},
+		{"none", dialect.SQLite{}, "SELECT 1", nil},
+	}
+	for _, tt := range tests {
+		placeholders, err := NamedPlaceholders(tt.dialect, tt.query)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		var got []string
+		for _, p := range placeholders {
+			if text := tt.query[p.Start:p.End]; text != ":"+p.Name {
+				t.Errorf("%s: placeholder %s spans %q", tt.name, p.Name, text)
+			}
+			got = append(got, p.Name)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: NamedPlaceholders(%q) = %q, want %q", tt.name, tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestNamedPlaceholdersUnterminated(t *testing.T) {
+	if _, err := NamedPlaceholders(dialect.Postgres{}, "SELECT ':a WHERE b = :b"); err == nil {
+		t.Error("an unterminated literal was accepted")
+	}
+}
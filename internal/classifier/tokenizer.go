@@ -0,0 +1,315 @@
+package classifier
+
+import (
+	"fmt"
+	"strings"
+)
+
+type tokenKind int
+
+const (
+	tokenWord tokenKind = iota
+	tokenQuotedIdent
+	tokenString
+	tokenNumber
+	tokenParam
+	tokenPunct
+	tokenSemicolon
+)
+
+type token struct {
+	kind  tokenKind
+	text  string
+	start int
+	end   int
+}
+
+// upper returns the token text in upper case for words, and the raw text for
+// everything else, so keyword checks never match quoted identifiers.
+func (t token) upper() string {
+	if t.kind == tokenWord {
+		return strings.ToUpper(t.text)
+	}
+	return t.text
+}
+
+func (t token) is(keyword string) bool {
+	return t.kind == tokenWord && strings.EqualFold(t.text, keyword)
+}
+
+func (t token) isPunct(p string) bool {
+	return t.kind == tokenPunct && t.text == p
+}
+
+type lexerOptions struct {
+	hashComments      bool // MySQL: "# comment"
+	dashNeedsSpace    bool // MySQL: "--" only starts a comment when followed by whitespace
+	nestedComments    bool // Postgres: /* /* */ */
+	executableComment bool // MySQL: /*! ... */ bodies are executed
+	backslashEscapes  bool // MySQL: '\'' inside string literals
+	doubleQuoteString bool // MySQL: "..." is a string, not an identifier
+	backtickIdent     bool // MySQL, SQLite
+	bracketIdent      bool // SQLite: [ident]
+	dollarQuotes      bool // Postgres: $tag$ ... $tag$
+	escapeStrings     bool // Postgres: E'...'
+}
+
+func optionsFor(dialectName string) lexerOptions {
+	switch dialectName {
+	case "mysql":
+		return lexerOptions{
+			hashComments:      true,
+			dashNeedsSpace:    true,
+			executableComment: true,
+			backslashEscapes:  true,
+			doubleQuoteString: true,
+			backtickIdent:     true,
+		}
+	case "sqlite":
+		return lexerOptions{
+			backtickIdent: true,
+			bracketIdent:  true,
+		}
+	default:
+		return lexerOptions{
+			nestedComments: true,
+			dollarQuotes:   true,
+			escapeStrings:  true,
+		}
+	}
+}
+
+type lexer struct {
+	src    string
+	pos    int
+	opts   lexerOptions
+	tokens []token
+	// inExecComment is set while inside a MySQL /*! ... */ comment, whose
+	// contents are tokenized like regular SQL.
+	inExecComment bool
+}
+
+func tokenize(src string, opts lexerOptions) ([]token, error) {
+	l := &lexer{src: src, opts: opts}
+	for {
+		if err := l.skipSpaceAndComments(); err != nil {
+			return nil, err
+		}
+		if l.pos >= len(l.src) {
+			break
+		}
+		if err := l.next(); err != nil {
+			return nil, err
+		}
+	}
+	if l.inExecComment {
+		return nil, fmt.Errorf("unterminated comment")
+	}
+	return l.tokens, nil
+}
+
+func (l *lexer) peek(offset int) byte {
+	if l.pos+offset < len(l.src) {
+		return l.src[l.pos+offset]
+	}
+	return 0
+}
+
+func (l *lexer) skipSpaceAndComments() error {
+	for l.pos < len(l.src) {
+		c := l.src[l.pos]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f' || c == '\v':
+			l.pos++
+		case c == '-' && l.peek(1) == '-' && (!l.opts.dashNeedsSpace || isSpaceOrEnd(l.peek(2))):
+			l.skipLine()
+		case c == '#' && l.opts.hashComments:
+			l.skipLine()
+		case c == '/' && l.peek(1) == '*':
+			if l.opts.executableComment && l.peek(2) == '!' {
+				l.pos += 3
+				for l.pos < len(l.src) && l.src[l.pos] >= '0' && l.src[l.pos] <= '9' {
+					l.pos++
+				}
+				l.inExecComment = true
+				continue
+			}
+			if !l.skipBlockComment() {
+				return fmt.Errorf("unterminated comment")
+			}
+		case c == '*' && l.peek(1) == '/' && l.inExecComment:
+			l.pos += 2
+			l.inExecComment = false
+		default:
+			return nil
+		}
+	}
+	return nil
+}
+
+func isSpaceOrEnd(c byte) bool {
+	return c == 0 || c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
+
+func (l *lexer) skipLine() {
+	for l.pos < len(l.src) && l.src[l.pos] != '\n' {
+		l.pos++
+	}
+}
+
+func (l *lexer) skipBlockComment() bool {
+	depth := 0
+	for l.pos < len(l.src) {
+		if l.src[l.pos] == '/' && l.peek(1) == '*' {
+			if depth == 0 || l.opts.nestedComments {
+				depth++
+			}
+			l.pos += 2
+			continue
+		}
+		if l.src[l.pos] == '*' && l.peek(1) == '/' {
+			depth--
+			l.pos += 2
+			if depth == 0 {
+				return true
+			}
+			continue
+		}
+		l.pos++
+	}
+	return false
+}
+
+func (l *lexer) emit(kind tokenKind, start int) {
+	l.tokens = append(l.tokens, token{kind: kind, text: l.src[start:l.pos], start: start, end: l.pos})
+}
+
+func (l *lexer) next() error {
+	start := l.pos
+	c := l.src[l.pos]
+
+	switch {
+	case c == ';':
+		l.pos++
+		l.emit(tokenSemicolon, start)
+	case c == '\'':
+		if err := l.quoted('\'', l.opts.backslashEscapes); err != nil {
+			return err
+		}
+		l.emit(tokenString, start)
+	case (c == 'E' || c == 'e') && l.opts.escapeStrings && l.peek(1) == '\'':
+		l.pos++
+		if err := l.quoted('\'', true); err != nil {
+			return err
+		}
+		l.emit(tokenString, start)
+	case c == '"':
+		if l.opts.doubleQuoteString {
+			if err := l.quoted('"', l.opts.backslashEscapes); err != nil {
+				return err
+			}
+			l.emit(tokenString, start)
+		} else {
+			if err := l.quoted('"', false); err != nil {
+				return err
+			}
+			l.emit(tokenQuotedIdent, start)
+		}
+	case c == '`' && l.opts.backtickIdent:
+		if err := l.quoted('`', false); err != nil {
+			return err
+		}
+		l.emit(tokenQuotedIdent, start)
+	case c == '[' && l.opts.bracketIdent:
+		end := strings.IndexByte(l.src[l.pos:], ']')
+		if end < 0 {
+			return fmt.Errorf("unterminated quoted identifier")
+		}
+		l.pos += end + 1
+		l.emit(tokenQuotedIdent, start)
+	case c == '$' && l.opts.dollarQuotes && !isDigit(l.peek(1)):
+		tag, ok := l.dollarTag()
+		if !ok {
+			l.pos++
+			l.emit(tokenPunct, start)
+			return nil
+		}
+		l.pos += len(tag)
+		end := strings.Index(l.src[l.pos:], tag)
+		if end < 0 {
+			return fmt.Errorf("unterminated dollar-quoted string")
+		}
+		l.pos += end + len(tag)
+		l.emit(tokenString, start)
+	case (c == '$' || c == '?' || c == ':' || c == '@') && isDigit(l.peek(1)):
+		l.pos++
+		for l.pos < len(l.src) && isDigit(l.src[l.pos]) {
+			l.pos++
+		}
+		l.emit(tokenParam, start)
+	case isDigit(c) || (c == '.' && isDigit(l.peek(1))):
+		for l.pos < len(l.src) && (isDigit(l.src[l.pos]) || l.src[l.pos] == '.' || isLetter(l.src[l.pos])) {
+			l.pos++
+		}
+		l.emit(tokenNumber, start)
+	case isLetter(c) || c == '_' || c >= 0x80:
+		for l.pos < len(l.src) && isWordChar(l.src[l.pos]) {
+			l.pos++
+		}
+		l.emit(tokenWord, start)
+	default:
+		l.pos++
+		l.emit(tokenPunct, start)
+	}
+	return nil
+}
+
+// quoted consumes a literal opened by the delimiter q at the current
+// position. A doubled delimiter is an escaped one and, if backslash is set,
+// so is a backslash-escaped one.
+func (l *lexer) quoted(q byte, backslash bool) error {
+	l.pos++
+	for l.pos < len(l.src) {
+		c := l.src[l.pos]
+		if backslash && c == '\\' {
+			l.pos += 2
+			continue
+		}
+		if c == q {
+			if l.peek(1) == q {
+				l.pos += 2
+				continue
+			}
+			l.pos++
+			return nil
+		}
+		l.pos++
+	}
+	if q == '\'' || q == '"' && l.opts.doubleQuoteString {
+		return fmt.Errorf("unterminated string literal")
+	}
+	return fmt.Errorf("unterminated quoted identifier")
+}
+
+func (l *lexer) dollarTag() (string, bool) {
+	i := l.pos + 1
+	for i < len(l.src) && isWordChar(l.src[i]) && l.src[i] != '$' {
+		i++
+	}
+	if i < len(l.src) && l.src[i] == '$' {
+		return l.src[l.pos : i+1], true
+	}
+	return "", false
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isLetter(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+func isWordChar(c byte) bool {
+	return isLetter(c) || isDigit(c) || c == '_' || c == '$' || c >= 0x80
+}
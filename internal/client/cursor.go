@@ -0,0 +1,90 @@
+package client
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/AbdelilahOu/DBMcp/internal/dialect"
+)
+
+// Cursor is a query result held open across tool calls. It keeps a pool
+// connection and its read-only transaction until Close.
+type Cursor struct {
+	conn   *sql.Conn
+	tx     *sql.Tx
+	rows   *sql.Rows
+	d      dialect.Dialect
+	cancel context.CancelFunc
+	peeked bool
+}
+
+// OpenCursor runs query inside a read-only transaction and returns its open
+// result. ctx bounds opening only; the cursor lives until Close or Cancel.
+func OpenCursor(ctx context.Context, db *sql.DB, d dialect.Dialect, query string, args ...interface{}) (*Cursor, error) {
+	cursorCtx, cancel := context.WithCancel(context.Background())
+	stop := context.AfterFunc(ctx, cancel)
+	defer stop()
+
+	conn, err := db.Conn(cursorCtx)
+	if err != nil {
+		cancel()
+		return nil, fmt.Errorf("acquire connection: %w", err)
+	}
+
+	tx, err := beginReadOnly(cursorCtx, conn, d)
+	if err != nil {
+		conn.Close()
+		cancel()
+		return nil, err
+	}
+
+	rows, err := tx.QueryContext(cursorCtx, query, args...)
+	if err != nil {
+		endReadOnly(conn, tx, d)
+		conn.Close()
+		cancel()
+		if ctx.Err() != nil {
+			err = ctx.Err()
+		}
+		return nil, fmt.Errorf("query execution error: %v", err)
+	}
+
+	return &Cursor{conn: conn, tx: tx, rows: rows, d: d, cancel: cancel}, nil
+}
+
+// Rows is the underlying result; advance it with Next rather than
+// Rows().Next so a row peeked by HasMore is not skipped.
+func (c *Cursor) Rows() *sql.Rows {
+	return c.rows
+}
+
+func (c *Cursor) Next() bool {
+	if c.peeked {
+		c.peeked = false
+		return true
+	}
+	return c.rows.Next()
+}
+
+// HasMore reports whether another row follows, without consuming it.
+func (c *Cursor) HasMore() bool {
+	if !c.peeked {
+		c.peeked = c.rows.Next()
+	}
+	return c.peeked
+}
+
+// Cancel aborts the query from any goroutine; the cursor must still be
+// closed by its reader.
+func (c *Cursor) Cancel() {
+	c.cancel()
+}
+
+func (c *Cursor) Close() error {
+	err := c.rows.Close()
+	endReadOnly(c.conn, c.tx, c.d)
+	c.conn.Close()
+	c.cancel()
+	return err
+}
@@ -3,27 +3,41 @@ package client
 import (
 	"context"
 	"database/sql"
+	"database/sql/driver"
 	"fmt"
 	"time"
+
+	"github.com/AbdelilahOu/DBMcp/internal/dialect"
 )
 
 type DBClient struct {
 	DB *sql.DB
 }
 
-func NewDBClient(connString, driver string) (*DBClient, error) {
+// PoolOptions sizes a connection pool; zero fields keep database/sql's
+// defaults.
+type PoolOptions struct {
+	MaxOpenConns    int
+	ConnMaxLifetime time.Duration
+}
+
+func NewDBClient(ctx context.Context, connString, driver string, opts PoolOptions) (*DBClient, error) {
 	db, err := sql.Open(driver, connString)
 	if err != nil {
 		return nil, fmt.Errorf("open %s: %w", driver, err)
 	}
-	if err := db.Ping(); err != nil {
+	if err := db.PingContext(ctx); err != nil {
 		db.Close()
 		return nil, fmt.Errorf("ping %s: %w", driver, err)
 	}
 
-	db.SetMaxOpenConns(10)
-	db.SetMaxIdleConns(5)
-	db.SetConnMaxLifetime(5 * time.Minute)
+	if opts.MaxOpenConns > 0 {
+		db.SetMaxOpenConns(opts.MaxOpenConns)
+		db.SetMaxIdleConns((opts.MaxOpenConns + 1) / 2)
+	}
+	if opts.ConnMaxLifetime > 0 {
+		db.SetConnMaxLifetime(opts.ConnMaxLifetime)
+	}
 
 	return &DBClient{DB: db}, nil
 }
@@ -35,3 +49,65 @@ func (c *DBClient) Close() error {
 func (c *DBClient) Query(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
 	return c.DB.QueryContext(ctx, query, args...)
 }
+
+// ReadOnlyTx runs fn inside a read-only transaction that is always rolled
+// back, so nothing fn executes can persist a write.
+func ReadOnlyTx(ctx context.Context, db *sql.DB, d dialect.Dialect, fn func(tx *sql.Tx) error) error {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("acquire connection: %w", err)
+	}
+	defer conn.Close()
+
+	return ReadOnlyConnTx(ctx, conn, d, fn)
+}
+
+// ReadOnlyConnTx is ReadOnlyTx on a connection the caller holds, for work
+// that depends on session state such as hypothetical indexes.
+func ReadOnlyConnTx(ctx context.Context, conn *sql.Conn, d dialect.Dialect, fn func(tx *sql.Tx) error) error {
+	tx, err := beginReadOnly(ctx, conn, d)
+	if err != nil {
+		return err
+	}
+	defer endReadOnly(conn, tx, d)
+
+	return fn(tx)
+}
+
+// RollbackTx runs fn inside a transaction that is always rolled back, for
+// writes that are only run to be observed.
+func RollbackTx(ctx context.Context, db *sql.DB, fn func(tx *sql.Tx) error) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	return fn(tx)
+}
+
+func beginReadOnly(ctx context.Context, conn *sql.Conn, d dialect.Dialect) (*sql.Tx, error) {
+	tx, err := conn.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return nil, fmt.Errorf("begin read-only transaction: %w", err)
+	}
+
+	if enter, _ := d.ReadOnlyStatements(); enter != "" {
+		if _, err := tx.ExecContext(ctx, enter); err != nil {
+			endReadOnly(conn, tx, d)
+			return nil, fmt.Errorf("set transaction read only: %w", err)
+		}
+	}
+	return tx, nil
+}
+
+func endReadOnly(conn *sql.Conn, tx *sql.Tx, d dialect.Dialect) {
+	tx.Rollback()
+
+	if _, exit := d.ReadOnlyStatements(); exit != "" {
+		if _, err := conn.ExecContext(context.Background(), exit); err != nil {
+			// Never hand a connection stuck in read-only mode back to the pool.
+			conn.Raw(func(interface{}) error { return driver.ErrBadConn })
+		}
+	}
+}
@@ -0,0 +1,189 @@
+package client
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/AbdelilahOu/DBMcp/internal/config"
+)
+
+// Manager keeps one pool per named connection, shared by every session using
+// that connection. A pool nobody references is closed once it has been idle
+// for the manager's TTL.
+type Manager struct {
+	mu      sync.Mutex
+	pools   map[string]*managedPool
+	idleTTL time.Duration
+}
+
+type managedPool struct {
+	client    *DBClient
+	driver    string
+	refs      int
+	idleSince time.Time
+	idleTimer *time.Timer
+}
+
+type PoolStats struct {
+	Name            string
+	Driver          string
+	Sessions        int
+	MaxOpen         int
+	OpenConnections int
+	InUse           int
+	Idle            int
+	WaitCount       int64
+	WaitDuration    time.Duration
+	IdleSince       time.Time
+}
+
+var defaultManager = NewManager(config.DefaultPoolIdleTTL)
+
+func DefaultManager() *Manager {
+	return defaultManager
+}
+
+func NewManager(idleTTL time.Duration) *Manager {
+	return &Manager{
+		pools:   make(map[string]*managedPool),
+		idleTTL: idleTTL,
+	}
+}
+
+func (m *Manager) SetIdleTTL(ttl time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.idleTTL = ttl
+}
+
+// connectTimeout bounds opening and pinging a new pool.
+const connectTimeout = 30 * time.Second
+
+// Acquire returns the pool for name, opening it on first use, and takes a
+// reference on it. Every Acquire must be paired with a Release. opts only
+// apply when the pool is opened.
+func (m *Manager) Acquire(ctx context.Context, name, connString, driver string, opts PoolOptions) (*sql.DB, error) {
+	if db, ok := m.Retain(name); ok {
+		return db, nil
+	}
+
+	// The pool is opened without the lock, so an unreachable host only
+	// holds up the sessions waiting for it.
+	ctx, cancel := context.WithTimeout(ctx, connectTimeout)
+	defer cancel()
+	c, err := NewDBClient(ctx, connString, driver, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	// Another session may have opened the same pool meanwhile; keep one.
+	if p, ok := m.pools[name]; ok {
+		c.Close()
+		m.retain(p)
+		return p.client.DB, nil
+	}
+	p := &managedPool{client: c, driver: driver}
+	m.pools[name] = p
+	m.retain(p)
+	return c.DB, nil
+}
+
+// Retain takes another reference on an already open pool.
+func (m *Manager) Retain(name string) (*sql.DB, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	p, ok := m.pools[name]
+	if !ok {
+		return nil, false
+	}
+	m.retain(p)
+	return p.client.DB, true
+}
+
+func (m *Manager) retain(p *managedPool) {
+	p.refs++
+	p.idleSince = time.Time{}
+	if p.idleTimer != nil {
+		p.idleTimer.Stop()
+		p.idleTimer = nil
+	}
+}
+
+func (m *Manager) Release(name string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	p, ok := m.pools[name]
+	if !ok || p.refs == 0 {
+		return
+	}
+	p.refs--
+	if p.refs > 0 {
+		return
+	}
+
+	p.idleSince = time.Now()
+	p.idleTimer = time.AfterFunc(m.idleTTL, func() {
+		m.closeIfIdle(name, p)
+	})
+}
+
+func (m *Manager) closeIfIdle(name string, p *managedPool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.pools[name] != p || p.refs > 0 {
+		return
+	}
+	delete(m.pools, name)
+	p.client.Close()
+}
+
+func (m *Manager) Stats() []PoolStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stats := make([]PoolStats, 0, len(m.pools))
+	for name, p := range m.pools {
+		s := p.client.DB.Stats()
+		stats = append(stats, PoolStats{
+			Name:            name,
+			Driver:          p.driver,
+			Sessions:        p.refs,
+			MaxOpen:         s.MaxOpenConnections,
+			OpenConnections: s.OpenConnections,
+			InUse:           s.InUse,
+			Idle:            s.Idle,
+			WaitCount:       s.WaitCount,
+			WaitDuration:    s.WaitDuration,
+			IdleSince:       p.idleSince,
+		})
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Name < stats[j].Name })
+	return stats
+}
+
+// Close closes every pool regardless of references; used at shutdown.
+func (m *Manager) Close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var firstErr error
+	for name, p := range m.pools {
+		if p.idleTimer != nil {
+			p.idleTimer.Stop()
+		}
+		if err := p.client.Close(); err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("close %s: %w", name, err)
+		}
+		delete(m.pools, name)
+	}
+	return firstErr
+}
@@ -0,0 +1,109 @@
+package client
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// hangingDriver stands in for an unreachable host: connecting blocks until
+// the context ends.
+type hangingDriver struct{}
+
+func (hangingDriver) Open(string) (driver.Conn, error) {
+	return nil, errors.New("open without a context")
+}
+
+func (hangingDriver) OpenConnector(string) (driver.Connector, error) {
+	return hangingConnector{}, nil
+}
+
+type hangingConnector struct{}
+
+func (hangingConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func (hangingConnector) Driver() driver.Driver { return hangingDriver{} }
+
+func init() {
+	sql.Register("hanging", hangingDriver{})
+}
+
+func TestAcquireDoesNotBlockOtherPools(t *testing.T) {
+	m := NewManager(time.Minute)
+	defer m.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	failed := make(chan error, 1)
+	go func() {
+		_, err := m.Acquire(ctx, "unreachable", "", "hanging", PoolOptions{})
+		failed <- err
+	}()
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := m.Acquire(context.Background(), "local", ":memory:", "sqlite", PoolOptions{})
+		if err == nil {
+			m.Release("local")
+		}
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("Acquire(local): %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Acquire(local) waited on the unreachable pool")
+	}
+
+	cancel()
+	if err := <-failed; err == nil {
+		t.Error("Acquire(unreachable) succeeded")
+	}
+	for _, s := range m.Stats() {
+		if s.Name == "unreachable" {
+			t.Error("a pool that failed to open was kept")
+		}
+	}
+}
+
+func TestAcquireSharesOnePool(t *testing.T) {
+	m := NewManager(time.Minute)
+	defer m.Close()
+
+	const sessions = 8
+	dbs := make([]*sql.DB, sessions)
+	var wg sync.WaitGroup
+	for i := range dbs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			db, err := m.Acquire(context.Background(), "local", ":memory:", "sqlite", PoolOptions{})
+			if err != nil {
+				t.Errorf("Acquire: %v", err)
+			}
+			dbs[i] = db
+		}(i)
+	}
+	wg.Wait()
+
+	for _, db := range dbs[1:] {
+		if db != dbs[0] {
+			t.Fatal("sessions acquiring the same name got different pools")
+		}
+	}
+	stats := m.Stats()
+	if len(stats) != 1 || stats[0].Sessions != sessions {
+		t.Errorf("stats = %+v, want one pool with %d sessions", stats, sessions)
+	}
+}
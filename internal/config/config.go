@@ -4,6 +4,10 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"strings"
+	"time"
+
+	"github.com/AbdelilahOu/DBMcp/internal/dialect"
 )
 
 type Connection struct {
@@ -11,6 +15,14 @@ type Connection struct {
 	Type        string `json:"type"`
 	URL         string `json:"url"`
 	Description string `json:"description"`
+	ReadOnly    bool   `json:"read_only"`
+	// Admin allows cancel_backend to cancel and terminate other sessions on
+	// this connection's server, and top_queries to reset its statement
+	// statistics.
+	Admin bool `json:"admin"`
+	// Settings overrides the top-level settings for this connection; zero
+	// fields inherit.
+	Settings Settings `json:"settings"`
 }
 
 type LoggingConfig struct {
@@ -20,10 +32,116 @@ type LoggingConfig struct {
 	Console    bool   `json:"console"`
 }
 
+type StaticToken struct {
+	Subject string `json:"subject"`
+	Token   string `json:"token"`
+}
+
+type JWTConfig struct {
+	JWKSFile string `json:"jwks_file"`
+	Issuer   string `json:"issuer"`
+	Audience string `json:"audience"`
+}
+
+type ClientCertConfig struct {
+	CAFile          string   `json:"ca_file"`
+	AllowedSubjects []string `json:"allowed_subjects"`
+}
+
+type AuthConfig struct {
+	BearerTokens []StaticToken     `json:"bearer_tokens"`
+	JWT          *JWTConfig        `json:"jwt"`
+	ClientCert   *ClientCertConfig `json:"client_cert"`
+}
+
+// ExportConfig enables export_query. Exported files are written under Dir
+// and nowhere else.
+type ExportConfig struct {
+	Dir string `json:"dir"`
+}
+
+// SnapshotConfig enables snapshot_schema, schema_changes and
+// list_snapshots, which keep their snapshots under Dir. With OnConnect,
+// switch_connection also snapshots the connection's current schema.
+type SnapshotConfig struct {
+	Dir       string `json:"dir"`
+	OnConnect bool   `json:"on_connect"`
+}
+
+type TLSConfig struct {
+	CertFile string `json:"cert_file"`
+	KeyFile  string `json:"key_file"`
+}
+
+// Duration is a time.Duration written as a Go duration string ("30s", "5m")
+// in the config file.
+type Duration time.Duration
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("duration must be a string like \"30s\": %v", err)
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+const (
+	DefaultQueryTimeout       = 30 * time.Second
+	DefaultAnalyzeTimeout     = time.Minute
+	DefaultExportTimeout      = 10 * time.Minute
+	DefaultMaxConnections     = 10
+	DefaultConnectionLifetime = 5 * time.Minute
+	DefaultPoolIdleTTL        = 10 * time.Minute
+	DefaultPageSize           = 100
+	DefaultMaxPageSize        = 1000
+	DefaultCursorIdleTTL      = time.Minute
+)
+
+type Settings struct {
+	// QueryTimeout bounds every database call a tool makes.
+	QueryTimeout Duration `json:"query_timeout"`
+	// MaxQueryTimeout caps the timeout_ms tool argument; it defaults to
+	// QueryTimeout, so callers can only shorten the timeout.
+	MaxQueryTimeout Duration `json:"max_query_timeout"`
+	// AnalyzeTimeout bounds explain_query with analyze, which runs the
+	// statement; timeout_ms can only shorten it.
+	AnalyzeTimeout Duration `json:"analyze_timeout"`
+	// ExportTimeout bounds export_query, which streams a whole result to a
+	// file; timeout_ms can only shorten it.
+	ExportTimeout      Duration `json:"export_timeout"`
+	MaxConnections     int      `json:"max_connections"`
+	ConnectionLifetime Duration `json:"connection_lifetime"`
+	// DefaultPageSize is how many rows select_query returns per page unless
+	// page_size asks for another size, up to MaxPageSize.
+	DefaultPageSize int `json:"default_page_size"`
+	MaxPageSize     int `json:"max_page_size"`
+	// CursorIdleTTL is how long a select_query cursor nobody reads keeps
+	// its pool connection and open transaction.
+	CursorIdleTTL Duration `json:"cursor_idle_ttl"`
+	// PoolIdleTTL is how long a pool no session uses stays open. It is
+	// server-wide only.
+	PoolIdleTTL Duration `json:"pool_idle_ttl"`
+}
+
 type Config struct {
 	Connections       map[string]Connection `json:"connections"`
 	DefaultConnection string                `json:"default_connection"`
 	Logging           LoggingConfig         `json:"logging"`
+	ReadOnly          bool                  `json:"read_only"`
+	Auth              AuthConfig            `json:"auth"`
+	TLS               TLSConfig             `json:"tls"`
+	Settings          Settings              `json:"settings"`
+	Export            ExportConfig          `json:"export"`
+	Snapshots         SnapshotConfig        `json:"snapshots"`
 }
 
 func LoadConfig(configPath string) (*Config, error) {
@@ -40,6 +158,63 @@ func (c *Config) GetConnection(name string) (Connection, bool) {
 	return conn, exists
 }
 
+func (c *Config) IsReadOnly(conn Connection) bool {
+	return c.ReadOnly || conn.ReadOnly
+}
+
+// HasAdminConnection reports whether any connection allows admin
+// operations.
+func (c *Config) HasAdminConnection() bool {
+	for _, conn := range c.Connections {
+		if conn.Admin {
+			return true
+		}
+	}
+	return false
+}
+
+// SettingsFor returns the settings in effect for conn: its own overrides on
+// top of the top-level settings.
+func (c *Config) SettingsFor(conn Connection) Settings {
+	s := c.Settings
+	o := conn.Settings
+	if o.QueryTimeout > 0 {
+		s.QueryTimeout = o.QueryTimeout
+	}
+	if o.MaxQueryTimeout > 0 {
+		s.MaxQueryTimeout = o.MaxQueryTimeout
+	}
+	if o.AnalyzeTimeout > 0 {
+		s.AnalyzeTimeout = o.AnalyzeTimeout
+	}
+	if o.ExportTimeout > 0 {
+		s.ExportTimeout = o.ExportTimeout
+	}
+	if o.MaxConnections > 0 {
+		s.MaxConnections = o.MaxConnections
+	}
+	if o.ConnectionLifetime > 0 {
+		s.ConnectionLifetime = o.ConnectionLifetime
+	}
+	if o.DefaultPageSize > 0 {
+		s.DefaultPageSize = o.DefaultPageSize
+	}
+	if o.MaxPageSize > 0 {
+		s.MaxPageSize = o.MaxPageSize
+	}
+	if o.CursorIdleTTL > 0 {
+		s.CursorIdleTTL = o.CursorIdleTTL
+	}
+	if s.MaxQueryTimeout == 0 {
+		s.MaxQueryTimeout = s.QueryTimeout
+	}
+	return s
+}
+
+func (a AuthConfig) Enabled() bool {
+	return len(a.BearerTokens) > 0 || a.JWT != nil || a.ClientCert != nil
+}
+
 func (c *Config) ListConnections() map[string]Connection {
 	return c.Connections
 }
@@ -51,12 +226,33 @@ func (c *Config) ValidateConnection(conn Connection) error {
 	if conn.Type == "" {
 		return fmt.Errorf("connection type is required")
 	}
-	if conn.Type != "postgres" && conn.Type != "mysql" {
-		return fmt.Errorf("connection type must be 'postgres' or 'mysql'")
+	if !dialect.IsSupported(conn.Type) {
+		return fmt.Errorf("connection type must be one of: %s", strings.Join(dialect.SupportedTypes(), ", "))
 	}
 	if conn.URL == "" {
 		return fmt.Errorf("connection URL is required")
 	}
+	if err := conn.Settings.validate(); err != nil {
+		return err
+	}
+	s := c.SettingsFor(conn)
+	if s.MaxQueryTimeout < s.QueryTimeout {
+		return fmt.Errorf("max_query_timeout (%s) is shorter than query_timeout (%s)",
+			time.Duration(s.MaxQueryTimeout), time.Duration(s.QueryTimeout))
+	}
+	if s.MaxPageSize < s.DefaultPageSize {
+		return fmt.Errorf("max_page_size (%d) is smaller than default_page_size (%d)", s.MaxPageSize, s.DefaultPageSize)
+	}
+	return nil
+}
+
+func (s Settings) validate() error {
+	if s.QueryTimeout < 0 || s.MaxQueryTimeout < 0 || s.AnalyzeTimeout < 0 || s.ExportTimeout < 0 || s.ConnectionLifetime < 0 || s.PoolIdleTTL < 0 || s.CursorIdleTTL < 0 {
+		return fmt.Errorf("settings durations must not be negative")
+	}
+	if s.MaxConnections < 0 || s.DefaultPageSize < 0 || s.MaxPageSize < 0 {
+		return fmt.Errorf("max_connections and page sizes must not be negative")
+	}
 	return nil
 }
 
@@ -85,6 +281,37 @@ func loadConfigFromFile(path string) (*Config, error) {
 		config.Logging.Console = true
 	}
 
+	if err := config.Settings.validate(); err != nil {
+		return nil, fmt.Errorf("invalid settings: %v", err)
+	}
+	if config.Settings.QueryTimeout == 0 {
+		config.Settings.QueryTimeout = Duration(DefaultQueryTimeout)
+	}
+	if config.Settings.AnalyzeTimeout == 0 {
+		config.Settings.AnalyzeTimeout = Duration(DefaultAnalyzeTimeout)
+	}
+	if config.Settings.ExportTimeout == 0 {
+		config.Settings.ExportTimeout = Duration(DefaultExportTimeout)
+	}
+	if config.Settings.MaxConnections == 0 {
+		config.Settings.MaxConnections = DefaultMaxConnections
+	}
+	if config.Settings.ConnectionLifetime == 0 {
+		config.Settings.ConnectionLifetime = Duration(DefaultConnectionLifetime)
+	}
+	if config.Settings.PoolIdleTTL == 0 {
+		config.Settings.PoolIdleTTL = Duration(DefaultPoolIdleTTL)
+	}
+	if config.Settings.CursorIdleTTL == 0 {
+		config.Settings.CursorIdleTTL = Duration(DefaultCursorIdleTTL)
+	}
+	if config.Settings.DefaultPageSize == 0 {
+		config.Settings.DefaultPageSize = DefaultPageSize
+	}
+	if config.Settings.MaxPageSize == 0 {
+		config.Settings.MaxPageSize = max(DefaultMaxPageSize, config.Settings.DefaultPageSize)
+	}
+
 	for name, conn := range config.Connections {
 		conn.Name = name
 		if err := config.ValidateConnection(conn); err != nil {
@@ -0,0 +1,141 @@
+package dialect
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Dialect holds everything that differs between database engines: catalog
+// queries, identifier quoting, placeholder style and EXPLAIN syntax.
+type Dialect interface {
+	Name() string
+	DriverName() string
+
+	QuoteIdentifier(name string) string
+	QualifiedName(schema, table string) string
+	Placeholder(n int) string
+
+	CurrentSchemaQuery() string
+	DatabaseNameQuery() string
+	VersionQuery() string
+	FormatVersion(version string) string
+	SchemasQuery() string
+	TableCountQuery() string
+
+	ListTablesQuery(schema string) (string, []interface{})
+	ColumnsQuery(schema, table string) (string, []interface{})
+	// IndexesQuery and ForeignKeysQuery return their column lists as JSON
+	// arrays of names, so names with commas survive. Index columns are the
+	// key columns only, without INCLUDE columns.
+	IndexesQuery(schema, table string) (string, []interface{})
+	// ForeignKeysQuery lists the foreign keys from or to table, or every
+	// foreign key touching schema when table is empty.
+	ForeignKeysQuery(schema, table string) (string, []interface{})
+	CheckConstraintsQuery(schema, table string) (string, []interface{})
+	// CheckConstraintsAvailableQuery counts the catalogs CheckConstraintsQuery
+	// reads, which is zero on servers without them; empty when every server
+	// of the engine has them.
+	CheckConstraintsAvailableQuery() string
+	ViewsQuery(schema string) (string, []interface{})
+	// RoutinesQuery lists functions and procedures; empty when the engine
+	// has none.
+	RoutinesQuery(schema string) (string, []interface{})
+
+	// CreateStatementQuery returns a query whose column holds the CREATE
+	// statement of an object, or an empty query when the engine has none
+	// for that kind of object.
+	CreateStatementQuery(kind, schema, name string) (query string, args []interface{}, column string)
+
+	TableSizeQuery(schema, table string) (string, []interface{})
+	LastAnalyzedQuery(schema, table string) (string, []interface{})
+	ColumnNullabilityQuery(schema, table string) (string, []interface{})
+	// TableRowEstimatesQuery returns relname and the planner's row estimate
+	// of each table named, for plans that only give the rows a scan returns
+	// after its filter; empty when the plans say how many rows were read.
+	TableRowEstimatesQuery(tables []string) (string, []interface{})
+
+	ExplainQuery(query string) string
+	// ExplainAnalyzeQuery runs query and reports its plan with actual row
+	// counts and timings; empty when the engine cannot.
+	ExplainAnalyzeQuery(query string) string
+	// HypotheticalIndexQueries check for, create and drop indexes the
+	// planner considers without building them; empty when unsupported.
+	// installed counts rows, create takes the CREATE INDEX statement and
+	// returns the index name.
+	HypotheticalIndexQueries() (installed, create, reset string)
+	// StatementStatsQueries read per-statement statistics kept by source;
+	// empty when there are none. available returns the version of source,
+	// or an empty string when it is not collecting.
+	StatementStatsQueries() (source, available, reset string)
+	// StatementStatsQuery returns id, query, calls, total_time_ms,
+	// mean_time_ms, rows and hit_ratio for the current database, for the
+	// version of the source that available reported.
+	StatementStatsQuery(version string) string
+	// SessionsQuery lists the other sessions on the server with id, user,
+	// database, client_address, application, state, query, duration_ms,
+	// wait_event, waiting_for, blocked_by (a comma-separated list of session
+	// ids) and idle; empty when the engine has no sessions.
+	SessionsQuery() string
+	// CancelSessionQuery cancels the running query of session id, or ends
+	// the session when terminate is set. It returns a boolean row where the
+	// engine reports success that way.
+	CancelSessionQuery(id int64, terminate bool) string
+
+	// DDL for bringing one schema in line with another. Statements the
+	// engine cannot run without rebuilding the table come back empty.
+	ColumnDefinition(column, columnType string, nullable bool, defaultValue string) string
+	AlterColumnDDL(schema, table, column, columnType string, nullable bool, defaultValue string) []string
+	DropIndexDDL(schema, table, index string) string
+	// AddConstraintDDL adds a constraint given as it appears in CREATE
+	// TABLE, such as "CONSTRAINT fk FOREIGN KEY (a) REFERENCES t (b)".
+	AddConstraintDDL(schema, table, constraint string) string
+	// DropConstraintDDL drops a PRIMARY KEY, FOREIGN KEY or CHECK
+	// constraint.
+	DropConstraintDDL(schema, table, name, kind string) string
+	DropRoutineDDL(schema, kind, name, arguments string) string
+
+	// ReadOnlyStatements returns the statements run right after BEGIN and
+	// after the transaction ends to keep it from writing. Either may be empty
+	// when the driver already honors sql.TxOptions.ReadOnly.
+	ReadOnlyStatements() (enter, exit string)
+	// RollbackUndoesWrites reports whether rolling a transaction back undoes
+	// every table write a DML statement makes, so the statement can be run
+	// just to observe it.
+	RollbackUndoesWrites() bool
+}
+
+var registry = map[string]Dialect{
+	"postgres": Postgres{},
+	"mysql":    MySQL{},
+	"sqlite":   SQLite{},
+}
+
+func New(dbType string) (Dialect, error) {
+	d, ok := registry[strings.ToLower(dbType)]
+	if !ok {
+		return nil, fmt.Errorf("unsupported database type '%s'", dbType)
+	}
+	return d, nil
+}
+
+func IsSupported(dbType string) bool {
+	_, ok := registry[strings.ToLower(dbType)]
+	return ok
+}
+
+func SupportedTypes() []string {
+	return []string{"postgres", "mysql", "sqlite"}
+}
+
+// columnDefinition is the column clause of CREATE TABLE for engines whose
+// catalogs report defaults as SQL expressions.
+func columnDefinition(d Dialect, column, columnType string, nullable bool, defaultValue string) string {
+	def := d.QuoteIdentifier(column) + " " + columnType
+	if !nullable {
+		def += " NOT NULL"
+	}
+	if defaultValue != "" {
+		def += " DEFAULT " + defaultValue
+	}
+	return def
+}
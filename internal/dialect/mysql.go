@@ -0,0 +1,384 @@
+package dialect
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type MySQL struct{}
+
+func (MySQL) Name() string {
+	return "mysql"
+}
+
+func (MySQL) DriverName() string {
+	return "mysql"
+}
+
+func (MySQL) QuoteIdentifier(name string) string {
+	return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+}
+
+func (m MySQL) QualifiedName(schema, table string) string {
+	if schema == "" {
+		return m.QuoteIdentifier(table)
+	}
+	return m.QuoteIdentifier(schema) + "." + m.QuoteIdentifier(table)
+}
+
+func (MySQL) Placeholder(n int) string {
+	return "?"
+}
+
+func (MySQL) CurrentSchemaQuery() string {
+	return "SELECT DATABASE()"
+}
+
+func (MySQL) DatabaseNameQuery() string {
+	return "SELECT DATABASE()"
+}
+
+func (MySQL) VersionQuery() string {
+	return "SELECT VERSION()"
+}
+
+func (MySQL) FormatVersion(version string) string {
+	return "MySQL " + version
+}
+
+func (MySQL) SchemasQuery() string {
+	return "SELECT SCHEMA_NAME FROM information_schema.SCHEMATA WHERE SCHEMA_NAME NOT IN ('information_schema', 'mysql', 'performance_schema', 'sys')"
+}
+
+func (MySQL) TableCountQuery() string {
+	return "SELECT COUNT(*) FROM information_schema.tables WHERE table_schema NOT IN ('information_schema', 'mysql', 'performance_schema', 'sys')"
+}
+
+func (MySQL) ListTablesQuery(schema string) (string, []interface{}) {
+	if schema != "" {
+		return `
+			SELECT
+				table_name as name,
+				table_schema as schema_name,
+				table_type as table_type
+			FROM information_schema.tables
+			WHERE table_schema = ?
+			ORDER BY table_name`, []interface{}{schema}
+	}
+	return `
+		SELECT
+			table_name as name,
+			table_schema as schema_name,
+			table_type as table_type
+		FROM information_schema.tables
+		WHERE table_schema NOT IN ('information_schema', 'mysql', 'performance_schema', 'sys')
+		ORDER BY table_name`, nil
+}
+
+func (MySQL) ColumnsQuery(schema, table string) (string, []interface{}) {
+	return `
+		SELECT
+			COLUMN_NAME as column_name,
+			DATA_TYPE as data_type,
+			COLUMN_TYPE as column_type,
+			CASE WHEN IS_NULLABLE = 'YES' THEN true ELSE false END as is_nullable,
+			COALESCE(COLUMN_DEFAULT, '') as default_value,
+			CHARACTER_MAXIMUM_LENGTH as character_maximum_length,
+			CASE WHEN COLUMN_KEY = 'PRI' THEN true ELSE false END as is_primary_key,
+			CASE WHEN EXTRA LIKE '%auto_increment%' THEN 'AUTO_INCREMENT' ELSE '' END as identity,
+			'' as sequence
+		FROM information_schema.columns
+		WHERE table_name = ? AND table_schema = ?
+		ORDER BY ordinal_position`, []interface{}{table, schema}
+}
+
+// Functional key parts (MySQL 8.0.13) have no COLUMN_NAME. The EXPRESSION
+// column that holds them is missing on older servers and MariaDB, so it is
+// not read. MySQL has no partial indexes.
+func (MySQL) IndexesQuery(schema, table string) (string, []interface{}) {
+	return `
+		SELECT
+			INDEX_NAME as index_name,
+			CONCAT('[', GROUP_CONCAT(JSON_QUOTE(COALESCE(COLUMN_NAME, '(expression)')) ORDER BY SEQ_IN_INDEX), ']') as columns,
+			CASE WHEN NON_UNIQUE = 0 THEN true ELSE false END as is_unique,
+			CASE WHEN INDEX_NAME = 'PRIMARY' THEN true ELSE false END as is_primary,
+			false as is_partial,
+			MAX(COLUMN_NAME IS NULL) as is_expression,
+			'' as predicate,
+			'' as definition,
+			'' as constraint_type
+		FROM information_schema.statistics
+		WHERE table_name = ? AND table_schema = ?
+		GROUP BY index_name, non_unique
+		ORDER BY index_name`, []interface{}{table, schema}
+}
+
+func (MySQL) ForeignKeysQuery(schema, table string) (string, []interface{}) {
+	query := `
+		SELECT
+			k.CONSTRAINT_NAME as constraint_name,
+			k.TABLE_SCHEMA as schema_name,
+			k.TABLE_NAME as table_name,
+			CONCAT('[', GROUP_CONCAT(JSON_QUOTE(k.COLUMN_NAME) ORDER BY k.ORDINAL_POSITION), ']') as columns,
+			k.REFERENCED_TABLE_SCHEMA as referenced_schema,
+			k.REFERENCED_TABLE_NAME as referenced_table,
+			CONCAT('[', GROUP_CONCAT(JSON_QUOTE(k.REFERENCED_COLUMN_NAME) ORDER BY k.ORDINAL_POSITION), ']') as referenced_columns,
+			r.UPDATE_RULE as on_update,
+			r.DELETE_RULE as on_delete
+		FROM information_schema.KEY_COLUMN_USAGE k
+		JOIN information_schema.REFERENTIAL_CONSTRAINTS r
+			ON r.CONSTRAINT_SCHEMA = k.CONSTRAINT_SCHEMA
+			AND r.CONSTRAINT_NAME = k.CONSTRAINT_NAME
+			AND r.TABLE_NAME = k.TABLE_NAME
+		WHERE k.REFERENCED_TABLE_NAME IS NOT NULL`
+	group := `
+		GROUP BY k.CONSTRAINT_NAME, k.TABLE_SCHEMA, k.TABLE_NAME, k.REFERENCED_TABLE_SCHEMA,
+			k.REFERENCED_TABLE_NAME, r.UPDATE_RULE, r.DELETE_RULE
+		ORDER BY k.TABLE_SCHEMA, k.TABLE_NAME, k.CONSTRAINT_NAME`
+	if table == "" {
+		return query + `
+			AND (k.TABLE_SCHEMA = ? OR k.REFERENCED_TABLE_SCHEMA = ?)` + group, []interface{}{schema, schema}
+	}
+	return query + `
+			AND ((k.TABLE_SCHEMA = ? AND k.TABLE_NAME = ?)
+				OR (k.REFERENCED_TABLE_SCHEMA = ? AND k.REFERENCED_TABLE_NAME = ?))` + group,
+		[]interface{}{schema, table, schema, table}
+}
+
+// CHECK constraints are enforced from MySQL 8.0.16. Older servers parse and
+// discard them and have no CHECK_CONSTRAINTS table, so the query fails
+// there; CheckConstraintsAvailableQuery tells them apart.
+func (MySQL) CheckConstraintsQuery(schema, table string) (string, []interface{}) {
+	return `
+		SELECT
+			cc.CONSTRAINT_NAME as constraint_name,
+			cc.CHECK_CLAUSE as expression
+		FROM information_schema.TABLE_CONSTRAINTS tc
+		JOIN information_schema.CHECK_CONSTRAINTS cc
+			ON cc.CONSTRAINT_SCHEMA = tc.CONSTRAINT_SCHEMA
+			AND cc.CONSTRAINT_NAME = tc.CONSTRAINT_NAME
+		WHERE tc.CONSTRAINT_TYPE = 'CHECK' AND tc.TABLE_NAME = ? AND tc.TABLE_SCHEMA = ?
+		ORDER BY cc.CONSTRAINT_NAME`, []interface{}{table, schema}
+}
+
+// information_schema lists its own tables, so this works on every version.
+func (MySQL) CheckConstraintsAvailableQuery() string {
+	return `SELECT COUNT(*) FROM information_schema.TABLES
+		WHERE TABLE_SCHEMA = 'information_schema' AND TABLE_NAME = 'CHECK_CONSTRAINTS'`
+}
+
+func (MySQL) ViewsQuery(schema string) (string, []interface{}) {
+	return `
+		SELECT
+			TABLE_NAME as name,
+			VIEW_DEFINITION as definition
+		FROM information_schema.VIEWS
+		WHERE TABLE_SCHEMA = ?
+		ORDER BY TABLE_NAME`, []interface{}{schema}
+}
+
+// ROUTINE_DEFINITION is only the body, and is empty without the privileges
+// to see it.
+func (MySQL) RoutinesQuery(schema string) (string, []interface{}) {
+	return `
+		SELECT
+			r.ROUTINE_NAME as name,
+			r.ROUTINE_TYPE as kind,
+			COALESCE((
+				SELECT GROUP_CONCAT(CONCAT_WS(' ', p.PARAMETER_MODE, p.PARAMETER_NAME, p.DTD_IDENTIFIER)
+					ORDER BY p.ORDINAL_POSITION SEPARATOR ', ')
+				FROM information_schema.PARAMETERS p
+				WHERE p.SPECIFIC_SCHEMA = r.ROUTINE_SCHEMA AND p.SPECIFIC_NAME = r.SPECIFIC_NAME
+					AND p.ORDINAL_POSITION > 0
+			), '') as arguments,
+			COALESCE(r.ROUTINE_DEFINITION, '') as definition
+		FROM information_schema.ROUTINES r
+		WHERE r.ROUTINE_SCHEMA = ?
+		ORDER BY r.ROUTINE_NAME`, []interface{}{schema}
+}
+
+// Indexes have no SHOW CREATE of their own; they are part of their
+// table's.
+func (m MySQL) CreateStatementQuery(kind, schema, name string) (string, []interface{}, string) {
+	qualified := m.QualifiedName(schema, name)
+	switch kind {
+	case "table":
+		return "SHOW CREATE TABLE " + qualified, nil, "Create Table"
+	case "view":
+		return "SHOW CREATE VIEW " + qualified, nil, "Create View"
+	case "function":
+		return "SHOW CREATE FUNCTION " + qualified, nil, "Create Function"
+	case "procedure":
+		return "SHOW CREATE PROCEDURE " + qualified, nil, "Create Procedure"
+	case "trigger":
+		return "SHOW CREATE TRIGGER " + qualified, nil, "SQL Original Statement"
+	}
+	return "", nil, ""
+}
+
+func (MySQL) TableSizeQuery(schema, table string) (string, []interface{}) {
+	return `
+		SELECT
+			CONCAT(ROUND(((data_length + index_length) / 1024 / 1024), 2), ' MB') AS total_size,
+			CONCAT(ROUND((data_length / 1024 / 1024), 2), ' MB') AS table_size,
+			CONCAT(ROUND((index_length / 1024 / 1024), 2), ' MB') AS index_size
+		FROM information_schema.tables
+		WHERE table_schema = ? AND table_name = ?`, []interface{}{schema, table}
+}
+
+func (MySQL) LastAnalyzedQuery(schema, table string) (string, []interface{}) {
+	return "", nil
+}
+
+func (MySQL) ColumnNullabilityQuery(schema, table string) (string, []interface{}) {
+	return `
+		SELECT
+			COLUMN_NAME,
+			CASE
+				WHEN IS_NULLABLE = 'YES' THEN 'Nullable'
+				ELSE 'Not Null'
+			END as nullability
+		FROM information_schema.columns
+		WHERE table_schema = ? AND table_name = ?
+		ORDER BY ordinal_position
+		LIMIT 5`, []interface{}{schema, table}
+}
+
+func (MySQL) TableRowEstimatesQuery(tables []string) (string, []interface{}) {
+	return "", nil
+}
+
+func (MySQL) ExplainQuery(query string) string {
+	return fmt.Sprintf("EXPLAIN FORMAT=JSON %s", query)
+}
+
+// EXPLAIN ANALYZE needs MySQL 8.0.18 and only prints the tree format.
+func (MySQL) ExplainAnalyzeQuery(query string) string {
+	return fmt.Sprintf("EXPLAIN ANALYZE %s", query)
+}
+
+func (MySQL) HypotheticalIndexQueries() (string, string, string) {
+	return "", "", ""
+}
+
+func (MySQL) StatementStatsQueries() (string, string, string) {
+	return "performance_schema",
+		"SELECT IF(@@performance_schema, VERSION(), '')",
+		"TRUNCATE TABLE performance_schema.events_statements_summary_by_digest"
+}
+
+// Timers are in picoseconds. The digest table has no buffer counters, so
+// hit_ratio is NULL.
+func (MySQL) StatementStatsQuery(version string) string {
+	return `SELECT DIGEST AS id, DIGEST_TEXT AS query, COUNT_STAR AS calls,
+			SUM_TIMER_WAIT / 1000000000 AS total_time_ms,
+			AVG_TIMER_WAIT / 1000000000 AS mean_time_ms,
+			SUM_ROWS_SENT + SUM_ROWS_AFFECTED AS ` + "`rows`" + `,
+			NULL AS hit_ratio
+		FROM performance_schema.events_statements_summary_by_digest
+		WHERE SCHEMA_NAME = DATABASE()`
+}
+
+// data_lock_waits (MySQL 8.0) names threads, not connections; threads maps
+// them to processlist ids. It only covers InnoDB row and table locks.
+func (MySQL) SessionsQuery() string {
+	return `SELECT p.ID AS id,
+			COALESCE(p.USER, '') AS user_name,
+			COALESCE(p.DB, '') AS database_name,
+			COALESCE(p.HOST, '') AS client_address,
+			'' AS application,
+			p.COMMAND AS state,
+			COALESCE(p.INFO, '') AS query,
+			p.TIME * 1000 AS duration_ms,
+			NULLIF(p.STATE, '') AS wait_event,
+			(
+				SELECT CONCAT(l.LOCK_TYPE, ' ', l.LOCK_MODE, ' on ', l.OBJECT_SCHEMA, '.', l.OBJECT_NAME)
+				FROM performance_schema.data_lock_waits w
+				JOIN performance_schema.data_locks l ON l.ENGINE_LOCK_ID = w.REQUESTING_ENGINE_LOCK_ID
+				JOIN performance_schema.threads t ON t.THREAD_ID = w.REQUESTING_THREAD_ID
+				WHERE t.PROCESSLIST_ID = p.ID
+				LIMIT 1
+			) AS waiting_for,
+			(
+				SELECT GROUP_CONCAT(DISTINCT bt.PROCESSLIST_ID)
+				FROM performance_schema.data_lock_waits w
+				JOIN performance_schema.threads rt ON rt.THREAD_ID = w.REQUESTING_THREAD_ID
+				JOIN performance_schema.threads bt ON bt.THREAD_ID = w.BLOCKING_THREAD_ID
+				WHERE rt.PROCESSLIST_ID = p.ID
+			) AS blocked_by,
+			p.COMMAND = 'Sleep' AS idle
+		FROM information_schema.PROCESSLIST p
+		WHERE p.ID <> CONNECTION_ID() AND p.COMMAND <> 'Daemon'`
+}
+
+func (MySQL) CancelSessionQuery(id int64, terminate bool) string {
+	if terminate {
+		return fmt.Sprintf("KILL %d", id)
+	}
+	return fmt.Sprintf("KILL QUERY %d", id)
+}
+
+// COLUMN_DEFAULT holds literals unquoted, so anything that does not look
+// like a number, NULL or an expression is quoted back.
+func (m MySQL) ColumnDefinition(column, columnType string, nullable bool, defaultValue string) string {
+	def := m.QuoteIdentifier(column) + " " + columnType
+	if nullable {
+		def += " NULL"
+	} else {
+		def += " NOT NULL"
+	}
+	if defaultValue != "" {
+		def += " DEFAULT " + mysqlDefault(defaultValue)
+	}
+	return def
+}
+
+func mysqlDefault(v string) string {
+	if _, err := strconv.ParseFloat(v, 64); err == nil {
+		return v
+	}
+	upper := strings.ToUpper(v)
+	if upper == "NULL" || strings.HasPrefix(upper, "CURRENT_TIMESTAMP") || strings.HasPrefix(v, "(") {
+		return v
+	}
+	return "'" + strings.ReplaceAll(v, "'", "''") + "'"
+}
+
+func (m MySQL) AlterColumnDDL(schema, table, column, columnType string, nullable bool, defaultValue string) []string {
+	return []string{"ALTER TABLE " + m.QualifiedName(schema, table) + " MODIFY COLUMN " + m.ColumnDefinition(column, columnType, nullable, defaultValue)}
+}
+
+func (m MySQL) DropIndexDDL(schema, table, index string) string {
+	return "DROP INDEX " + m.QuoteIdentifier(index) + " ON " + m.QualifiedName(schema, table)
+}
+
+func (m MySQL) AddConstraintDDL(schema, table, constraint string) string {
+	return "ALTER TABLE " + m.QualifiedName(schema, table) + " ADD " + constraint
+}
+
+func (m MySQL) DropConstraintDDL(schema, table, name, kind string) string {
+	alter := "ALTER TABLE " + m.QualifiedName(schema, table)
+	if kind == "PRIMARY KEY" {
+		return alter + " DROP PRIMARY KEY"
+	}
+	return alter + " DROP " + kind + " " + m.QuoteIdentifier(name)
+}
+
+// MySQL routines cannot be overloaded, so the name is enough.
+func (m MySQL) DropRoutineDDL(schema, kind, name, arguments string) string {
+	return "DROP " + kind + " " + m.QualifiedName(schema, name)
+}
+
+// The mysql driver already issues START TRANSACTION READ ONLY for read-only
+// transactions.
+func (MySQL) ReadOnlyStatements() (string, string) {
+	return "", ""
+}
+
+// Writes to MyISAM and other non-transactional tables persist through a
+// rollback, and which engines a statement writes to, through triggers and
+// foreign keys included, is not known up front.
+func (MySQL) RollbackUndoesWrites() bool {
+	return false
+}
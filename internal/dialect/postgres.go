@@ -0,0 +1,429 @@
+package dialect
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type Postgres struct{}
+
+func (Postgres) Name() string {
+	return "postgres"
+}
+
+func (Postgres) DriverName() string {
+	return "postgres"
+}
+
+func (Postgres) QuoteIdentifier(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+func (p Postgres) QualifiedName(schema, table string) string {
+	if schema == "" {
+		return p.QuoteIdentifier(table)
+	}
+	return p.QuoteIdentifier(schema) + "." + p.QuoteIdentifier(table)
+}
+
+func (Postgres) Placeholder(n int) string {
+	return fmt.Sprintf("$%d", n)
+}
+
+func (Postgres) CurrentSchemaQuery() string {
+	return "SELECT current_schema()"
+}
+
+func (Postgres) DatabaseNameQuery() string {
+	return "SELECT current_database()"
+}
+
+func (Postgres) VersionQuery() string {
+	return "SELECT version()"
+}
+
+func (Postgres) FormatVersion(version string) string {
+	if strings.Contains(version, "PostgreSQL") {
+		parts := strings.Fields(version)
+		if len(parts) >= 2 {
+			return "PostgreSQL " + parts[1]
+		}
+	}
+	return version
+}
+
+func (Postgres) SchemasQuery() string {
+	return "SELECT schema_name FROM information_schema.schemata WHERE schema_name NOT IN ('information_schema', 'pg_catalog', 'pg_toast')"
+}
+
+func (Postgres) TableCountQuery() string {
+	return "SELECT COUNT(*) FROM information_schema.tables WHERE table_schema NOT IN ('information_schema', 'pg_catalog', 'pg_toast')"
+}
+
+func (Postgres) ListTablesQuery(schema string) (string, []interface{}) {
+	if schema != "" {
+		return `
+			SELECT
+				table_name as name,
+				table_schema as schema_name,
+				table_type as table_type
+			FROM information_schema.tables
+			WHERE table_schema = $1
+			ORDER BY table_name`, []interface{}{schema}
+	}
+	return `
+		SELECT
+			table_name as name,
+			table_schema as schema_name,
+			table_type as table_type
+		FROM information_schema.tables
+		WHERE table_schema NOT IN ('information_schema', 'pg_catalog')
+		ORDER BY table_name`, nil
+}
+
+func (Postgres) ColumnsQuery(schema, table string) (string, []interface{}) {
+	return `
+		SELECT
+			c.column_name,
+			c.data_type,
+			COALESCE((
+				SELECT format_type(a.atttypid, a.atttypmod)
+				FROM pg_attribute a
+				JOIN pg_class t ON t.oid = a.attrelid
+				JOIN pg_namespace n ON n.oid = t.relnamespace
+				WHERE n.nspname = c.table_schema AND t.relname = c.table_name AND a.attname = c.column_name
+			), c.data_type) as column_type,
+			CASE WHEN c.is_nullable = 'YES' THEN true ELSE false END as is_nullable,
+			COALESCE(c.column_default, '') as default_value,
+			c.character_maximum_length,
+			CASE WHEN pk.column_name IS NOT NULL THEN true ELSE false END as is_primary_key,
+			CASE WHEN c.is_identity = 'YES' THEN 'GENERATED ' || c.identity_generation || ' AS IDENTITY' ELSE '' END as identity,
+			CASE WHEN c.is_identity = 'YES' THEN '' ELSE COALESCE((
+				SELECT s.relname FROM pg_class s
+				WHERE s.oid = pg_get_serial_sequence(quote_ident(c.table_schema) || '.' || quote_ident(c.table_name), c.column_name)::regclass
+			), '') END as sequence
+		FROM information_schema.columns c
+		LEFT JOIN (
+			SELECT ku.column_name
+			FROM information_schema.table_constraints tc
+			JOIN information_schema.key_column_usage ku
+				ON tc.constraint_name = ku.constraint_name
+				AND tc.table_schema = ku.table_schema
+			WHERE tc.constraint_type = 'PRIMARY KEY'
+				AND tc.table_name = $1
+				AND tc.table_schema = $2
+		) pk ON c.column_name = pk.column_name
+		WHERE c.table_name = $1 AND c.table_schema = $2
+		ORDER BY c.ordinal_position`, []interface{}{table, schema}
+}
+
+func (Postgres) IndexesQuery(schema, table string) (string, []interface{}) {
+	return `
+		SELECT
+			i.relname as index_name,
+			(
+				SELECT json_agg(COALESCE(a.attname, '(expression)') ORDER BY k.n)::text
+				FROM generate_subscripts(ix.indkey, 1) k(n)
+				LEFT JOIN pg_attribute a ON a.attrelid = t.oid AND a.attnum = ix.indkey[k.n] AND ix.indkey[k.n] <> 0
+				WHERE k.n < ix.indnkeyatts
+			) as columns,
+			ix.indisunique as is_unique,
+			ix.indisprimary as is_primary,
+			ix.indpred IS NOT NULL as is_partial,
+			ix.indexprs IS NOT NULL as is_expression,
+			COALESCE(pg_get_expr(ix.indpred, ix.indrelid, true), '') as predicate,
+			pg_get_indexdef(ix.indexrelid) as definition,
+			COALESCE((
+				SELECT CASE con.contype WHEN 'u' THEN 'UNIQUE' ELSE 'EXCLUDE' END
+				FROM pg_constraint con
+				WHERE con.conrelid = t.oid AND con.conindid = ix.indexrelid AND con.contype IN ('u', 'x')
+			), '') as constraint_type
+		FROM pg_class t
+		JOIN pg_index ix ON t.oid = ix.indrelid
+		JOIN pg_class i ON i.oid = ix.indexrelid
+		JOIN pg_namespace n ON n.oid = t.relnamespace
+		WHERE t.relname = $1 AND n.nspname = $2
+		ORDER BY i.relname`, []interface{}{table, schema}
+}
+
+func (Postgres) ForeignKeysQuery(schema, table string) (string, []interface{}) {
+	query := `
+		SELECT
+			c.conname as constraint_name,
+			sn.nspname as schema_name,
+			st.relname as table_name,
+			(
+				SELECT json_agg(a.attname ORDER BY k.n)::text
+				FROM unnest(c.conkey) WITH ORDINALITY k(attnum, n)
+				JOIN pg_attribute a ON a.attrelid = c.conrelid AND a.attnum = k.attnum
+			) as columns,
+			tn.nspname as referenced_schema,
+			tt.relname as referenced_table,
+			(
+				SELECT json_agg(a.attname ORDER BY k.n)::text
+				FROM unnest(c.confkey) WITH ORDINALITY k(attnum, n)
+				JOIN pg_attribute a ON a.attrelid = c.confrelid AND a.attnum = k.attnum
+			) as referenced_columns,
+			` + pgReferentialAction("c.confupdtype") + ` as on_update,
+			` + pgReferentialAction("c.confdeltype") + ` as on_delete
+		FROM pg_constraint c
+		JOIN pg_class st ON st.oid = c.conrelid
+		JOIN pg_namespace sn ON sn.oid = st.relnamespace
+		JOIN pg_class tt ON tt.oid = c.confrelid
+		JOIN pg_namespace tn ON tn.oid = tt.relnamespace
+		WHERE c.contype = 'f'`
+	if table == "" {
+		return query + `
+			AND (sn.nspname = $1 OR tn.nspname = $1)
+		ORDER BY sn.nspname, st.relname, c.conname`, []interface{}{schema}
+	}
+	return query + `
+			AND ((sn.nspname = $1 AND st.relname = $2) OR (tn.nspname = $1 AND tt.relname = $2))
+		ORDER BY sn.nspname, st.relname, c.conname`, []interface{}{schema, table}
+}
+
+func pgReferentialAction(column string) string {
+	return `CASE ` + column + `
+				WHEN 'r' THEN 'RESTRICT'
+				WHEN 'c' THEN 'CASCADE'
+				WHEN 'n' THEN 'SET NULL'
+				WHEN 'd' THEN 'SET DEFAULT'
+				ELSE 'NO ACTION'
+			END`
+}
+
+func (Postgres) CheckConstraintsQuery(schema, table string) (string, []interface{}) {
+	return `
+		SELECT
+			c.conname as constraint_name,
+			pg_get_constraintdef(c.oid, true) as expression
+		FROM pg_constraint c
+		JOIN pg_class t ON t.oid = c.conrelid
+		JOIN pg_namespace n ON n.oid = t.relnamespace
+		WHERE c.contype = 'c' AND t.relname = $1 AND n.nspname = $2
+		ORDER BY c.conname`, []interface{}{table, schema}
+}
+
+func (Postgres) CheckConstraintsAvailableQuery() string {
+	return ""
+}
+
+func (Postgres) ViewsQuery(schema string) (string, []interface{}) {
+	return `
+		SELECT
+			viewname as name,
+			definition
+		FROM pg_views
+		WHERE schemaname = $1
+		ORDER BY viewname`, []interface{}{schema}
+}
+
+// Functions that belong to an extension are left out; they come and go
+// with the extension.
+func (Postgres) RoutinesQuery(schema string) (string, []interface{}) {
+	return `
+		SELECT
+			p.proname as name,
+			CASE p.prokind WHEN 'p' THEN 'PROCEDURE' ELSE 'FUNCTION' END as kind,
+			pg_get_function_identity_arguments(p.oid) as arguments,
+			pg_get_functiondef(p.oid) as definition
+		FROM pg_proc p
+		JOIN pg_namespace n ON n.oid = p.pronamespace
+		WHERE n.nspname = $1 AND p.prokind IN ('f', 'p')
+			AND NOT EXISTS (
+				SELECT 1 FROM pg_depend dep
+				WHERE dep.classid = 'pg_proc'::regclass AND dep.objid = p.oid AND dep.deptype = 'e'
+			)
+		ORDER BY p.proname, arguments`, []interface{}{schema}
+}
+
+// Postgres has no SHOW CREATE; see the pgddl package.
+func (Postgres) CreateStatementQuery(kind, schema, name string) (string, []interface{}, string) {
+	return "", nil, ""
+}
+
+func (p Postgres) TableSizeQuery(schema, table string) (string, []interface{}) {
+	return `
+		SELECT
+			pg_size_pretty(pg_total_relation_size(c.oid)) as total_size,
+			pg_size_pretty(pg_relation_size(c.oid)) as table_size,
+			pg_size_pretty(pg_total_relation_size(c.oid) - pg_relation_size(c.oid)) as index_size
+		FROM pg_class c
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		WHERE n.nspname = $1 AND c.relname = $2`, []interface{}{schema, table}
+}
+
+func (Postgres) LastAnalyzedQuery(schema, table string) (string, []interface{}) {
+	return `
+		SELECT
+			last_analyze,
+			last_autoanalyze
+		FROM pg_stat_user_tables
+		WHERE schemaname = $1 AND relname = $2`, []interface{}{schema, table}
+}
+
+func (Postgres) ColumnNullabilityQuery(schema, table string) (string, []interface{}) {
+	return `
+		SELECT
+			attname as column_name,
+			CASE
+				WHEN attnotnull THEN 'Not Null'
+				ELSE 'Nullable'
+			END as nullability
+		FROM pg_attribute a
+		JOIN pg_class t ON a.attrelid = t.oid
+		JOIN pg_namespace n ON t.relnamespace = n.oid
+		WHERE n.nspname = $1 AND t.relname = $2 AND a.attnum > 0 AND NOT a.attisdropped
+		ORDER BY a.attnum
+		LIMIT 5`, []interface{}{schema, table}
+}
+
+// reltuples is -1 for tables never vacuumed or analyzed.
+func (p Postgres) TableRowEstimatesQuery(tables []string) (string, []interface{}) {
+	placeholders := make([]string, len(tables))
+	args := make([]interface{}, len(tables))
+	for i, t := range tables {
+		placeholders[i] = p.Placeholder(i + 1)
+		args[i] = t
+	}
+	return `
+		SELECT c.relname, c.reltuples
+		FROM pg_class c
+		WHERE c.relname IN (` + strings.Join(placeholders, ", ") + `)
+			AND c.relkind IN ('r', 'p', 'm')
+			AND c.reltuples >= 0
+			AND pg_table_is_visible(c.oid)`, args
+}
+
+func (Postgres) ExplainQuery(query string) string {
+	return fmt.Sprintf("EXPLAIN (FORMAT JSON) %s", query)
+}
+
+func (Postgres) ExplainAnalyzeQuery(query string) string {
+	return fmt.Sprintf("EXPLAIN (ANALYZE, BUFFERS, FORMAT JSON) %s", query)
+}
+
+// Hypothetical indexes come from the hypopg extension and only live in the
+// session that creates them.
+func (Postgres) HypotheticalIndexQueries() (string, string, string) {
+	return "SELECT count(*) FROM pg_extension WHERE extname = 'hypopg'",
+		"SELECT indexname FROM hypopg_create_index($1)",
+		"SELECT hypopg_reset()"
+}
+
+func (Postgres) StatementStatsQueries() (string, string, string) {
+	return "pg_stat_statements",
+		"SELECT COALESCE(MAX(extversion), '') FROM pg_extension WHERE extname = 'pg_stat_statements'",
+		"SELECT pg_stat_statements_reset()"
+}
+
+// pg_stat_statements 1.8 (Postgres 13) renamed the timing columns from
+// total_time and mean_time to total_exec_time and mean_exec_time.
+func (Postgres) StatementStatsQuery(version string) string {
+	total, mean := "total_exec_time", "mean_exec_time"
+	if extensionVersionBefore(version, 1, 8) {
+		total, mean = "total_time", "mean_time"
+	}
+	return `SELECT queryid::text AS id, query, calls,
+			` + total + ` AS total_time_ms,
+			` + mean + ` AS mean_time_ms,
+			rows,
+			CASE WHEN shared_blks_hit + shared_blks_read > 0
+				THEN shared_blks_hit::float8 / (shared_blks_hit + shared_blks_read) END AS hit_ratio
+		FROM pg_stat_statements
+		WHERE dbid = (SELECT oid FROM pg_database WHERE datname = current_database())`
+}
+
+// extensionVersionBefore reports whether an extension version such as "1.7"
+// is older than major.minor. Versions it cannot read count as current.
+func extensionVersionBefore(version string, major, minor int) bool {
+	parts := strings.SplitN(version, ".", 3)
+	gotMajor, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return false
+	}
+	gotMinor := 0
+	if len(parts) > 1 {
+		if gotMinor, err = strconv.Atoi(parts[1]); err != nil {
+			return false
+		}
+	}
+	return gotMajor < major || gotMajor == major && gotMinor < minor
+}
+
+// pg_blocking_pids reads pg_locks for us; waiting_for is the lock a
+// session waits on.
+func (Postgres) SessionsQuery() string {
+	return `SELECT a.pid AS id,
+			COALESCE(a.usename, '') AS user_name,
+			COALESCE(a.datname, '') AS database_name,
+			COALESCE(host(a.client_addr), '') AS client_address,
+			COALESCE(a.application_name, '') AS application,
+			COALESCE(a.state, '') AS state,
+			COALESCE(a.query, '') AS query,
+			EXTRACT(EPOCH FROM now() - a.query_start) * 1000 AS duration_ms,
+			CASE WHEN a.wait_event IS NOT NULL THEN a.wait_event_type || ': ' || a.wait_event END AS wait_event,
+			(
+				SELECT l.locktype || ' ' || l.mode ||
+					COALESCE(' on ' || l.relation::regclass::text, '')
+				FROM pg_locks l
+				WHERE l.pid = a.pid AND NOT l.granted
+				LIMIT 1
+			) AS waiting_for,
+			array_to_string(pg_blocking_pids(a.pid), ',') AS blocked_by,
+			COALESCE(a.state = 'idle', false) AS idle
+		FROM pg_stat_activity a
+		WHERE a.pid <> pg_backend_pid() AND a.backend_type = 'client backend'`
+}
+
+func (Postgres) CancelSessionQuery(id int64, terminate bool) string {
+	if terminate {
+		return fmt.Sprintf("SELECT pg_terminate_backend(%d)", id)
+	}
+	return fmt.Sprintf("SELECT pg_cancel_backend(%d)", id)
+}
+
+func (p Postgres) ColumnDefinition(column, columnType string, nullable bool, defaultValue string) string {
+	return columnDefinition(p, column, columnType, nullable, defaultValue)
+}
+
+func (p Postgres) AlterColumnDDL(schema, table, column, columnType string, nullable bool, defaultValue string) []string {
+	alter := "ALTER TABLE " + p.QualifiedName(schema, table) + " ALTER COLUMN " + p.QuoteIdentifier(column)
+	statements := []string{alter + " TYPE " + columnType}
+	if nullable {
+		statements = append(statements, alter+" DROP NOT NULL")
+	} else {
+		statements = append(statements, alter+" SET NOT NULL")
+	}
+	if defaultValue == "" {
+		statements = append(statements, alter+" DROP DEFAULT")
+	} else {
+		statements = append(statements, alter+" SET DEFAULT "+defaultValue)
+	}
+	return statements
+}
+
+func (p Postgres) DropIndexDDL(schema, table, index string) string {
+	return "DROP INDEX " + p.QualifiedName(schema, index)
+}
+
+func (p Postgres) AddConstraintDDL(schema, table, constraint string) string {
+	return "ALTER TABLE " + p.QualifiedName(schema, table) + " ADD " + constraint
+}
+
+func (p Postgres) DropConstraintDDL(schema, table, name, kind string) string {
+	return "ALTER TABLE " + p.QualifiedName(schema, table) + " DROP CONSTRAINT " + p.QuoteIdentifier(name)
+}
+
+func (p Postgres) DropRoutineDDL(schema, kind, name, arguments string) string {
+	return "DROP " + kind + " " + p.QualifiedName(schema, name) + "(" + arguments + ")"
+}
+
+func (Postgres) ReadOnlyStatements() (string, string) {
+	return "SET TRANSACTION READ ONLY", ""
+}
+
+func (Postgres) RollbackUndoesWrites() bool {
+	return true
+}
@@ -0,0 +1,288 @@
+package dialect
+
+import (
+	"fmt"
+	"strings"
+)
+
+type SQLite struct{}
+
+func (SQLite) Name() string {
+	return "sqlite"
+}
+
+func (SQLite) DriverName() string {
+	return "sqlite"
+}
+
+func (SQLite) QuoteIdentifier(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+func (s SQLite) QualifiedName(schema, table string) string {
+	if schema == "" {
+		return s.QuoteIdentifier(table)
+	}
+	return s.QuoteIdentifier(schema) + "." + s.QuoteIdentifier(table)
+}
+
+func (SQLite) Placeholder(n int) string {
+	return fmt.Sprintf("?%d", n)
+}
+
+func (SQLite) CurrentSchemaQuery() string {
+	return "SELECT 'main'"
+}
+
+func (SQLite) DatabaseNameQuery() string {
+	return "SELECT COALESCE(NULLIF(file, ''), name) FROM pragma_database_list WHERE name = 'main'"
+}
+
+func (SQLite) VersionQuery() string {
+	return "SELECT sqlite_version()"
+}
+
+func (SQLite) FormatVersion(version string) string {
+	return "SQLite " + version
+}
+
+func (SQLite) SchemasQuery() string {
+	return "SELECT name FROM pragma_database_list ORDER BY seq"
+}
+
+func (SQLite) TableCountQuery() string {
+	return "SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name NOT LIKE 'sqlite_%'"
+}
+
+func (s SQLite) ListTablesQuery(schema string) (string, []interface{}) {
+	if schema == "" {
+		schema = "main"
+	}
+	return `
+		SELECT
+			name,
+			?1 as schema_name,
+			CASE type WHEN 'table' THEN 'BASE TABLE' ELSE 'VIEW' END as table_type
+		FROM ` + s.QuoteIdentifier(schema) + `.sqlite_master
+		WHERE type IN ('table', 'view') AND name NOT LIKE 'sqlite_%'
+		ORDER BY name`, []interface{}{schema}
+}
+
+func (SQLite) ColumnsQuery(schema, table string) (string, []interface{}) {
+	return `
+		SELECT
+			name,
+			type,
+			type as column_type,
+			"notnull" = 0 as is_nullable,
+			COALESCE(dflt_value, '') as default_value,
+			NULL as character_maximum_length,
+			pk > 0 as is_primary_key,
+			'' as identity,
+			'' as sequence
+		FROM pragma_table_info(?1, ?2)
+		ORDER BY cid`, []interface{}{table, schema}
+}
+
+func (s SQLite) IndexesQuery(schema, table string) (string, []interface{}) {
+	if schema == "" {
+		schema = "main"
+	}
+	return `
+		SELECT
+			il.name as index_name,
+			(
+				SELECT json_group_array(COALESCE(ii.name, '(expression)'))
+				FROM (SELECT name FROM pragma_index_info(il.name, ?2) ORDER BY seqno) ii
+			) as columns,
+			il."unique" as is_unique,
+			il.origin = 'pk' as is_primary,
+			il.partial as is_partial,
+			EXISTS (SELECT 1 FROM pragma_index_info(il.name, ?2) WHERE cid = -2) as is_expression,
+			'' as predicate,
+			COALESCE((
+				SELECT sql FROM ` + s.QuoteIdentifier(schema) + `.sqlite_master WHERE type = 'index' AND name = il.name
+			), '') as definition,
+			'' as constraint_type
+		FROM pragma_index_list(?1, ?2) il
+		ORDER BY il.name`, []interface{}{table, schema}
+}
+
+// SQLite foreign keys have no names; they are called fk_<table>_<id>. A
+// missing target column list means the parent's primary key.
+func (s SQLite) ForeignKeysQuery(schema, table string) (string, []interface{}) {
+	if schema == "" {
+		schema = "main"
+	}
+	filter, args := "", []interface{}{schema}
+	if table != "" {
+		filter, args = ` AND (m.name = ?2 OR fk."table" = ?2)`, append(args, table)
+	}
+	return `
+		SELECT
+			'fk_' || m.name || '_' || fk.id as constraint_name,
+			?1 as schema_name,
+			m.name as table_name,
+			json_group_array(fk."from") as columns,
+			?1 as referenced_schema,
+			fk."table" as referenced_table,
+			json_group_array(COALESCE(fk."to", (
+				SELECT p.name FROM pragma_table_info(fk."table", ?1) p WHERE p.pk = fk.seq + 1
+			))) as referenced_columns,
+			fk.on_update,
+			fk.on_delete
+		FROM ` + s.QuoteIdentifier(schema) + `.sqlite_master m
+		JOIN pragma_foreign_key_list(m.name, ?1) fk
+		WHERE m.type = 'table'` + filter + `
+		GROUP BY m.name, fk.id
+		ORDER BY m.name, fk.id`, args
+}
+
+func (s SQLite) ViewsQuery(schema string) (string, []interface{}) {
+	if schema == "" {
+		schema = "main"
+	}
+	return `
+		SELECT
+			name,
+			sql as definition
+		FROM ` + s.QuoteIdentifier(schema) + `.sqlite_master
+		WHERE type = 'view'
+		ORDER BY name`, nil
+}
+
+func (SQLite) RoutinesQuery(schema string) (string, []interface{}) {
+	return "", nil
+}
+
+// SQLite keeps CHECK constraints only in the CREATE TABLE text.
+func (SQLite) CheckConstraintsQuery(schema, table string) (string, []interface{}) {
+	return "", nil
+}
+
+func (SQLite) CheckConstraintsAvailableQuery() string {
+	return ""
+}
+
+// A table comes with its indexes and triggers, as on the other engines.
+func (s SQLite) CreateStatementQuery(kind, schema, name string) (string, []interface{}, string) {
+	if schema == "" {
+		schema = "main"
+	}
+	master := s.QuoteIdentifier(schema) + ".sqlite_master"
+	switch kind {
+	case "table":
+		return `
+			SELECT group_concat(sql || ';', char(10)) as sql
+			FROM (
+				SELECT sql FROM ` + master + `
+				WHERE tbl_name = ?1 AND sql IS NOT NULL AND type IN ('table', 'index', 'trigger')
+					AND EXISTS (SELECT 1 FROM ` + master + ` WHERE type = 'table' AND name = ?1)
+				ORDER BY CASE type WHEN 'table' THEN 0 WHEN 'index' THEN 1 ELSE 2 END, name
+			)
+			HAVING count(*) > 0`, []interface{}{name}, "sql"
+	case "view", "index", "trigger":
+		return "SELECT sql FROM " + master + " WHERE type = ?1 AND name = ?2 AND sql IS NOT NULL", []interface{}{kind, name}, "sql"
+	}
+	return "", nil, ""
+}
+
+func (SQLite) TableSizeQuery(schema, table string) (string, []interface{}) {
+	return `
+		SELECT
+			printf('%.2f MB', (t + i) / 1048576.0) as total_size,
+			printf('%.2f MB', t / 1048576.0) as table_size,
+			printf('%.2f MB', i / 1048576.0) as index_size
+		FROM (
+			SELECT
+				COALESCE((SELECT SUM(pgsize) FROM dbstat(?2) WHERE name = ?1), 0) as t,
+				COALESCE((SELECT SUM(pgsize) FROM dbstat(?2) WHERE name IN (SELECT name FROM pragma_index_list(?1, ?2))), 0) as i
+		)`, []interface{}{table, schema}
+}
+
+func (SQLite) LastAnalyzedQuery(schema, table string) (string, []interface{}) {
+	return "", nil
+}
+
+func (SQLite) ColumnNullabilityQuery(schema, table string) (string, []interface{}) {
+	return `
+		SELECT
+			name,
+			CASE
+				WHEN "notnull" THEN 'Not Null'
+				ELSE 'Nullable'
+			END as nullability
+		FROM pragma_table_info(?1, ?2)
+		ORDER BY cid
+		LIMIT 5`, []interface{}{table, schema}
+}
+
+func (SQLite) TableRowEstimatesQuery(tables []string) (string, []interface{}) {
+	return "", nil
+}
+
+func (SQLite) ExplainQuery(query string) string {
+	return fmt.Sprintf("EXPLAIN QUERY PLAN %s", query)
+}
+
+// EXPLAIN QUERY PLAN has no actual counts or timings.
+func (SQLite) ExplainAnalyzeQuery(query string) string {
+	return ""
+}
+
+func (SQLite) HypotheticalIndexQueries() (string, string, string) {
+	return "", "", ""
+}
+
+func (SQLite) StatementStatsQueries() (string, string, string) {
+	return "", "", ""
+}
+
+func (SQLite) StatementStatsQuery(version string) string {
+	return ""
+}
+
+// SQLite is embedded; there are no other sessions to see.
+func (SQLite) SessionsQuery() string {
+	return ""
+}
+
+func (SQLite) CancelSessionQuery(id int64, terminate bool) string {
+	return ""
+}
+
+func (s SQLite) ColumnDefinition(column, columnType string, nullable bool, defaultValue string) string {
+	return columnDefinition(s, column, columnType, nullable, defaultValue)
+}
+
+// SQLite can only add, rename and drop columns; everything else takes a
+// table rebuild.
+func (SQLite) AlterColumnDDL(schema, table, column, columnType string, nullable bool, defaultValue string) []string {
+	return nil
+}
+
+func (s SQLite) DropIndexDDL(schema, table, index string) string {
+	return "DROP INDEX " + s.QualifiedName(schema, index)
+}
+
+func (SQLite) AddConstraintDDL(schema, table, constraint string) string {
+	return ""
+}
+
+func (SQLite) DropConstraintDDL(schema, table, name, kind string) string {
+	return ""
+}
+
+func (SQLite) DropRoutineDDL(schema, kind, name, arguments string) string {
+	return ""
+}
+
+// SQLite has no read-only transactions, so query_only is switched on for the
+// connection and switched back off once the transaction is over.
+func (SQLite) ReadOnlyStatements() (string, string) {
+	return "PRAGMA query_only = ON", "PRAGMA query_only = OFF"
+}
+
+func (SQLite) RollbackUndoesWrites() bool {
+	return true
+}
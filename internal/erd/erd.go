@@ -0,0 +1,176 @@
+package erd
+
+import (
+	"fmt"
+	"html"
+	"strings"
+)
+
+type Format string
+
+const (
+	Mermaid Format = "mermaid"
+	DOT     Format = "dot"
+)
+
+// ParseFormat validates a diagram format; empty means Mermaid.
+func ParseFormat(s string) (Format, error) {
+	switch f := Format(strings.ToLower(s)); f {
+	case "":
+		return Mermaid, nil
+	case Mermaid, DOT:
+		return f, nil
+	default:
+		return "", fmt.Errorf("unsupported diagram format %q (expected mermaid or dot)", s)
+	}
+}
+
+type Column struct {
+	Name       string
+	Type       string
+	PrimaryKey bool
+	ForeignKey bool
+	Unique     bool
+}
+
+type Table struct {
+	Name    string
+	Columns []Column
+}
+
+// Relation is a foreign key from Child to Parent.
+type Relation struct {
+	Name   string
+	Child  string
+	Parent string
+	// OneToOne is set when the child's key columns are unique, so each
+	// parent row has at most one child.
+	OneToOne bool
+	// Optional is set when a key column is nullable, so a child row need
+	// not have a parent.
+	Optional bool
+}
+
+type Diagram struct {
+	Tables    []Table
+	Relations []Relation
+}
+
+func Render(f Format, d Diagram) string {
+	if f == DOT {
+		return renderDOT(d)
+	}
+	return renderMermaid(d)
+}
+
+func renderMermaid(d Diagram) string {
+	var b strings.Builder
+	b.WriteString("erDiagram\n")
+	for _, t := range d.Tables {
+		if len(t.Columns) == 0 {
+			fmt.Fprintf(&b, "    %s\n", mermaidName(t.Name))
+			continue
+		}
+		fmt.Fprintf(&b, "    %s {\n", mermaidName(t.Name))
+		for _, c := range t.Columns {
+			fmt.Fprintf(&b, "        %s %s", mermaidType(c.Type), mermaidName(c.Name))
+			if keys := columnKeys(c); len(keys) > 0 {
+				b.WriteString(" " + strings.Join(keys, ", "))
+			}
+			b.WriteString("\n")
+		}
+		b.WriteString("    }\n")
+	}
+	for _, r := range d.Relations {
+		parent, child := "||", "o{"
+		if r.Optional {
+			parent = "|o"
+		}
+		if r.OneToOne {
+			child = "o|"
+		}
+		fmt.Fprintf(&b, "    %s %s--%s %s : %q\n", mermaidName(r.Parent), parent, child, mermaidName(r.Child), r.Name)
+	}
+	return b.String()
+}
+
+func columnKeys(c Column) []string {
+	var keys []string
+	if c.PrimaryKey {
+		keys = append(keys, "PK")
+	}
+	if c.ForeignKey {
+		keys = append(keys, "FK")
+	}
+	if c.Unique && !c.PrimaryKey {
+		keys = append(keys, "UK")
+	}
+	return keys
+}
+
+// mermaidName replaces characters Mermaid does not accept in entity and
+// attribute names.
+func mermaidName(s string) string {
+	return strings.Map(mermaidRune, s)
+}
+
+// mermaidType is mermaidName that also keeps the brackets of types such as
+// varchar(255); the comma of numeric(10,2) becomes a hyphen.
+func mermaidType(s string) string {
+	if s == "" {
+		return "unknown"
+	}
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case '(', ')', '[', ']':
+			return r
+		case ',':
+			return '-'
+		}
+		return mermaidRune(r)
+	}, s)
+}
+
+func mermaidRune(r rune) rune {
+	if r == '_' || r == '-' || r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' {
+		return r
+	}
+	return '_'
+}
+
+func renderDOT(d Diagram) string {
+	var b strings.Builder
+	b.WriteString("digraph er {\n")
+	b.WriteString("    graph [rankdir=LR];\n")
+	b.WriteString("    node [shape=plaintext, fontname=\"Helvetica\"];\n")
+	b.WriteString("    edge [fontname=\"Helvetica\", fontsize=10, dir=both];\n")
+	for _, t := range d.Tables {
+		fmt.Fprintf(&b, "    %s [label=<<table border=\"0\" cellborder=\"1\" cellspacing=\"0\">", dotID(t.Name))
+		fmt.Fprintf(&b, "<tr><td bgcolor=\"lightgrey\"><b>%s</b></td></tr>", html.EscapeString(t.Name))
+		for _, c := range t.Columns {
+			label := c.Name + " : " + c.Type
+			if keys := columnKeys(c); len(keys) > 0 {
+				label += " " + strings.Join(keys, ", ")
+			}
+			fmt.Fprintf(&b, "<tr><td align=\"left\">%s</td></tr>", html.EscapeString(label))
+		}
+		b.WriteString("</table>>];\n")
+	}
+	for _, r := range d.Relations {
+		head, tail := "tee", "crowodot"
+		if r.Optional {
+			head = "teeodot"
+		}
+		if r.OneToOne {
+			tail = "teeodot"
+		}
+		fmt.Fprintf(&b, "    %s -> %s [label=%s, arrowhead=%s, arrowtail=%s];\n",
+			dotID(r.Child), dotID(r.Parent), dotID(r.Name), head, tail)
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+func dotID(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
@@ -0,0 +1,239 @@
+package export
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/AbdelilahOu/DBMcp/internal/render"
+	"github.com/AbdelilahOu/DBMcp/internal/resultset"
+)
+
+type Format string
+
+const (
+	CSV     Format = "csv"
+	JSONL   Format = "jsonl"
+	Parquet Format = "parquet"
+)
+
+// ParseFormat validates an export format; empty means CSV.
+func ParseFormat(s string) (Format, error) {
+	switch f := Format(strings.ToLower(s)); f {
+	case "":
+		return CSV, nil
+	case CSV, JSONL, Parquet:
+		return f, nil
+	default:
+		return "", fmt.Errorf("unsupported export format %q (expected csv, jsonl or parquet)", s)
+	}
+}
+
+// Result describes a finished export file.
+type Result struct {
+	Path   string
+	Rows   int64
+	Bytes  int64
+	SHA256 string
+}
+
+// Sandbox is the export root. Files are only ever created inside it: names
+// are resolved through an os.Root, so neither ".." nor symlinks can escape.
+type Sandbox struct {
+	dir  string
+	root *os.Root
+}
+
+func OpenSandbox(dir string) (*Sandbox, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, fmt.Errorf("invalid export directory: %v", err)
+	}
+	if err := os.MkdirAll(abs, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create export directory: %v", err)
+	}
+	root, err := os.OpenRoot(abs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open export directory: %v", err)
+	}
+	return &Sandbox{dir: abs, root: root}, nil
+}
+
+func (s *Sandbox) Dir() string {
+	return s.dir
+}
+
+func (s *Sandbox) Close() error {
+	return s.root.Close()
+}
+
+// Write streams every row of rows, returned by the driver for dbType, into
+// the file name, relative to the sandbox root. The file only appears once complete; a failed export leaves
+// nothing behind.
+func (s *Sandbox) Write(name string, overwrite bool, format Format, dbType string, rows *sql.Rows) (Result, error) {
+	name, err := s.cleanName(name, format)
+	if err != nil {
+		return Result{}, err
+	}
+
+	if dir := filepath.Dir(name); dir != "." {
+		if err := s.root.MkdirAll(dir, 0o755); err != nil {
+			return Result{}, fmt.Errorf("failed to create %s: %v", dir, err)
+		}
+	}
+	if !overwrite {
+		if _, err := s.root.Stat(name); err == nil {
+			return Result{}, fmt.Errorf("%s already exists; set overwrite to replace it", name)
+		} else if !errors.Is(err, fs.ErrNotExist) {
+			return Result{}, err
+		}
+	}
+
+	tmp := name + ".partial-" + randomSuffix()
+	f, err := s.root.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return Result{}, fmt.Errorf("failed to create export file: %v", err)
+	}
+
+	result, err := writeFile(f, format, dbType, rows)
+	if closeErr := f.Close(); err == nil && closeErr != nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = s.root.Rename(tmp, name)
+	}
+	if err != nil {
+		s.root.Remove(tmp)
+		return Result{}, err
+	}
+
+	result.Path = filepath.Join(s.dir, name)
+	return result, nil
+}
+
+// extensions maps the file extensions export formats are known by to the
+// format.
+var extensions = map[string]Format{
+	".csv":     CSV,
+	".jsonl":   JSONL,
+	".ndjson":  JSONL,
+	".parquet": Parquet,
+}
+
+// cleanName checks that name stays inside the sandbox and gives it the
+// format's extension unless it already has one. An extension of another
+// format is refused rather than left to mislabel the file.
+func (s *Sandbox) cleanName(name string, format Format) (string, error) {
+	name = filepath.Clean(filepath.FromSlash(name))
+	if name == "." || !filepath.IsLocal(name) {
+		return "", fmt.Errorf("file name %q must be a relative path inside the export directory", name)
+	}
+	ext := filepath.Ext(name)
+	switch f, known := extensions[strings.ToLower(ext)]; {
+	case !known:
+		name += "." + string(format)
+	case f != format:
+		return "", fmt.Errorf("file name %q has the extension of %s files, but the format is %s", name, f, format)
+	}
+	return name, nil
+}
+
+func writeFile(f *os.File, format Format, dbType string, rows *sql.Rows) (Result, error) {
+	hash := sha256.New()
+	out := &countingWriter{w: io.MultiWriter(f, hash)}
+
+	scanner, err := resultset.NewScanner(rows, dbType)
+	if err != nil {
+		return Result{}, err
+	}
+	columns := make([]string, len(scanner.Columns()))
+	for i, c := range scanner.Columns() {
+		columns[i] = c.Name
+	}
+
+	var w render.RowWriter
+	switch format {
+	case CSV:
+		cw, err := render.NewCSVWriter(out, columns)
+		if err != nil {
+			return Result{}, err
+		}
+		w = &normalizingWriter{next: cw, kinds: scanner.Kinds()}
+	case JSONL:
+		w = &normalizingWriter{next: render.NewNDJSONWriter(out, columns), kinds: scanner.Kinds()}
+	case Parquet:
+		if w, err = newParquetWriter(out, scanner.Columns(), scanner.Kinds()); err != nil {
+			return Result{}, err
+		}
+	}
+
+	var count int64
+	for rows.Next() {
+		row, err := scanner.ScanRaw()
+		if err != nil {
+			return Result{}, err
+		}
+		if err := w.WriteRow(row); err != nil {
+			return Result{}, fmt.Errorf("row %d: %v", count+1, err)
+		}
+		count++
+	}
+	if err := rows.Err(); err != nil {
+		return Result{}, fmt.Errorf("error iterating rows: %v", err)
+	}
+	if err := w.Flush(); err != nil {
+		return Result{}, fmt.Errorf("failed to write export file: %v", err)
+	}
+	if err := f.Sync(); err != nil {
+		return Result{}, fmt.Errorf("failed to write export file: %v", err)
+	}
+
+	return Result{
+		Rows:   count,
+		Bytes:  out.n,
+		SHA256: hex.EncodeToString(hash.Sum(nil)),
+	}, nil
+}
+
+// normalizingWriter feeds text formats the same values select_query
+// returns, except that binary data is never truncated.
+type normalizingWriter struct {
+	next  render.RowWriter
+	kinds []resultset.Kind
+}
+
+func (n *normalizingWriter) WriteRow(row []interface{}) error {
+	for i, v := range row {
+		row[i] = resultset.Normalize(v, n.kinds[i], 0)
+	}
+	return n.next.WriteRow(row)
+}
+
+func (n *normalizingWriter) Flush() error {
+	return n.next.Flush()
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+func randomSuffix() string {
+	b := make([]byte, 6)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,323 @@
+package export
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"math"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/parquet-go/parquet-go"
+
+	"github.com/AbdelilahOu/DBMcp/internal/resultset"
+	_ "modernc.org/sqlite"
+)
+
+func openTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "export.db"))
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	for _, stmt := range []string{
+		`CREATE TABLE items (id INTEGER, name TEXT, price REAL, data BLOB)`,
+		`INSERT INTO items VALUES (1, 'plain', 1.5, x'00ff'), (2, 'a, "quoted" name', NULL, NULL)`,
+	} {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatalf("%s: %v", stmt, err)
+		}
+	}
+	return db
+}
+
+func openTestSandbox(t *testing.T) *Sandbox {
+	t.Helper()
+	s, err := OpenSandbox(filepath.Join(t.TempDir(), "exports"))
+	if err != nil {
+		t.Fatalf("OpenSandbox: %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+func exportItems(t *testing.T, s *Sandbox, db *sql.DB, name string, overwrite bool, format Format) (Result, error) {
+	t.Helper()
+	rows, err := db.QueryContext(context.Background(), "SELECT id, name, price, data FROM items ORDER BY id")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	return s.Write(name, overwrite, format, "sqlite", rows)
+}
+
+func TestSandboxNames(t *testing.T) {
+	db := openTestDB(t)
+	s := openTestSandbox(t)
+
+	tests := []struct {
+		name    string
+		format  Format
+		want    string
+		wantErr bool
+	}{
+		{name: "items", format: CSV, want: "items.csv"},
+		{name: "reports/2024/items", format: JSONL, want: filepath.Join("reports", "2024", "items.jsonl")},
+		{name: "items.ndjson", format: JSONL, want: "items.ndjson"},
+		{name: "items.PARQUET", format: Parquet, want: "items.PARQUET"},
+		{name: "items.2024-01", format: CSV, want: "items.2024-01.csv"},
+		{name: "reports/../items-again", format: CSV, want: "items-again.csv"},
+		{name: "items.csv", format: Parquet, wantErr: true},
+		{name: "items.parquet", format: JSONL, wantErr: true},
+		{name: "../items", format: CSV, wantErr: true},
+		{name: "reports/../../items", format: CSV, wantErr: true},
+		{name: "/tmp/items", format: CSV, wantErr: true},
+		{name: "", format: CSV, wantErr: true},
+		{name: ".", format: CSV, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := exportItems(t, s, db, tt.name, false, tt.format)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("exported to %s, want an error", result.Path)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Write: %v", err)
+			}
+			if want := filepath.Join(s.Dir(), tt.want); result.Path != want {
+				t.Errorf("path = %s, want %s", result.Path, want)
+			}
+			if result.Rows != 2 {
+				t.Errorf("rows = %d, want 2", result.Rows)
+			}
+		})
+	}
+
+	entries, err := os.ReadDir(filepath.Dir(s.Dir()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range entries {
+		if e.Name() != "exports" && e.Name() != "export.db" {
+			t.Errorf("%s was written outside the export directory", e.Name())
+		}
+	}
+}
+
+func TestSandboxSymlinkEscape(t *testing.T) {
+	db := openTestDB(t)
+	s := openTestSandbox(t)
+	outside := t.TempDir()
+	if err := os.Symlink(outside, filepath.Join(s.Dir(), "link")); err != nil {
+		t.Skipf("symlinks unavailable: %v", err)
+	}
+
+	if _, err := exportItems(t, s, db, "link/items", false, CSV); err == nil {
+		t.Fatal("exported through a symlink out of the export directory")
+	}
+	if entries, _ := os.ReadDir(outside); len(entries) != 0 {
+		t.Errorf("files were written outside the export directory: %v", entries)
+	}
+}
+
+func TestSandboxOverwrite(t *testing.T) {
+	db := openTestDB(t)
+	s := openTestSandbox(t)
+
+	if _, err := exportItems(t, s, db, "items", false, CSV); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if _, err := exportItems(t, s, db, "items", false, CSV); err == nil {
+		t.Fatal("an existing file was replaced without overwrite")
+	}
+	if _, err := exportItems(t, s, db, "items", true, CSV); err != nil {
+		t.Fatalf("Write with overwrite: %v", err)
+	}
+
+	entries, err := os.ReadDir(s.Dir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("export directory holds %d entries, want only items.csv", len(entries))
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	result, err := exportItems(t, openTestSandbox(t), openTestDB(t), "items", false, CSV)
+	if err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	data, err := os.ReadFile(result.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if int64(len(data)) != result.Bytes {
+		t.Errorf("bytes = %d, file holds %d", result.Bytes, len(data))
+	}
+	want := "id,name,price,data\n1,plain,1.5,AP8=\n2,\"a, \"\"quoted\"\" name\",,\n"
+	if got := strings.ReplaceAll(string(data), "\r\n", "\n"); got != want {
+		t.Errorf("CSV =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestWriteJSONL(t *testing.T) {
+	result, err := exportItems(t, openTestSandbox(t), openTestDB(t), "items", false, JSONL)
+	if err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	data, err := os.ReadFile(result.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines, want 2:\n%s", len(lines), data)
+	}
+	want := []map[string]interface{}{
+		{"id": 1.0, "name": "plain", "price": 1.5, "data": "AP8="},
+		{"id": 2.0, "name": `a, "quoted" name`, "price": nil, "data": nil},
+	}
+	for i, line := range lines {
+		var got map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &got); err != nil {
+			t.Fatalf("line %d: %v", i+1, err)
+		}
+		for k, v := range want[i] {
+			if got[k] != v {
+				t.Errorf("line %d: %s = %v, want %v", i+1, k, got[k], v)
+			}
+		}
+	}
+}
+
+func TestWriteParquet(t *testing.T) {
+	result, err := exportItems(t, openTestSandbox(t), openTestDB(t), "items", false, Parquet)
+	if err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	data, err := os.ReadFile(result.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rows := readParquet(t, data)
+	if len(rows) != 2 {
+		t.Fatalf("got %d rows, want 2", len(rows))
+	}
+	// Leaf columns are ordered by name: data, id, name, price.
+	first := rows[0]
+	if !bytes.Equal(first[0].ByteArray(), []byte{0x00, 0xff}) || first[1].Int64() != 1 ||
+		string(first[2].ByteArray()) != "plain" || first[3].Double() != 1.5 {
+		t.Errorf("first row = %v", first)
+	}
+	if second := rows[1]; !second[0].IsNull() || !second[3].IsNull() || string(second[2].ByteArray()) != `a, "quoted" name` {
+		t.Errorf("second row = %v", second)
+	}
+}
+
+func TestParquetUnsignedBigint(t *testing.T) {
+	columns := []resultset.Column{{Name: "big", DatabaseType: "UNSIGNED BIGINT"}, {Name: "small", DatabaseType: "UNSIGNED INT"}}
+	kinds := []resultset.Kind{resultset.KindInteger, resultset.KindInteger}
+
+	var out bytes.Buffer
+	w, err := newParquetWriter(&out, columns, kinds)
+	if err != nil {
+		t.Fatalf("newParquetWriter: %v", err)
+	}
+	values := []uint64{math.MaxUint64, math.MaxInt64 + 1, 0, 42}
+	for _, v := range values {
+		if err := w.WriteRow([]interface{}{v, int64(7)}); err != nil {
+			t.Fatalf("WriteRow(%d): %v", v, err)
+		}
+	}
+	if err := w.WriteRow([]interface{}{[]byte("18446744073709551615"), nil}); err != nil {
+		t.Fatalf("WriteRow from text: %v", err)
+	}
+	if err := w.WriteRow([]interface{}{int64(-1), nil}); err == nil {
+		t.Error("a negative value was stored in an unsigned column")
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+
+	f, err := parquet.OpenFile(bytes.NewReader(out.Bytes()), int64(out.Len()))
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	bigColumn, _ := f.Schema().Lookup("big")
+	if logical := bigColumn.Node.Type().LogicalType(); logical == nil || !strings.Contains(strings.ToUpper(logical.String()), "DECIMAL") {
+		t.Errorf("big column logical type = %v, want DECIMAL", logical)
+	}
+	small, _ := f.Schema().Lookup("small")
+	if small.Node.Type().Kind() != parquet.Int64 {
+		t.Errorf("small column is %v, want INT64", small.Node.Type().Kind())
+	}
+
+	rows := readParquet(t, out.Bytes())
+	want := append(values, math.MaxUint64)
+	if len(rows) != len(want) {
+		t.Fatalf("got %d rows, want %d", len(rows), len(want))
+	}
+	for i, row := range rows {
+		got := new(big.Int).SetBytes(row[0].ByteArray())
+		if !got.IsUint64() || got.Uint64() != want[i] {
+			t.Errorf("row %d: big = %s, want %d", i+1, got, want[i])
+		}
+	}
+}
+
+func readParquet(t *testing.T, data []byte) []parquet.Row {
+	t.Helper()
+	r := parquet.NewReader(bytes.NewReader(data))
+	defer r.Close()
+	rows := make([]parquet.Row, r.NumRows())
+	n, err := r.ReadRows(rows)
+	if err != nil && n != len(rows) {
+		t.Fatalf("ReadRows: %v", err)
+	}
+	return rows[:n]
+}
+
+func TestParquetRepeatedNames(t *testing.T) {
+	// A suffixed name must not collapse into a later column's real name.
+	columns := []resultset.Column{{Name: "a"}, {Name: "a"}, {Name: "a_2"}}
+	kinds := []resultset.Kind{resultset.KindInteger, resultset.KindInteger, resultset.KindInteger}
+
+	var out bytes.Buffer
+	w, err := newParquetWriter(&out, columns, kinds)
+	if err != nil {
+		t.Fatalf("newParquetWriter: %v", err)
+	}
+	if err := w.WriteRow([]interface{}{int64(1), int64(2), int64(3)}); err != nil {
+		t.Fatalf("WriteRow: %v", err)
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+
+	f, err := parquet.OpenFile(bytes.NewReader(out.Bytes()), int64(out.Len()))
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	want := map[string]int64{"a": 1, "a_3": 2, "a_2": 3}
+	row := readParquet(t, out.Bytes())[0]
+	for name, v := range want {
+		leaf, ok := f.Schema().Lookup(name)
+		if !ok {
+			t.Errorf("schema lacks %s", name)
+			continue
+		}
+		if got := row[leaf.ColumnIndex].Int64(); got != v {
+			t.Errorf("%s = %d, want %d", name, got, v)
+		}
+	}
+}
@@ -0,0 +1,267 @@
+package export
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+
+	"github.com/AbdelilahOu/DBMcp/internal/render"
+	"github.com/AbdelilahOu/DBMcp/internal/resultset"
+)
+
+const (
+	// parquetRowGroupSize bounds how many rows are buffered in memory
+	// before a row group is written out.
+	parquetRowGroupSize = 64 * 1024
+	parquetBatchSize    = 1024
+)
+
+// parquetWriter writes one optional column per result column, typed from
+// the column's database type. Decimals, JSON and text are stored as UTF-8
+// strings so no precision is lost, and unsigned 64-bit integers, which
+// can exceed an INT64, as DECIMAL(20, 0).
+type parquetWriter struct {
+	w     *parquet.Writer
+	kinds []resultset.Kind
+	// unsigned marks BIGINT UNSIGNED columns.
+	unsigned []bool
+	names    []string
+	// index maps a result column to its leaf column in the schema, which
+	// orders fields by name.
+	index []int
+	batch []parquet.Row
+}
+
+func newParquetWriter(out io.Writer, columns []resultset.Column, kinds []resultset.Kind) (*parquetWriter, error) {
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("query returned no columns")
+	}
+
+	names := make([]string, len(columns))
+	unsigned := make([]bool, len(columns))
+	for i, c := range columns {
+		names[i] = c.Name
+		unsigned[i] = kinds[i] == resultset.KindInteger && isUnsignedBigint(c.DatabaseType)
+	}
+	names = render.UniqueNames(names)
+	group := parquet.Group{}
+	for i, name := range names {
+		node := parquetNode(kinds[i])
+		if unsigned[i] {
+			node = parquet.Decimal(0, 20, parquet.FixedLenByteArrayType(unsignedDecimalBytes))
+		}
+		group[name] = parquet.Optional(node)
+	}
+	schema := parquet.NewSchema("export", group)
+
+	index := make([]int, len(names))
+	for i, name := range names {
+		leaf, ok := schema.Lookup(name)
+		if !ok {
+			return nil, fmt.Errorf("column %s missing from parquet schema", name)
+		}
+		index[i] = leaf.ColumnIndex
+	}
+
+	return &parquetWriter{
+		w:        parquet.NewWriter(out, schema, parquet.MaxRowsPerRowGroup(parquetRowGroupSize), parquet.Compression(&parquet.Snappy)),
+		kinds:    kinds,
+		unsigned: unsigned,
+		names:    names,
+		index:    index,
+	}, nil
+}
+
+// unsignedDecimalBytes holds any uint64 as a big-endian two's complement
+// number, with a leading zero byte to keep it positive.
+const unsignedDecimalBytes = 9
+
+func isUnsignedBigint(databaseType string) bool {
+	t := strings.ToUpper(databaseType)
+	return strings.Contains(t, "UNSIGNED") && strings.Contains(t, "BIGINT")
+}
+
+func parquetNode(k resultset.Kind) parquet.Node {
+	switch k {
+	case resultset.KindInteger:
+		return parquet.Int(64)
+	case resultset.KindFloat:
+		return parquet.Leaf(parquet.DoubleType)
+	case resultset.KindBoolean:
+		return parquet.Leaf(parquet.BooleanType)
+	case resultset.KindDateTime:
+		return parquet.Timestamp(parquet.Microsecond)
+	case resultset.KindBinary:
+		return parquet.Leaf(parquet.ByteArrayType)
+	default:
+		return parquet.String()
+	}
+}
+
+func (p *parquetWriter) WriteRow(row []interface{}) error {
+	out := make(parquet.Row, len(row))
+	for i, v := range row {
+		if v == nil {
+			out[p.index[i]] = parquet.NullValue().Level(0, 0, p.index[i])
+			continue
+		}
+		var value parquet.Value
+		var err error
+		if p.unsigned[i] {
+			value, err = unsignedValue(v)
+		} else {
+			value, err = parquetValue(v, p.kinds[i])
+		}
+		if err != nil {
+			return fmt.Errorf("column %s: %v", p.names[i], err)
+		}
+		out[p.index[i]] = value.Level(0, 1, p.index[i])
+	}
+
+	p.batch = append(p.batch, out)
+	if len(p.batch) >= parquetBatchSize {
+		return p.writeBatch()
+	}
+	return nil
+}
+
+func (p *parquetWriter) writeBatch() error {
+	if _, err := p.w.WriteRows(p.batch); err != nil {
+		return err
+	}
+	p.batch = p.batch[:0]
+	return nil
+}
+
+func (p *parquetWriter) Flush() error {
+	if err := p.writeBatch(); err != nil {
+		return err
+	}
+	return p.w.Close()
+}
+
+func parquetValue(v interface{}, k resultset.Kind) (parquet.Value, error) {
+	switch k {
+	case resultset.KindInteger:
+		i, err := toInt64(v)
+		return parquet.Int64Value(i), err
+	case resultset.KindFloat:
+		f, err := toFloat64(v)
+		return parquet.DoubleValue(f), err
+	case resultset.KindBoolean:
+		b, err := toBool(v)
+		return parquet.BooleanValue(b), err
+	case resultset.KindDateTime:
+		t, err := toTime(v)
+		return parquet.Int64Value(t.UnixMicro()), err
+	case resultset.KindBinary:
+		if b, ok := v.([]byte); ok {
+			return parquet.ByteArrayValue(b), nil
+		}
+	}
+	text := render.CellText(resultset.Normalize(v, k, 0))
+	return parquet.ByteArrayValue([]byte(text)), nil
+}
+
+func unsignedValue(v interface{}) (parquet.Value, error) {
+	u, err := toUint64(v)
+	if err != nil {
+		return parquet.Value{}, err
+	}
+	b := make([]byte, unsignedDecimalBytes)
+	binary.BigEndian.PutUint64(b[1:], u)
+	return parquet.FixedLenByteArrayValue(b), nil
+}
+
+func toUint64(v interface{}) (uint64, error) {
+	switch v := v.(type) {
+	case uint64:
+		return v, nil
+	case int64:
+		if v >= 0 {
+			return uint64(v), nil
+		}
+	case []byte:
+		return strconv.ParseUint(string(v), 10, 64)
+	case string:
+		return strconv.ParseUint(v, 10, 64)
+	}
+	return 0, fmt.Errorf("cannot store %v (%T) as an unsigned integer", v, v)
+}
+
+func toInt64(v interface{}) (int64, error) {
+	switch v := v.(type) {
+	case int64:
+		return v, nil
+	case uint64:
+		if v <= math.MaxInt64 {
+			return int64(v), nil
+		}
+	case float64:
+		if v == math.Trunc(v) {
+			return int64(v), nil
+		}
+	case bool:
+		if v {
+			return 1, nil
+		}
+		return 0, nil
+	case []byte:
+		return strconv.ParseInt(string(v), 10, 64)
+	case string:
+		return strconv.ParseInt(v, 10, 64)
+	}
+	return 0, fmt.Errorf("cannot store %v (%T) as an integer", v, v)
+}
+
+func toFloat64(v interface{}) (float64, error) {
+	switch v := v.(type) {
+	case float64:
+		return v, nil
+	case float32:
+		return float64(v), nil
+	case int64:
+		return float64(v), nil
+	case []byte:
+		return strconv.ParseFloat(string(v), 64)
+	case string:
+		return strconv.ParseFloat(v, 64)
+	}
+	return 0, fmt.Errorf("cannot store %v (%T) as a double", v, v)
+}
+
+func toBool(v interface{}) (bool, error) {
+	switch v := v.(type) {
+	case bool:
+		return v, nil
+	case int64:
+		return v != 0, nil
+	case []byte:
+		return strconv.ParseBool(string(v))
+	case string:
+		return strconv.ParseBool(v)
+	}
+	return false, fmt.Errorf("cannot store %v (%T) as a boolean", v, v)
+}
+
+func toTime(v interface{}) (time.Time, error) {
+	switch v := v.(type) {
+	case time.Time:
+		return v, nil
+	case []byte:
+		if t, ok := resultset.ParseDateTime(string(v)); ok {
+			return t, nil
+		}
+	case string:
+		if t, ok := resultset.ParseDateTime(v); ok {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("cannot store %v (%T) as a timestamp", v, v)
+}
@@ -235,11 +235,15 @@ func Error(msg string, err error, fields ...map[string]interface{}) {
 	}
 }
 
-func LogToolCall(toolName string, params interface{}, result interface{}, err error) {
+func LogToolCall(toolName, principal string, params interface{}, result interface{}, err error) {
+	fields := make(map[string]interface{})
+	if principal != "" {
+		fields["principal"] = principal
+	}
 	if err != nil {
-		Error(fmt.Sprintf("Tool call failed: %s", toolName), err)
+		Error(fmt.Sprintf("Tool call failed: %s", toolName), err, fields)
 	} else {
-		Info(fmt.Sprintf("Tool call completed: %s", toolName))
+		Info(fmt.Sprintf("Tool call completed: %s", toolName), fields)
 	}
 }
 
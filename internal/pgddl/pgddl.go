@@ -0,0 +1,738 @@
+// Package pgddl rebuilds runnable CREATE statements for Postgres objects
+// from pg_catalog, since Postgres has no SHOW CREATE.
+package pgddl
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/AbdelilahOu/DBMcp/internal/dialect"
+)
+
+// Object kinds Build understands.
+const (
+	Table            = "table"
+	View             = "view"
+	MaterializedView = "materialized_view"
+	Index            = "index"
+	Sequence         = "sequence"
+	Function         = "function"
+	Procedure        = "procedure"
+	Trigger          = "trigger"
+)
+
+var pg = dialect.Postgres{}
+
+// Build returns the DDL of the named object of kind in schema. Triggers
+// are named per table, so table narrows a trigger lookup; it is ignored
+// for other kinds.
+func Build(ctx context.Context, db *sql.DB, kind, schema, name, table string) (string, error) {
+	var s script
+	var err error
+	switch kind {
+	case Table:
+		err = s.table(ctx, db, schema, name)
+	case View, MaterializedView:
+		err = s.view(ctx, db, kind, schema, name)
+	case Index:
+		err = s.index(ctx, db, schema, name)
+	case Sequence:
+		err = s.sequence(ctx, db, schema, name)
+	case Function, Procedure:
+		err = s.routine(ctx, db, kind, schema, name)
+	case Trigger:
+		err = s.trigger(ctx, db, schema, name, table)
+	default:
+		return "", fmt.Errorf("unsupported object type %q", kind)
+	}
+	if err != nil {
+		return "", err
+	}
+	return s.String(), nil
+}
+
+// script collects statements; comments are kept apart so they come last.
+type script struct {
+	statements []string
+	comments   []string
+}
+
+func (s *script) add(statement string) {
+	s.statements = append(s.statements, strings.TrimRight(strings.TrimSpace(statement), ";"))
+}
+
+// note adds an SQL comment line in place of a statement.
+func (s *script) note(text string) {
+	s.statements = append(s.statements, "-- "+text)
+}
+
+// comment adds COMMENT ON target IS literal when literal, already quoted,
+// is not empty.
+func (s *script) comment(target, literal string) {
+	if literal != "" {
+		s.comments = append(s.comments, "COMMENT ON "+target+" IS "+literal)
+	}
+}
+
+// addTrigger adds a trigger of the table qualified. Trigger names are only
+// unique per table, so the comment names both.
+func (s *script) addTrigger(name, qualified, def, comment string) {
+	s.add(def)
+	s.comment("TRIGGER "+pg.QuoteIdentifier(name)+" ON "+qualified, comment)
+}
+
+// addRoutine adds one overload of a function or procedure, whose comment
+// names it by its argument types.
+func (s *script) addRoutine(kind, qualified, arguments, def, comment string) {
+	s.add(def)
+	s.comment(strings.ToUpper(kind)+" "+qualified+"("+arguments+")", comment)
+}
+
+func (s *script) String() string {
+	var b strings.Builder
+	for _, statement := range append(s.statements, s.comments...) {
+		b.WriteString(statement)
+		if !strings.HasPrefix(statement, "--") {
+			b.WriteString(";")
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+var relkindNames = map[string]string{
+	"r": "table", "p": "partitioned table", "v": "view", "m": "materialized view",
+	"i": "index", "I": "partitioned index", "S": "sequence", "f": "foreign table", "c": "composite type",
+}
+
+// relation looks up a pg_class entry and checks its kind.
+func relation(ctx context.Context, db *sql.DB, schema, name string, kinds ...string) (oid int64, relkind string, err error) {
+	err = db.QueryRowContext(ctx, `
+		SELECT c.oid, c.relkind
+		FROM pg_class c
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		WHERE n.nspname = $1 AND c.relname = $2`, schema, name).Scan(&oid, &relkind)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, "", fmt.Errorf("%s.%s not found", schema, name)
+	}
+	if err != nil {
+		return 0, "", err
+	}
+	for _, k := range kinds {
+		if relkind == k {
+			return oid, relkind, nil
+		}
+	}
+	return 0, "", fmt.Errorf("%s.%s is a %s", schema, name, relkindNames[relkind])
+}
+
+func (s *script) table(ctx context.Context, db *sql.DB, schema, name string) error {
+	oid, relkind, err := relation(ctx, db, schema, name, "r", "p")
+	if err != nil {
+		return err
+	}
+	qualified := pg.QualifiedName(schema, name)
+
+	t := tableInfo{qualified: qualified, partitioned: relkind == "p"}
+	var persistence, comment string
+	err = db.QueryRowContext(ctx, `
+		SELECT
+			c.relpersistence,
+			c.relispartition,
+			COALESCE(pg_get_partkeydef(c.oid), ''),
+			COALESCE(pg_get_expr(c.relpartbound, c.oid), ''),
+			COALESCE(array_to_string(c.reloptions, ', '), ''),
+			COALESCE(quote_literal(obj_description(c.oid, 'pg_class')), '')
+		FROM pg_class c
+		WHERE c.oid = $1`, oid).Scan(&persistence, &t.isPartition, &t.partitionKey, &t.partitionBound, &t.options, &comment)
+	if err != nil {
+		return fmt.Errorf("read table: %v", err)
+	}
+	t.unlogged = persistence == "u"
+
+	t.parents, err = stringColumn(ctx, db, `
+		SELECT quote_ident(n.nspname) || '.' || quote_ident(p.relname)
+		FROM pg_inherits i
+		JOIN pg_class p ON p.oid = i.inhparent
+		JOIN pg_namespace n ON n.oid = p.relnamespace
+		WHERE i.inhrelid = $1
+		ORDER BY i.inhseqno`, oid)
+	if err != nil {
+		return fmt.Errorf("read parents: %v", err)
+	}
+
+	// Sequences behind serial columns come first, and are tied to their
+	// column once the table exists.
+	rows, err := db.QueryContext(ctx, `
+		SELECT s.relname, a.attname
+		FROM pg_depend d
+		JOIN pg_class s ON s.oid = d.objid AND s.relkind = 'S'
+		JOIN pg_attribute a ON a.attrelid = d.refobjid AND a.attnum = d.refobjsubid
+		WHERE d.classid = 'pg_class'::regclass AND d.refobjid = $1 AND d.deptype = 'a'
+		ORDER BY s.relname`, oid)
+	if err != nil {
+		return fmt.Errorf("read sequences: %v", err)
+	}
+	var ownedBy []string
+	var sequences []string
+	for rows.Next() {
+		var seq, column string
+		if err := rows.Scan(&seq, &column); err != nil {
+			rows.Close()
+			return err
+		}
+		sequences = append(sequences, seq)
+		ownedBy = append(ownedBy, "ALTER SEQUENCE "+pg.QualifiedName(schema, seq)+" OWNED BY "+qualified+"."+pg.QuoteIdentifier(column))
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	for _, seq := range sequences {
+		if err := s.sequenceStatement(ctx, db, schema, seq, false); err != nil {
+			return err
+		}
+	}
+
+	columns, columnComments, err := tableColumns(ctx, db, oid, qualified)
+	if err != nil {
+		return err
+	}
+	constraints, foreignKeys, err := s.tableConstraints(ctx, db, oid, qualified)
+	if err != nil {
+		return err
+	}
+	t.elements = append(columns, constraints...)
+	s.add(t.statement())
+
+	for _, fk := range foreignKeys {
+		s.add(fk)
+	}
+	for _, stmt := range ownedBy {
+		s.add(stmt)
+	}
+	if err := s.relationIndexes(ctx, db, oid); err != nil {
+		return err
+	}
+	if err := s.relationTriggers(ctx, db, oid, qualified); err != nil {
+		return err
+	}
+
+	s.comment("TABLE "+qualified, comment)
+	s.comments = append(s.comments, columnComments...)
+	return nil
+}
+
+// tableInfo is what CREATE TABLE is built from.
+type tableInfo struct {
+	qualified      string
+	unlogged       bool
+	partitioned    bool
+	isPartition    bool
+	parents        []string
+	partitionKey   string
+	partitionBound string
+	options        string
+	// elements are the column definitions and inline constraints.
+	elements []string
+}
+
+// statement returns CREATE TABLE. A partition is created as PARTITION OF
+// its parent, with only the columns and constraints of its own.
+func (t tableInfo) statement() string {
+	var b strings.Builder
+	b.WriteString("CREATE ")
+	if t.unlogged {
+		b.WriteString("UNLOGGED ")
+	}
+	b.WriteString("TABLE " + t.qualified)
+	switch {
+	case t.isPartition && len(t.parents) > 0:
+		b.WriteString(" PARTITION OF " + t.parents[0])
+		if len(t.elements) > 0 {
+			b.WriteString(" (\n    " + strings.Join(t.elements, ",\n    ") + "\n)")
+		}
+		b.WriteString("\n" + t.partitionBound)
+	default:
+		b.WriteString(" (\n    " + strings.Join(t.elements, ",\n    ") + "\n)")
+		if len(t.parents) > 0 {
+			b.WriteString("\nINHERITS (" + strings.Join(t.parents, ", ") + ")")
+		}
+	}
+	if t.partitioned {
+		b.WriteString("\nPARTITION BY " + t.partitionKey)
+	}
+	if t.options != "" {
+		b.WriteString("\nWITH (" + t.options + ")")
+	}
+	return b.String()
+}
+
+// tableColumns returns the column definitions of a table, leaving out
+// the columns it inherits, and the COMMENT statements for them.
+func tableColumns(ctx context.Context, db *sql.DB, oid int64, qualified string) ([]string, []string, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT
+			a.attname,
+			format_type(a.atttypid, a.atttypmod),
+			a.attnotnull,
+			COALESCE(pg_get_expr(d.adbin, d.adrelid), ''),
+			a.attidentity::text,
+			a.attgenerated::text,
+			COALESCE(CASE WHEN a.attcollation <> t.typcollation
+				THEN quote_ident(cn.nspname) || '.' || quote_ident(co.collname) END, ''),
+			a.attislocal,
+			COALESCE((
+				SELECT format('START WITH %s INCREMENT BY %s MINVALUE %s MAXVALUE %s CACHE %s%s',
+					s.seqstart, s.seqincrement, s.seqmin, s.seqmax, s.seqcache,
+					CASE WHEN s.seqcycle THEN ' CYCLE' ELSE '' END)
+				FROM pg_depend dep
+				JOIN pg_sequence s ON s.seqrelid = dep.objid
+				WHERE dep.classid = 'pg_class'::regclass AND dep.refobjid = a.attrelid
+					AND dep.refobjsubid = a.attnum AND dep.deptype = 'i'
+			), ''),
+			COALESCE(quote_literal(col_description(a.attrelid, a.attnum)), '')
+		FROM pg_attribute a
+		JOIN pg_type t ON t.oid = a.atttypid
+		LEFT JOIN pg_attrdef d ON d.adrelid = a.attrelid AND d.adnum = a.attnum
+		LEFT JOIN pg_collation co ON co.oid = a.attcollation
+		LEFT JOIN pg_namespace cn ON cn.oid = co.collnamespace
+		WHERE a.attrelid = $1 AND a.attnum > 0 AND NOT a.attisdropped
+		ORDER BY a.attnum`, oid)
+	if err != nil {
+		return nil, nil, fmt.Errorf("read columns: %v", err)
+	}
+	defer rows.Close()
+
+	var columns, comments []string
+	for rows.Next() {
+		var c columnInfo
+		var comment string
+		var isLocal bool
+		if err := rows.Scan(&c.name, &c.typ, &c.notNull, &c.def, &c.identity, &c.generated, &c.collation, &isLocal, &c.identityOptions, &comment); err != nil {
+			return nil, nil, err
+		}
+		if comment != "" {
+			comments = append(comments, "COMMENT ON COLUMN "+qualified+"."+pg.QuoteIdentifier(c.name)+" IS "+comment)
+		}
+		if isLocal {
+			columns = append(columns, c.definition())
+		}
+	}
+	return columns, comments, rows.Err()
+}
+
+// columnInfo is a pg_attribute row; identity and generated hold attidentity
+// and attgenerated.
+type columnInfo struct {
+	name, typ       string
+	notNull         bool
+	def             string
+	identity        string
+	generated       string
+	collation       string
+	identityOptions string
+}
+
+func (c columnInfo) definition() string {
+	column := pg.QuoteIdentifier(c.name) + " " + c.typ
+	if c.collation != "" {
+		column += " COLLATE " + c.collation
+	}
+	switch {
+	case c.generated == "s":
+		column += " GENERATED ALWAYS AS (" + c.def + ") STORED"
+	case c.identity == "a" || c.identity == "d":
+		if c.identity == "a" {
+			column += " GENERATED ALWAYS AS IDENTITY"
+		} else {
+			column += " GENERATED BY DEFAULT AS IDENTITY"
+		}
+		if c.identityOptions != "" {
+			column += " (" + c.identityOptions + ")"
+		}
+	case c.def != "":
+		column += " DEFAULT " + c.def
+	}
+	if c.notNull {
+		column += " NOT NULL"
+	}
+	return column
+}
+
+// tableConstraints returns the primary key, unique, check and exclusion
+// constraints declared on the table itself, and its foreign keys as
+// ALTER TABLE statements so they can run once the referenced tables exist.
+func (s *script) tableConstraints(ctx context.Context, db *sql.DB, oid int64, qualified string) ([]string, []string, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT
+			c.conname,
+			c.contype::text,
+			pg_get_constraintdef(c.oid, true),
+			COALESCE(quote_literal(obj_description(c.oid, 'pg_constraint')), '')
+		FROM pg_constraint c
+		WHERE c.conrelid = $1 AND c.conislocal AND c.contype IN ('p', 'u', 'c', 'x', 'f')
+		ORDER BY CASE c.contype WHEN 'p' THEN 0 WHEN 'u' THEN 1 WHEN 'c' THEN 2 WHEN 'x' THEN 3 ELSE 4 END, c.conname`, oid)
+	if err != nil {
+		return nil, nil, fmt.Errorf("read constraints: %v", err)
+	}
+	defer rows.Close()
+
+	var inline, foreignKeys []string
+	for rows.Next() {
+		var name, kind, def, comment string
+		if err := rows.Scan(&name, &kind, &def, &comment); err != nil {
+			return nil, nil, err
+		}
+		if kind == "f" {
+			foreignKeys = append(foreignKeys, addConstraint(qualified, name, def))
+		} else {
+			inline = append(inline, constraintClause(name, def))
+		}
+		s.comment("CONSTRAINT "+pg.QuoteIdentifier(name)+" ON "+qualified, comment)
+	}
+	return inline, foreignKeys, rows.Err()
+}
+
+// constraintClause is a named constraint as it appears in CREATE TABLE.
+func constraintClause(name, def string) string {
+	return "CONSTRAINT " + pg.QuoteIdentifier(name) + " " + def
+}
+
+// addConstraint adds a constraint to an existing table, which is how
+// foreign keys are created once the tables they reference exist.
+func addConstraint(table, name, def string) string {
+	return "ALTER TABLE " + table + " ADD " + constraintClause(name, def)
+}
+
+// relationIndexes adds the indexes of a table or materialized view that
+// do not back a constraint.
+func (s *script) relationIndexes(ctx context.Context, db *sql.DB, oid int64) error {
+	rows, err := db.QueryContext(ctx, `
+		SELECT
+			pg_get_indexdef(i.indexrelid),
+			quote_ident(n.nspname) || '.' || quote_ident(ic.relname),
+			COALESCE(quote_literal(obj_description(i.indexrelid, 'pg_class')), '')
+		FROM pg_index i
+		JOIN pg_class ic ON ic.oid = i.indexrelid
+		JOIN pg_namespace n ON n.oid = ic.relnamespace
+		WHERE i.indrelid = $1
+			AND NOT EXISTS (
+				SELECT 1 FROM pg_constraint c
+				WHERE c.conindid = i.indexrelid AND c.conrelid = i.indrelid AND c.contype IN ('p', 'u', 'x')
+			)
+		ORDER BY ic.relname`, oid)
+	if err != nil {
+		return fmt.Errorf("read indexes: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var def, qualified, comment string
+		if err := rows.Scan(&def, &qualified, &comment); err != nil {
+			return err
+		}
+		s.add(def)
+		s.comment("INDEX "+qualified, comment)
+	}
+	return rows.Err()
+}
+
+func (s *script) relationTriggers(ctx context.Context, db *sql.DB, oid int64, qualified string) error {
+	rows, err := db.QueryContext(ctx, `
+		SELECT
+			pg_get_triggerdef(t.oid, true),
+			t.tgname,
+			COALESCE(quote_literal(obj_description(t.oid, 'pg_trigger')), '')
+		FROM pg_trigger t
+		WHERE t.tgrelid = $1 AND NOT t.tgisinternal
+		ORDER BY t.tgname`, oid)
+	if err != nil {
+		return fmt.Errorf("read triggers: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var def, name, comment string
+		if err := rows.Scan(&def, &name, &comment); err != nil {
+			return err
+		}
+		s.addTrigger(name, qualified, def, comment)
+	}
+	return rows.Err()
+}
+
+func (s *script) view(ctx context.Context, db *sql.DB, kind, schema, name string) error {
+	want := "v"
+	if kind == MaterializedView {
+		want = "m"
+	}
+	oid, _, err := relation(ctx, db, schema, name, want)
+	if err != nil {
+		return err
+	}
+	qualified := pg.QualifiedName(schema, name)
+
+	var definition, options, comment string
+	err = db.QueryRowContext(ctx, `
+		SELECT
+			pg_get_viewdef(c.oid, true),
+			COALESCE(array_to_string(c.reloptions, E'\n'), ''),
+			COALESCE(quote_literal(obj_description(c.oid, 'pg_class')), '')
+		FROM pg_class c
+		WHERE c.oid = $1`, oid).Scan(&definition, &options, &comment)
+	if err != nil {
+		return fmt.Errorf("read view: %v", err)
+	}
+	_, columnComments, err := tableColumns(ctx, db, oid, qualified)
+	if err != nil {
+		return err
+	}
+
+	s.add(viewStatement(kind, qualified, definition, options))
+	if kind == MaterializedView {
+		if err := s.relationIndexes(ctx, db, oid); err != nil {
+			return err
+		}
+		s.comment("MATERIALIZED VIEW "+qualified, comment)
+	} else {
+		if err := s.relationTriggers(ctx, db, oid, qualified); err != nil {
+			return err
+		}
+		s.comment("VIEW "+qualified, comment)
+	}
+	s.comments = append(s.comments, columnComments...)
+	return nil
+}
+
+// viewStatement returns CREATE VIEW or CREATE MATERIALIZED VIEW from the
+// view's query and its reloptions, one per line.
+func viewStatement(kind, qualified, definition, options string) string {
+	definition = strings.TrimRight(strings.TrimSpace(definition), ";")
+
+	// check_option is stored among the options but written as a clause.
+	var with []string
+	checkOption := ""
+	for _, opt := range strings.Split(options, "\n") {
+		if value, ok := strings.CutPrefix(opt, "check_option="); ok {
+			checkOption = "\nWITH " + strings.ToUpper(value) + " CHECK OPTION"
+		} else if opt != "" {
+			with = append(with, opt)
+		}
+	}
+	withClause := ""
+	if len(with) > 0 {
+		withClause = " WITH (" + strings.Join(with, ", ") + ")"
+	}
+
+	if kind == MaterializedView {
+		return "CREATE MATERIALIZED VIEW " + qualified + withClause + " AS\n" + definition + "\nWITH DATA"
+	}
+	return "CREATE OR REPLACE VIEW " + qualified + withClause + " AS\n" + definition + checkOption
+}
+
+// index returns CREATE INDEX, or the ALTER TABLE that adds the constraint
+// the index backs.
+func (s *script) index(ctx context.Context, db *sql.DB, schema, name string) error {
+	oid, _, err := relation(ctx, db, schema, name, "i", "I")
+	if err != nil {
+		return err
+	}
+
+	var def, table, constraint, constraintDef, comment string
+	err = db.QueryRowContext(ctx, `
+		SELECT
+			pg_get_indexdef(i.indexrelid),
+			quote_ident(n.nspname) || '.' || quote_ident(t.relname),
+			COALESCE(c.conname, ''),
+			COALESCE(pg_get_constraintdef(c.oid, true), ''),
+			COALESCE(quote_literal(obj_description(i.indexrelid, 'pg_class')), '')
+		FROM pg_index i
+		JOIN pg_class t ON t.oid = i.indrelid
+		JOIN pg_namespace n ON n.oid = t.relnamespace
+		LEFT JOIN pg_constraint c
+			ON c.conindid = i.indexrelid AND c.conrelid = i.indrelid AND c.contype IN ('p', 'u', 'x')
+		WHERE i.indexrelid = $1`, oid).Scan(&def, &table, &constraint, &constraintDef, &comment)
+	if err != nil {
+		return fmt.Errorf("read index: %v", err)
+	}
+
+	if constraint != "" {
+		s.add(addConstraint(table, constraint, constraintDef))
+	} else {
+		s.add(def)
+	}
+	s.comment("INDEX "+pg.QualifiedName(schema, name), comment)
+	return nil
+}
+
+func (s *script) sequence(ctx context.Context, db *sql.DB, schema, name string) error {
+	if _, _, err := relation(ctx, db, schema, name, "S"); err != nil {
+		return err
+	}
+	return s.sequenceStatement(ctx, db, schema, name, true)
+}
+
+// sequenceStatement adds CREATE SEQUENCE, and with ownedBy the statement
+// tying it to the column that owns it.
+func (s *script) sequenceStatement(ctx context.Context, db *sql.DB, schema, name string, ownedBy bool) error {
+	seq := sequenceInfo{qualified: pg.QualifiedName(schema, name)}
+	var owner, dependency, persistence, comment string
+	err := db.QueryRowContext(ctx, `
+		SELECT
+			format_type(s.seqtypid, NULL),
+			s.seqstart, s.seqincrement, s.seqmin, s.seqmax, s.seqcache, s.seqcycle,
+			COALESCE(o.owner, ''),
+			COALESCE(o.deptype, ''),
+			c.relpersistence,
+			COALESCE(quote_literal(obj_description(c.oid, 'pg_class')), '')
+		FROM pg_class c
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		JOIN pg_sequence s ON s.seqrelid = c.oid
+		LEFT JOIN LATERAL (
+			SELECT
+				quote_ident(tn.nspname) || '.' || quote_ident(t.relname) || '.' || quote_ident(a.attname) as owner,
+				d.deptype::text as deptype
+			FROM pg_depend d
+			JOIN pg_class t ON t.oid = d.refobjid
+			JOIN pg_namespace tn ON tn.oid = t.relnamespace
+			JOIN pg_attribute a ON a.attrelid = d.refobjid AND a.attnum = d.refobjsubid
+			WHERE d.classid = 'pg_class'::regclass AND d.objid = c.oid AND d.deptype IN ('a', 'i')
+			LIMIT 1
+		) o ON true
+		WHERE n.nspname = $1 AND c.relname = $2`, schema, name).
+		Scan(&seq.typ, &seq.start, &seq.increment, &seq.minValue, &seq.maxValue, &seq.cache, &seq.cycle, &owner, &dependency, &persistence, &comment)
+	if err != nil {
+		return fmt.Errorf("read sequence: %v", err)
+	}
+	seq.unlogged = persistence == "u"
+
+	if dependency == "i" {
+		s.note(seq.qualified + " is the identity sequence of " + owner + " and is created with its column")
+		return nil
+	}
+
+	s.add(seq.statement())
+	if ownedBy && owner != "" {
+		s.add("ALTER SEQUENCE " + seq.qualified + " OWNED BY " + owner)
+	}
+	s.comment("SEQUENCE "+seq.qualified, comment)
+	return nil
+}
+
+// sequenceInfo is what CREATE SEQUENCE is built from.
+type sequenceInfo struct {
+	qualified, typ                              string
+	start, increment, minValue, maxValue, cache string
+	cycle, unlogged                             bool
+}
+
+func (seq sequenceInfo) statement() string {
+	create := "CREATE "
+	if seq.unlogged {
+		create += "UNLOGGED "
+	}
+	create += fmt.Sprintf("SEQUENCE %s AS %s\n    INCREMENT BY %s\n    MINVALUE %s\n    MAXVALUE %s\n    START WITH %s\n    CACHE %s",
+		seq.qualified, seq.typ, seq.increment, seq.minValue, seq.maxValue, seq.start, seq.cache)
+	if seq.cycle {
+		create += "\n    CYCLE"
+	} else {
+		create += "\n    NO CYCLE"
+	}
+	return create
+}
+
+// routine adds every overload of the named function or procedure.
+func (s *script) routine(ctx context.Context, db *sql.DB, kind, schema, name string) error {
+	prokind := "f"
+	if kind == Procedure {
+		prokind = "p"
+	}
+	rows, err := db.QueryContext(ctx, `
+		SELECT
+			pg_get_functiondef(p.oid),
+			pg_get_function_identity_arguments(p.oid),
+			COALESCE(quote_literal(obj_description(p.oid, 'pg_proc')), '')
+		FROM pg_proc p
+		JOIN pg_namespace n ON n.oid = p.pronamespace
+		WHERE n.nspname = $1 AND p.proname = $2 AND p.prokind = $3
+		ORDER BY 2`, schema, name, prokind)
+	if err != nil {
+		return fmt.Errorf("read %s: %v", kind, err)
+	}
+	defer rows.Close()
+
+	found := false
+	for rows.Next() {
+		var def, arguments, comment string
+		if err := rows.Scan(&def, &arguments, &comment); err != nil {
+			return err
+		}
+		found = true
+		s.addRoutine(kind, pg.QualifiedName(schema, name), arguments, def, comment)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	if !found {
+		return fmt.Errorf("%s %s.%s not found", kind, schema, name)
+	}
+	return nil
+}
+
+// trigger adds the named trigger of every table in schema that has one,
+// or of table only when it is given.
+func (s *script) trigger(ctx context.Context, db *sql.DB, schema, name, table string) error {
+	rows, err := db.QueryContext(ctx, `
+		SELECT
+			pg_get_triggerdef(t.oid, true),
+			quote_ident(n.nspname) || '.' || quote_ident(c.relname),
+			COALESCE(quote_literal(obj_description(t.oid, 'pg_trigger')), '')
+		FROM pg_trigger t
+		JOIN pg_class c ON c.oid = t.tgrelid
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		WHERE n.nspname = $1 AND t.tgname = $2 AND ($3::text = '' OR c.relname = $3::text) AND NOT t.tgisinternal
+		ORDER BY c.relname`, schema, name, table)
+	if err != nil {
+		return fmt.Errorf("read trigger: %v", err)
+	}
+	defer rows.Close()
+
+	found := false
+	for rows.Next() {
+		var def, qualified, comment string
+		if err := rows.Scan(&def, &qualified, &comment); err != nil {
+			return err
+		}
+		found = true
+		s.addTrigger(name, qualified, def, comment)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	if !found {
+		return fmt.Errorf("trigger %s not found in %s", name, schema)
+	}
+	return nil
+}
+
+func stringColumn(ctx context.Context, db *sql.DB, query string, args ...interface{}) ([]string, error) {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var values []string
+	for rows.Next() {
+		var v string
+		if err := rows.Scan(&v); err != nil {
+			return nil, err
+		}
+		values = append(values, v)
+	}
+	return values, rows.Err()
+}
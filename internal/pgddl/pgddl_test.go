@@ -0,0 +1,151 @@
+package pgddl
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestScriptPutsCommentsLast(t *testing.T) {
+	var s script
+	s.add("CREATE TABLE t (a int);")
+	s.comment("TABLE t", "'the table'")
+	s.comment("COLUMN t.b", "")
+	s.note("t_a_seq is the identity sequence of t.a and is created with its column")
+	s.add("  CREATE INDEX t_a ON t (a)\n")
+
+	want := "CREATE TABLE t (a int);\n" +
+		"-- t_a_seq is the identity sequence of t.a and is created with its column\n" +
+		"CREATE INDEX t_a ON t (a);\n" +
+		"COMMENT ON TABLE t IS 'the table';\n"
+	if got := s.String(); got != want {
+		t.Errorf("script =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestColumnDefinition(t *testing.T) {
+	tests := []struct {
+		column columnInfo
+		want   string
+	}{
+		{columnInfo{name: "id", typ: "integer", notNull: true}, `"id" integer NOT NULL`},
+		{columnInfo{name: "Note", typ: "text", def: "''::text"}, `"Note" text DEFAULT ''::text`},
+		{columnInfo{name: "name", typ: "text", collation: `pg_catalog."C"`}, `"name" text COLLATE pg_catalog."C"`},
+		{
+			columnInfo{name: "id", typ: "bigint", notNull: true, identity: "a", identityOptions: "START WITH 10 INCREMENT BY 1 MINVALUE 1 MAXVALUE 9223372036854775807 CACHE 1"},
+			`"id" bigint GENERATED ALWAYS AS IDENTITY (START WITH 10 INCREMENT BY 1 MINVALUE 1 MAXVALUE 9223372036854775807 CACHE 1) NOT NULL`,
+		},
+		{columnInfo{name: "id", typ: "bigint", notNull: true, identity: "d"}, `"id" bigint GENERATED BY DEFAULT AS IDENTITY NOT NULL`},
+		{columnInfo{name: "total", typ: "numeric", def: "(price * qty)", generated: "s"}, `"total" numeric GENERATED ALWAYS AS ((price * qty)) STORED`},
+	}
+	for _, tt := range tests {
+		if got := tt.column.definition(); got != tt.want {
+			t.Errorf("definition = %s, want %s", got, tt.want)
+		}
+	}
+}
+
+func TestConstraints(t *testing.T) {
+	if got, want := constraintClause("orders_pkey", "PRIMARY KEY (id)"), `CONSTRAINT "orders_pkey" PRIMARY KEY (id)`; got != want {
+		t.Errorf("constraintClause = %s, want %s", got, want)
+	}
+	got := addConstraint("public.orders", "Orders_customer_fkey", "FOREIGN KEY (customer_id) REFERENCES customers(id)")
+	if want := `ALTER TABLE public.orders ADD CONSTRAINT "Orders_customer_fkey" FOREIGN KEY (customer_id) REFERENCES customers(id)`; got != want {
+		t.Errorf("addConstraint = %s, want %s", got, want)
+	}
+}
+
+func TestTableStatement(t *testing.T) {
+	elements := []string{"id integer NOT NULL", "CONSTRAINT t_pkey PRIMARY KEY (id)"}
+	tests := []struct {
+		name  string
+		table tableInfo
+		want  string
+	}{
+		{
+			name:  "plain",
+			table: tableInfo{qualified: "public.t", elements: elements},
+			want:  "CREATE TABLE public.t (\n    id integer NOT NULL,\n    CONSTRAINT t_pkey PRIMARY KEY (id)\n)",
+		},
+		{
+			name:  "unlogged with options",
+			table: tableInfo{qualified: "public.t", unlogged: true, options: "fillfactor=70", elements: elements[:1]},
+			want:  "CREATE UNLOGGED TABLE public.t (\n    id integer NOT NULL\n)\nWITH (fillfactor=70)",
+		},
+		{
+			name:  "partitioned",
+			table: tableInfo{qualified: "public.events", partitioned: true, partitionKey: "RANGE (created_at)", elements: elements[:1]},
+			want:  "CREATE TABLE public.events (\n    id integer NOT NULL\n)\nPARTITION BY RANGE (created_at)",
+		},
+		{
+			name:  "partition without columns of its own",
+			table: tableInfo{qualified: "public.events_2024", isPartition: true, parents: []string{"public.events"}, partitionBound: "FOR VALUES FROM ('2024-01-01') TO ('2025-01-01')"},
+			want:  "CREATE TABLE public.events_2024 PARTITION OF public.events\nFOR VALUES FROM ('2024-01-01') TO ('2025-01-01')",
+		},
+		{
+			name: "partition that is partitioned again",
+			table: tableInfo{
+				qualified: "public.events_2024", isPartition: true, partitioned: true, parents: []string{"public.events"},
+				partitionBound: "FOR VALUES FROM ('2024-01-01') TO ('2025-01-01')", partitionKey: "LIST (kind)",
+				elements: []string{"CONSTRAINT events_2024_check CHECK (id > 0)"},
+			},
+			want: "CREATE TABLE public.events_2024 PARTITION OF public.events (\n    CONSTRAINT events_2024_check CHECK (id > 0)\n)\n" +
+				"FOR VALUES FROM ('2024-01-01') TO ('2025-01-01')\nPARTITION BY LIST (kind)",
+		},
+		{
+			name:  "inherits",
+			table: tableInfo{qualified: "public.child", parents: []string{"public.a", "public.b"}, elements: elements[:1]},
+			want:  "CREATE TABLE public.child (\n    id integer NOT NULL\n)\nINHERITS (public.a, public.b)",
+		},
+	}
+	for _, tt := range tests {
+		if got := tt.table.statement(); got != tt.want {
+			t.Errorf("%s: statement =\n%s\nwant\n%s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestViewStatement(t *testing.T) {
+	got := viewStatement(View, "public.open_orders", " SELECT id\n   FROM orders;", "security_barrier=true\ncheck_option=local")
+	want := "CREATE OR REPLACE VIEW public.open_orders WITH (security_barrier=true) AS\nSELECT id\n   FROM orders\nWITH LOCAL CHECK OPTION"
+	if got != want {
+		t.Errorf("view =\n%s\nwant\n%s", got, want)
+	}
+
+	got = viewStatement(MaterializedView, "public.totals", "SELECT 1;", "")
+	if want := "CREATE MATERIALIZED VIEW public.totals AS\nSELECT 1\nWITH DATA"; got != want {
+		t.Errorf("materialized view =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestSequenceStatement(t *testing.T) {
+	seq := sequenceInfo{qualified: "public.ids", typ: "bigint", start: "1", increment: "1", minValue: "1", maxValue: "100", cache: "1"}
+	want := "CREATE SEQUENCE public.ids AS bigint\n    INCREMENT BY 1\n    MINVALUE 1\n    MAXVALUE 100\n    START WITH 1\n    CACHE 1\n    NO CYCLE"
+	if got := seq.statement(); got != want {
+		t.Errorf("sequence =\n%s\nwant\n%s", got, want)
+	}
+
+	seq.cycle, seq.unlogged = true, true
+	if got := seq.statement(); !strings.HasPrefix(got, "CREATE UNLOGGED SEQUENCE") || !strings.HasSuffix(got, "\n    CYCLE") {
+		t.Errorf("unlogged cycling sequence =\n%s", got)
+	}
+}
+
+func TestTriggersAndOverloads(t *testing.T) {
+	var s script
+	// The same trigger name on two tables.
+	s.addTrigger("audit", "public.a", "CREATE TRIGGER audit AFTER INSERT ON public.a FOR EACH ROW EXECUTE FUNCTION log()", "'on a'")
+	s.addTrigger("audit", "public.b", "CREATE TRIGGER audit AFTER INSERT ON public.b FOR EACH ROW EXECUTE FUNCTION log()", "")
+	s.addRoutine(Function, "public.area", "r numeric", "CREATE OR REPLACE FUNCTION public.area(r numeric) RETURNS numeric LANGUAGE sql AS $$ SELECT r * r $$\n", "'circle'")
+	s.addRoutine(Function, "public.area", "w numeric, h numeric", "CREATE OR REPLACE FUNCTION public.area(w numeric, h numeric) RETURNS numeric LANGUAGE sql AS $$ SELECT w * h $$\n", "'rectangle'")
+
+	want := "CREATE TRIGGER audit AFTER INSERT ON public.a FOR EACH ROW EXECUTE FUNCTION log();\n" +
+		"CREATE TRIGGER audit AFTER INSERT ON public.b FOR EACH ROW EXECUTE FUNCTION log();\n" +
+		"CREATE OR REPLACE FUNCTION public.area(r numeric) RETURNS numeric LANGUAGE sql AS $$ SELECT r * r $$;\n" +
+		"CREATE OR REPLACE FUNCTION public.area(w numeric, h numeric) RETURNS numeric LANGUAGE sql AS $$ SELECT w * h $$;\n" +
+		"COMMENT ON TRIGGER \"audit\" ON public.a IS 'on a';\n" +
+		"COMMENT ON FUNCTION public.area(r numeric) IS 'circle';\n" +
+		"COMMENT ON FUNCTION public.area(w numeric, h numeric) IS 'rectangle';\n"
+	if got := s.String(); got != want {
+		t.Errorf("script =\n%s\nwant\n%s", got, want)
+	}
+}
@@ -0,0 +1,316 @@
+package plan
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// mysqlAccessTypes names the access_type of EXPLAIN FORMAT=JSON tables.
+var mysqlAccessTypes = map[string]string{
+	"ALL":             "Table Scan",
+	"index":           "Full Index Scan",
+	"range":           "Index Range Scan",
+	"ref":             "Index Lookup",
+	"ref_or_null":     "Index Lookup",
+	"eq_ref":          "Unique Index Lookup",
+	"const":           "Constant Lookup",
+	"system":          "Constant Lookup",
+	"fulltext":        "Fulltext Search",
+	"index_merge":     "Index Merge",
+	"unique_subquery": "Subquery Index Lookup",
+	"index_subquery":  "Subquery Index Lookup",
+}
+
+// mysqlSubqueries are the query block keys holding lists of subqueries.
+var mysqlSubqueries = []string{
+	"select_list_subqueries",
+	"attached_subqueries",
+	"optimized_away_subqueries",
+	"order_by_subqueries",
+	"group_by_subqueries",
+	"having_subqueries",
+}
+
+type object = map[string]interface{}
+
+// mysqlJSON builds nodes from EXPLAIN FORMAT=JSON. It reports costs per
+// table; ownCost marks those nodes so costs can be summed up the tree.
+type mysqlJSON struct {
+	builder
+	ownCost map[int]bool
+}
+
+func parseMySQLJSON(text string) ([]Node, error) {
+	var root object
+	if err := json.Unmarshal([]byte(text), &root); err != nil {
+		return nil, fmt.Errorf("parse MySQL plan: %v", err)
+	}
+	block, ok := root["query_block"].(object)
+	if !ok {
+		return nil, fmt.Errorf("parse MySQL plan: no query_block found")
+	}
+
+	m := &mysqlJSON{ownCost: map[int]bool{}}
+	m.addBlock(block, 0)
+	m.rollUpCosts()
+	return m.result()
+}
+
+func (m *mysqlJSON) addBlock(block object, parent int) {
+	n := Node{Operation: "Select"}
+	if id, ok := number(block["select_id"]); ok {
+		n.Operation = "Select #" + formatNumber(id)
+	}
+	if message, ok := block["message"].(string); ok {
+		n.Operation += " (" + message + ")"
+	}
+	if costs, ok := block["cost_info"].(object); ok {
+		if cost, ok := number(costs["query_cost"]); ok {
+			n.Cost = ptr(cost)
+		}
+	}
+
+	id := m.add(n, parent)
+	m.addOperations(block, id)
+	for _, key := range mysqlSubqueries {
+		subqueries, _ := block[key].([]interface{})
+		for _, s := range subqueries {
+			if sub, ok := s.(object); ok {
+				if inner, ok := sub["query_block"].(object); ok {
+					m.addBlock(inner, id)
+				}
+			}
+		}
+	}
+}
+
+// addOperations adds what a query block or operation wraps: sorting,
+// grouping and the like, down to the tables read.
+func (m *mysqlJSON) addOperations(obj object, parent int) {
+	wrappers := []struct{ key, operation string }{
+		{"ordering_operation", "Order"},
+		{"grouping_operation", "Group"},
+		{"duplicates_removal", "Distinct"},
+		{"windowing", "Window"},
+		{"buffer_result", "Buffer Result"},
+	}
+	for _, w := range wrappers {
+		inner, ok := obj[w.key].(object)
+		if !ok {
+			continue
+		}
+		operation := w.operation
+		if inner["using_filesort"] == true {
+			operation += " (filesort)"
+		}
+		if inner["using_temporary_table"] == true {
+			operation += " (temporary table)"
+		}
+		m.addOperations(inner, m.add(Node{Operation: operation}, parent))
+	}
+
+	if tables, ok := obj["nested_loop"].([]interface{}); ok {
+		id := m.add(Node{Operation: "Nested Loop"}, parent)
+		for _, t := range tables {
+			if entry, ok := t.(object); ok {
+				m.addOperations(entry, id)
+			}
+		}
+	}
+	if table, ok := obj["table"].(object); ok {
+		m.addTable(table, parent)
+	}
+	if union, ok := obj["union_result"].(object); ok {
+		id := m.add(Node{Operation: "Union", Relation: stringOf(union["table_name"])}, parent)
+		specs, _ := union["query_specifications"].([]interface{})
+		for _, s := range specs {
+			if spec, ok := s.(object); ok {
+				if block, ok := spec["query_block"].(object); ok {
+					m.addBlock(block, id)
+				}
+			}
+		}
+	}
+}
+
+func (m *mysqlJSON) addTable(table object, parent int) {
+	access := stringOf(table["access_type"])
+	n := Node{
+		Operation: mysqlAccessTypes[access],
+		Relation:  stringOf(table["table_name"]),
+		Index:     stringOf(table["key"]),
+		Filter:    stringOf(table["attached_condition"]),
+		fullScan:  access == "ALL",
+	}
+	if n.Operation == "" {
+		n.Operation = access
+	}
+
+	// ref lists what each used key part is compared with.
+	keyParts, _ := table["used_key_parts"].([]interface{})
+	refs, _ := table["ref"].([]interface{})
+	var conditions []string
+	for i := 0; i < len(keyParts) && i < len(refs); i++ {
+		conditions = append(conditions, stringOf(keyParts[i])+" = "+stringOf(refs[i]))
+	}
+	n.Condition = strings.Join(conditions, " AND ")
+
+	if examined, ok := number(table["rows_examined_per_scan"]); ok {
+		n.scanned = examined
+		n.EstimatedRows = ptr(examined)
+		if filtered, ok := number(table["filtered"]); ok {
+			n.EstimatedRows = ptr(examined * filtered / 100)
+		}
+	}
+	if costs, ok := table["cost_info"].(object); ok {
+		read, okRead := number(costs["read_cost"])
+		eval, okEval := number(costs["eval_cost"])
+		if okRead || okEval {
+			n.Cost = ptr(read + eval)
+		}
+	}
+
+	id := m.add(n, parent)
+	if n.Cost != nil {
+		m.ownCost[id] = true
+	}
+	if sub, ok := table["materialized_from_subquery"].(object); ok {
+		if block, ok := sub["query_block"].(object); ok {
+			m.addBlock(block, id)
+		}
+	}
+}
+
+// rollUpCosts makes costs include those of children, as other plans do:
+// wrappers without a cost of their own get their children's, and tables
+// add them to their own.
+func (m *mysqlJSON) rollUpCosts() {
+	for i := len(m.nodes) - 1; i >= 0; i-- {
+		n := &m.nodes[i]
+		var sum float64
+		found := false
+		for j := i + 1; j < len(m.nodes); j++ {
+			if c := m.nodes[j]; c.Parent == n.ID && c.Cost != nil {
+				sum += *c.Cost
+				found = true
+			}
+		}
+		switch {
+		case !found:
+		case n.Cost == nil:
+			n.Cost = ptr(sum)
+		case m.ownCost[n.ID]:
+			*n.Cost += sum
+		}
+	}
+}
+
+// number reads a JSON number, or a string holding one as MySQL writes
+// costs.
+func number(v interface{}) (float64, bool) {
+	switch v := v.(type) {
+	case float64:
+		return v, true
+	case string:
+		f, err := strconv.ParseFloat(v, 64)
+		return f, err == nil
+	}
+	return 0, false
+}
+
+func stringOf(v interface{}) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	return ""
+}
+
+var (
+	treeCostRe   = regexp.MustCompile(`\(cost=(?:[0-9.e+-]+\.\.)?([0-9.e+-]+) rows=([0-9.e+-]+)\)`)
+	treeActualRe = regexp.MustCompile(`\(actual time=[0-9.e+-]+\.\.([0-9.e+-]+) rows=([0-9.e+-]+) loops=([0-9]+)\)`)
+	treeAccessRe = regexp.MustCompile(`^(.+?) on (\S+)(?: using (\S+))?(?: over \((.*)\)| \((.*)\))?`)
+	treeJoinRe   = regexp.MustCompile(`^(.*join) \((.*)\)$`)
+	treeLimitRe  = regexp.MustCompile(`, limit input to .*$`)
+)
+
+// parseMySQLTree reads the tree format EXPLAIN ANALYZE prints, one node per
+// line, indented four spaces per level:
+//
+//	-> Filter: (c.id > 5)  (cost=1.15 rows=3) (actual time=0.02..0.03 rows=4 loops=1)
+//	    -> Table scan on c  (cost=1.15 rows=9) (actual time=0.02..0.03 rows=9 loops=1)
+func parseMySQLTree(text string) ([]Node, error) {
+	var b builder
+	// parents[d] is the last node seen at depth d.
+	var parents []int
+	for _, line := range strings.Split(text, "\n") {
+		trimmed := strings.TrimLeft(line, " ")
+		if !strings.HasPrefix(trimmed, "-> ") {
+			continue
+		}
+		depth := (len(line) - len(trimmed)) / 4
+		if depth > len(parents) {
+			depth = len(parents)
+		}
+		parents = parents[:depth]
+
+		n := parseTreeLine(strings.TrimPrefix(trimmed, "-> "))
+		parent := 0
+		if depth > 0 {
+			parent = parents[depth-1]
+		}
+		parents = append(parents, b.add(n, parent))
+	}
+	return b.result()
+}
+
+func parseTreeLine(line string) Node {
+	description := line
+	if i := strings.Index(line, "  ("); i >= 0 {
+		description = line[:i]
+	}
+
+	var n Node
+	switch {
+	case strings.HasPrefix(description, "Filter: "):
+		n.Operation = "Filter"
+		n.Filter = strings.TrimPrefix(description, "Filter: ")
+	case strings.HasPrefix(description, "Sort: "):
+		n.Operation = "Sort"
+		keys := treeLimitRe.ReplaceAllString(strings.TrimPrefix(description, "Sort: "), "")
+		n.SortKeys = strings.Split(keys, ", ")
+	case treeJoinRe.MatchString(description):
+		m := treeJoinRe.FindStringSubmatch(description)
+		n.Operation, n.Condition = m[1], m[2]
+	case treeAccessRe.MatchString(description):
+		m := treeAccessRe.FindStringSubmatch(description)
+		n.Operation, n.Relation, n.Index = m[1], m[2], m[3]
+		n.Condition = firstNonEmpty(m[4], m[5])
+		n.fullScan = m[1] == "Table scan"
+	default:
+		n.Operation = description
+	}
+
+	if m := treeCostRe.FindStringSubmatch(line); m != nil {
+		if cost, err := strconv.ParseFloat(m[1], 64); err == nil {
+			n.Cost = ptr(cost)
+		}
+		if rows, err := strconv.ParseFloat(m[2], 64); err == nil {
+			n.EstimatedRows, n.scanned = ptr(rows), rows
+		}
+	}
+	if m := treeActualRe.FindStringSubmatch(line); m != nil {
+		last, _ := strconv.ParseFloat(m[1], 64)
+		rows, _ := strconv.ParseFloat(m[2], 64)
+		loops, _ := strconv.ParseFloat(m[3], 64)
+		n.ActualRows, n.Loops, n.TimeMs = ptr(rows), ptr(loops), ptr(last*loops)
+		if n.fullScan {
+			n.scanned = rows
+		}
+	} else if strings.Contains(line, "(never executed)") {
+		n.Loops, n.TimeMs = ptr(0), ptr(0)
+	}
+	return n
+}
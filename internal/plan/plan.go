@@ -0,0 +1,309 @@
+// Package plan turns the execution plans databases print into one node
+// tree and points out the parts of it worth a closer look.
+package plan
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Node is one step of a plan. Nodes are listed depth first; the tree is
+// kept through Parent, which is 0 for the root.
+type Node struct {
+	ID            int      `json:"id" jsonschema_description:"Node number, from 1 in depth-first order"`
+	Parent        int      `json:"parent" jsonschema_description:"ID of the parent node, 0 for the root"`
+	Depth         int      `json:"depth" jsonschema_description:"Depth in the tree, 0 for the root"`
+	Operation     string   `json:"operation" jsonschema_description:"What the node does, such as Seq Scan, Hash Join or Sort"`
+	Relation      string   `json:"relation,omitempty" jsonschema_description:"Table the node reads"`
+	Alias         string   `json:"alias,omitempty" jsonschema_description:"Alias of the table in the query, when it differs from the table name"`
+	Index         string   `json:"index,omitempty" jsonschema_description:"Index the node uses"`
+	Condition     string   `json:"condition,omitempty" jsonschema_description:"Index or join condition"`
+	Filter        string   `json:"filter,omitempty" jsonschema_description:"Condition rows are filtered on after they are read"`
+	SortKeys      []string `json:"sort_keys,omitempty" jsonschema_description:"Sort or group keys"`
+	EstimatedRows *float64 `json:"estimated_rows,omitempty" jsonschema_description:"Rows the planner expected per loop"`
+	ActualRows    *float64 `json:"actual_rows,omitempty" jsonschema_description:"Rows produced per loop, when analyzed"`
+	Cost          *float64 `json:"cost,omitempty" jsonschema_description:"Estimated total cost of the node and its children, in the planner's units"`
+	TimeMs        *float64 `json:"time_ms,omitempty" jsonschema_description:"Time spent in the node and its children over all loops, in milliseconds, when analyzed"`
+	Loops         *float64 `json:"loops,omitempty" jsonschema_description:"Times the node ran, when analyzed"`
+
+	// scanned is how many rows a full scan reads per loop, when known.
+	// With filtered set, it is only the rows left after the scan's filter,
+	// until SetTableRows replaces it.
+	scanned  float64
+	filtered bool
+	fullScan bool
+	// spill describes a sort that went to disk.
+	spill string
+}
+
+// FullScan reports whether the node reads a whole table.
+func (n Node) FullScan() bool {
+	return n.fullScan
+}
+
+// RowsScanned is how many rows a full scan reads per loop, or 0 when not
+// known.
+func (n Node) RowsScanned() float64 {
+	return n.scanned
+}
+
+// ScannedTables lists the tables whose full scans only report the rows left
+// after their filter, for SetTableRows.
+func ScannedTables(nodes []Node) []string {
+	var tables []string
+	for _, n := range nodes {
+		if n.fullScan && n.filtered && n.Relation != "" && !slices.Contains(tables, n.Relation) {
+			tables = append(tables, n.Relation)
+		}
+	}
+	return tables
+}
+
+// SetTableRows makes full scans of the tables in rows read the table's row
+// estimate rather than the rows left after their filter.
+func SetTableRows(nodes []Node, rows map[string]float64) {
+	for i := range nodes {
+		n := &nodes[i]
+		if r, ok := rows[n.Relation]; ok && n.fullScan && n.filtered {
+			n.scanned = max(n.scanned, r)
+			n.filtered = false
+		}
+	}
+}
+
+// Hotspot points at a node worth a closer look.
+type Hotspot struct {
+	Node      int    `json:"node" jsonschema_description:"ID of the node"`
+	Operation string `json:"operation" jsonschema_description:"Operation of the node"`
+	Relation  string `json:"relation,omitempty" jsonschema_description:"Table the node reads"`
+	Reason    string `json:"reason" jsonschema_description:"Why the node stands out"`
+}
+
+type Summary struct {
+	Analyzed       bool      `json:"analyzed" jsonschema_description:"Whether the plan has actual rows and timings"`
+	TotalCost      *float64  `json:"total_cost,omitempty" jsonschema_description:"Estimated cost of the whole plan"`
+	TotalTimeMs    *float64  `json:"total_time_ms,omitempty" jsonschema_description:"Actual time of the whole plan in milliseconds, when analyzed"`
+	MostExpensive  []Hotspot `json:"most_expensive,omitempty" jsonschema_description:"Nodes with the highest time of their own, or cost when not analyzed"`
+	SeqScans       []Hotspot `json:"seq_scans,omitempty" jsonschema_description:"Full scans of large tables"`
+	EstimateMisses []Hotspot `json:"estimate_misses,omitempty" jsonschema_description:"Nodes whose actual rows are off the estimate by more than the threshold"`
+	DiskSorts      []Hotspot `json:"disk_sorts,omitempty" jsonschema_description:"Sorts that spilled to disk"`
+	HighLoopJoins  []Hotspot `json:"high_loop_joins,omitempty" jsonschema_description:"Nested loops whose inner side runs many times"`
+}
+
+// Options tune what Summarize reports; zero fields take the defaults.
+type Options struct {
+	// Top is how many of the most expensive nodes to list.
+	Top int
+	// LargeTableRows is the number of rows from which a full scan is
+	// reported.
+	LargeTableRows float64
+	// MissFactor is the ratio between actual and estimated rows from which
+	// an estimate counts as missed.
+	MissFactor float64
+	// HighLoops is the number of inner-side runs from which a nested loop is
+	// reported.
+	HighLoops float64
+}
+
+const (
+	DefaultTop            = 5
+	DefaultLargeTableRows = 10000
+	DefaultMissFactor     = 10
+	DefaultHighLoops      = 1000
+)
+
+func (o Options) withDefaults() Options {
+	if o.Top <= 0 {
+		o.Top = DefaultTop
+	}
+	if o.LargeTableRows <= 0 {
+		o.LargeTableRows = DefaultLargeTableRows
+	}
+	if o.MissFactor <= 1 {
+		o.MissFactor = DefaultMissFactor
+	}
+	if o.HighLoops <= 0 {
+		o.HighLoops = DefaultHighLoops
+	}
+	return o
+}
+
+// Parse reads a plan printed by the given database type: Postgres JSON, or
+// MySQL JSON or tree (EXPLAIN ANALYZE) output.
+func Parse(dbType, text string) ([]Node, error) {
+	switch dbType {
+	case "postgres":
+		return parsePostgres(text)
+	case "mysql":
+		if t := strings.TrimSpace(text); strings.HasPrefix(t, "{") {
+			return parseMySQLJSON(t)
+		}
+		return parseMySQLTree(text)
+	default:
+		return nil, fmt.Errorf("%s plans are not supported; only Postgres and MySQL plans are parsed", dbType)
+	}
+}
+
+// builder numbers nodes as they are added depth first.
+type builder struct {
+	nodes []Node
+}
+
+// add appends n under parent and returns its ID.
+func (b *builder) add(n Node, parent int) int {
+	n.ID = len(b.nodes) + 1
+	n.Parent = parent
+	if parent > 0 {
+		n.Depth = b.nodes[parent-1].Depth + 1
+	}
+	b.nodes = append(b.nodes, n)
+	return n.ID
+}
+
+func (b *builder) result() ([]Node, error) {
+	if len(b.nodes) == 0 {
+		return nil, fmt.Errorf("plan has no nodes")
+	}
+	return b.nodes, nil
+}
+
+// Summarize lists the hotspots of a plan.
+func Summarize(nodes []Node, opts Options) Summary {
+	opts = opts.withDefaults()
+	var s Summary
+	if len(nodes) == 0 {
+		return s
+	}
+
+	children := make([][]int, len(nodes)+1)
+	for _, n := range nodes {
+		children[n.Parent] = append(children[n.Parent], n.ID)
+	}
+	node := func(id int) *Node { return &nodes[id-1] }
+
+	s.Analyzed = nodes[0].TimeMs != nil
+	s.TotalCost = nodes[0].Cost
+	s.TotalTimeMs = nodes[0].TimeMs
+
+	s.MostExpensive = mostExpensive(nodes, children, s, opts.Top)
+
+	for _, n := range nodes {
+		if n.fullScan {
+			if n.scanned >= opts.LargeTableRows {
+				reason := fmt.Sprintf("reads %s rows", formatNumber(n.scanned))
+				if n.Loops != nil && *n.Loops > 1 {
+					reason += fmt.Sprintf(" per loop over %s loops", formatNumber(*n.Loops))
+				}
+				if n.Filter != "" {
+					reason += " to filter on " + n.Filter
+				}
+				s.SeqScans = append(s.SeqScans, hotspot(n, reason))
+			}
+		}
+
+		if n.EstimatedRows != nil && n.ActualRows != nil && (n.Loops == nil || *n.Loops > 0) {
+			estimated, actual := max(*n.EstimatedRows, 1), max(*n.ActualRows, 1)
+			switch {
+			case actual/estimated >= opts.MissFactor:
+				s.EstimateMisses = append(s.EstimateMisses, hotspot(n, fmt.Sprintf("estimated %s rows, got %s (%sx more)",
+					formatNumber(*n.EstimatedRows), formatNumber(*n.ActualRows), formatNumber(actual/estimated))))
+			case estimated/actual >= opts.MissFactor:
+				s.EstimateMisses = append(s.EstimateMisses, hotspot(n, fmt.Sprintf("estimated %s rows, got %s (%sx fewer)",
+					formatNumber(*n.EstimatedRows), formatNumber(*n.ActualRows), formatNumber(estimated/actual))))
+			}
+		}
+
+		if n.spill != "" {
+			s.DiskSorts = append(s.DiskSorts, hotspot(n, n.spill))
+		}
+
+		if isNestedLoop(n.Operation) && len(children[n.ID]) >= 2 {
+			outer, inner := node(children[n.ID][0]), node(children[n.ID][1])
+			switch {
+			case inner.Loops != nil && *inner.Loops >= opts.HighLoops:
+				s.HighLoopJoins = append(s.HighLoopJoins, hotspot(n, fmt.Sprintf("inner side %s runs %s times",
+					describe(inner), formatNumber(*inner.Loops))))
+			case inner.Loops == nil && outer.EstimatedRows != nil && *outer.EstimatedRows >= opts.HighLoops:
+				s.HighLoopJoins = append(s.HighLoopJoins, hotspot(n, fmt.Sprintf("inner side %s is expected to run %s times",
+					describe(inner), formatNumber(*outer.EstimatedRows))))
+			}
+		}
+	}
+	return s
+}
+
+// mostExpensive ranks nodes by their own time, or their own cost when the
+// plan was not analyzed: their total less that of their children.
+func mostExpensive(nodes []Node, children [][]int, s Summary, top int) []Hotspot {
+	total := s.TotalCost
+	measure := func(n *Node) *float64 { return n.Cost }
+	unit := func(v float64) string { return "cost " + formatNumber(v) }
+	if s.Analyzed {
+		total = s.TotalTimeMs
+		measure = func(n *Node) *float64 { return n.TimeMs }
+		unit = func(v float64) string { return strconv.FormatFloat(v, 'f', 3, 64) + " ms" }
+	}
+	if total == nil || *total <= 0 {
+		return nil
+	}
+
+	type ranked struct {
+		node *Node
+		own  float64
+	}
+	var all []ranked
+	for i := range nodes {
+		n := &nodes[i]
+		v := measure(n)
+		if v == nil {
+			continue
+		}
+		own := *v
+		for _, c := range children[n.ID] {
+			if cv := measure(&nodes[c-1]); cv != nil {
+				own -= *cv
+			}
+		}
+		// Nodes under 1% are not worth a look.
+		if own >= *total/100 {
+			all = append(all, ranked{n, own})
+		}
+	}
+	sort.SliceStable(all, func(i, j int) bool { return all[i].own > all[j].own })
+
+	var hotspots []Hotspot
+	for _, r := range all[:min(top, len(all))] {
+		hotspots = append(hotspots, hotspot(*r.node, fmt.Sprintf("%s of its own, %.0f%% of the total",
+			unit(r.own), 100*r.own / *total)))
+	}
+	return hotspots
+}
+
+func hotspot(n Node, reason string) Hotspot {
+	return Hotspot{Node: n.ID, Operation: n.Operation, Relation: n.Relation, Reason: reason}
+}
+
+func isNestedLoop(operation string) bool {
+	return strings.HasPrefix(strings.ToLower(operation), "nested loop")
+}
+
+func describe(n *Node) string {
+	if n.Relation != "" {
+		return fmt.Sprintf("(%s on %s)", n.Operation, n.Relation)
+	}
+	return "(" + n.Operation + ")"
+}
+
+func formatNumber(v float64) string {
+	if v == float64(int64(v)) {
+		return strconv.FormatInt(int64(v), 10)
+	}
+	return strconv.FormatFloat(v, 'f', 1, 64)
+}
+
+func ptr(v float64) *float64 {
+	return &v
+}
@@ -0,0 +1,218 @@
+package plan
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func parseFixture(t *testing.T, dbType, name string) []Node {
+	t.Helper()
+	text, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	nodes, err := Parse(dbType, string(text))
+	if err != nil {
+		t.Fatalf("Parse %s: %v", name, err)
+	}
+	return nodes
+}
+
+// shape lists each node as depth, operation and relation.
+func shape(nodes []Node) []string {
+	var out []string
+	for _, n := range nodes {
+		s := strconv.Itoa(n.Depth) + " " + n.Operation
+		if n.Relation != "" {
+			s += " on " + n.Relation
+		}
+		out = append(out, s)
+	}
+	return out
+}
+
+func nodeOn(t *testing.T, nodes []Node, relation string) Node {
+	t.Helper()
+	for _, n := range nodes {
+		if n.Relation == relation {
+			return n
+		}
+	}
+	t.Fatalf("no node reads %s", relation)
+	return Node{}
+}
+
+func hotspotRelations(hotspots []Hotspot) []string {
+	var out []string
+	for _, h := range hotspots {
+		out = append(out, h.Relation)
+	}
+	return out
+}
+
+func TestParsePostgres(t *testing.T) {
+	nodes := parseFixture(t, "postgres", "postgres_explain.json")
+
+	want := []string{"0 Sort", "1 Hash Join", "2 Seq Scan on orders", "2 Hash", "3 Seq Scan on customers"}
+	if got := shape(nodes); !reflect.DeepEqual(got, want) {
+		t.Fatalf("nodes = %q, want %q", got, want)
+	}
+	orders := nodeOn(t, nodes, "orders")
+	if !orders.FullScan() || orders.Alias != "o" || orders.Filter != "(status = 'open'::text)" {
+		t.Errorf("orders scan = %+v", orders)
+	}
+	if nodes[1].Condition != "(o.customer_id = c.id)" {
+		t.Errorf("hash join condition = %q", nodes[1].Condition)
+	}
+
+	// Plan Rows of a scan counts the rows left after its filter.
+	if got := orders.RowsScanned(); got != 1000 {
+		t.Errorf("orders scan reads %v rows before SetTableRows, want the 1000 it returns", got)
+	}
+	if got := ScannedTables(nodes); !reflect.DeepEqual(got, []string{"orders", "customers"}) {
+		t.Errorf("ScannedTables = %q", got)
+	}
+	if s := Summarize(nodes, Options{}); len(s.SeqScans) != 0 {
+		t.Errorf("seq scans reported from post-filter rows: %+v", s.SeqScans)
+	}
+
+	SetTableRows(nodes, map[string]float64{"orders": 100000, "customers": 1000})
+	if got := nodeOn(t, nodes, "orders").RowsScanned(); got != 100000 {
+		t.Errorf("orders scan reads %v rows, want the table's 100000", got)
+	}
+	if got := ScannedTables(nodes); len(got) != 0 {
+		t.Errorf("ScannedTables after SetTableRows = %q", got)
+	}
+
+	s := Summarize(nodes, Options{})
+	if s.Analyzed || s.TotalCost == nil || *s.TotalCost != 2284.46 {
+		t.Errorf("summary = %+v, want total cost 2284.46 and not analyzed", s)
+	}
+	if got := hotspotRelations(s.SeqScans); !reflect.DeepEqual(got, []string{"orders"}) {
+		t.Errorf("seq scans = %+v, want orders", s.SeqScans)
+	}
+	if len(s.MostExpensive) == 0 || s.MostExpensive[0].Relation != "orders" {
+		t.Errorf("most expensive = %+v, want the orders scan first", s.MostExpensive)
+	}
+}
+
+func TestParsePostgresAnalyze(t *testing.T) {
+	nodes := parseFixture(t, "postgres", "postgres_analyze.json")
+
+	orders := nodeOn(t, nodes, "orders")
+	if got := orders.RowsScanned(); got != 100000 {
+		t.Errorf("orders scan reads %v rows, want returned plus removed 100000", got)
+	}
+	if got := ScannedTables(nodes); len(got) != 0 {
+		t.Errorf("ScannedTables of an analyzed plan = %q", got)
+	}
+
+	s := Summarize(nodes, Options{})
+	if !s.Analyzed || s.TotalTimeMs == nil || *s.TotalTimeMs != 25.410 {
+		t.Errorf("summary = %+v, want analyzed with 25.41 ms", s)
+	}
+	if got := hotspotRelations(s.SeqScans); !reflect.DeepEqual(got, []string{"orders"}) {
+		t.Errorf("seq scans = %+v, want orders", s.SeqScans)
+	}
+	if len(s.DiskSorts) != 1 || s.DiskSorts[0].Reason != "external merge used 1432 kB of disk" {
+		t.Errorf("disk sorts = %+v", s.DiskSorts)
+	}
+	// Sort, Hash Join and the orders scan returned 20000 rows against 1000.
+	if len(s.EstimateMisses) != 3 {
+		t.Errorf("estimate misses = %+v, want 3", s.EstimateMisses)
+	}
+}
+
+func TestParseMySQLJSON(t *testing.T) {
+	nodes := parseFixture(t, "mysql", "mysql_explain.json")
+
+	want := []string{"0 Select #1", "1 Order (filesort)", "2 Nested Loop", "3 Table Scan on o", "3 Unique Index Lookup on c"}
+	if got := shape(nodes); !reflect.DeepEqual(got, want) {
+		t.Fatalf("nodes = %q, want %q", got, want)
+	}
+	o := nodeOn(t, nodes, "o")
+	if !o.FullScan() || o.RowsScanned() != 99712 {
+		t.Errorf("o scan = %+v, want a full scan of 99712 rows", o)
+	}
+	if o.EstimatedRows == nil || *o.EstimatedRows != 9971.2 {
+		t.Errorf("o scan estimates %v rows, want 9971.2 after the filter", o.EstimatedRows)
+	}
+	c := nodeOn(t, nodes, "c")
+	if c.Index != "PRIMARY" || c.Condition != "id = shop.o.customer_id" {
+		t.Errorf("c lookup = %+v", c)
+	}
+	// MySQL reports the rows a scan examines, so no table sizes are needed.
+	if got := ScannedTables(nodes); len(got) != 0 {
+		t.Errorf("ScannedTables = %q", got)
+	}
+
+	s := Summarize(nodes, Options{})
+	if s.TotalCost == nil || *s.TotalCost != 4519.75 {
+		t.Errorf("total cost = %v, want 4519.75", s.TotalCost)
+	}
+	if got := hotspotRelations(s.SeqScans); !reflect.DeepEqual(got, []string{"o"}) {
+		t.Errorf("seq scans = %+v, want o", s.SeqScans)
+	}
+	if len(s.HighLoopJoins) != 1 {
+		t.Errorf("high loop joins = %+v, want the nested loop", s.HighLoopJoins)
+	}
+}
+
+func TestParseMySQLTree(t *testing.T) {
+	nodes := parseFixture(t, "mysql", "mysql_analyze.txt")
+
+	want := []string{
+		"0 Sort",
+		"1 Stream results",
+		"2 Nested loop inner join",
+		"3 Filter",
+		"4 Table scan on o",
+		"3 Single-row index lookup on c",
+	}
+	if got := shape(nodes); !reflect.DeepEqual(got, want) {
+		t.Fatalf("nodes = %q, want %q", got, want)
+	}
+	if !reflect.DeepEqual(nodes[0].SortKeys, []string{"o.created_at"}) {
+		t.Errorf("sort keys = %q", nodes[0].SortKeys)
+	}
+	o := nodeOn(t, nodes, "o")
+	if !o.FullScan() || o.RowsScanned() != 100000 {
+		t.Errorf("o scan = %+v, want a full scan of 100000 rows", o)
+	}
+	c := nodeOn(t, nodes, "c")
+	if c.Index != "PRIMARY" || c.Condition != "id=o.customer_id" || c.Loops == nil || *c.Loops != 20000 {
+		t.Errorf("c lookup = %+v", c)
+	}
+
+	s := Summarize(nodes, Options{})
+	if !s.Analyzed {
+		t.Error("tree plan with actual times is not analyzed")
+	}
+	if got := hotspotRelations(s.SeqScans); !reflect.DeepEqual(got, []string{"o"}) {
+		t.Errorf("seq scans = %+v, want o", s.SeqScans)
+	}
+	if len(s.HighLoopJoins) != 1 || s.HighLoopJoins[0].Reason != "inner side (Single-row index lookup on c) runs 20000 times" {
+		t.Errorf("high loop joins = %+v", s.HighLoopJoins)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		dbType string
+		text   string
+	}{
+		{"postgres", "Seq Scan on orders  (cost=0.00..2199.00 rows=1000 width=40)"},
+		{"postgres", "[]"},
+		{"mysql", `{"message": "no query_block"}`},
+		{"mysql", "id | select_type | table"},
+		{"sqlite", "SCAN orders"},
+	}
+	for _, tt := range tests {
+		if _, err := Parse(tt.dbType, tt.text); err == nil {
+			t.Errorf("Parse(%s, %q) succeeded", tt.dbType, tt.text)
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package plan
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// pgNode is a node of EXPLAIN (FORMAT JSON) output.
+type pgNode struct {
+	NodeType            string   `json:"Node Type"`
+	Strategy            string   `json:"Strategy"`
+	JoinType            string   `json:"Join Type"`
+	ParallelAware       bool     `json:"Parallel Aware"`
+	ScanDirection       string   `json:"Scan Direction"`
+	RelationName        string   `json:"Relation Name"`
+	CTEName             string   `json:"CTE Name"`
+	FunctionName        string   `json:"Function Name"`
+	Alias               string   `json:"Alias"`
+	IndexName           string   `json:"Index Name"`
+	TotalCost           *float64 `json:"Total Cost"`
+	PlanRows            float64  `json:"Plan Rows"`
+	ActualTotalTime     *float64 `json:"Actual Total Time"`
+	ActualRows          *float64 `json:"Actual Rows"`
+	ActualLoops         *float64 `json:"Actual Loops"`
+	IndexCond           string   `json:"Index Cond"`
+	RecheckCond         string   `json:"Recheck Cond"`
+	HashCond            string   `json:"Hash Cond"`
+	MergeCond           string   `json:"Merge Cond"`
+	Filter              string   `json:"Filter"`
+	JoinFilter          string   `json:"Join Filter"`
+	RowsRemovedByFilter float64  `json:"Rows Removed by Filter"`
+	SortKey             []string `json:"Sort Key"`
+	GroupKey            []string `json:"Group Key"`
+	SortMethod          string   `json:"Sort Method"`
+	SortSpaceUsed       float64  `json:"Sort Space Used"`
+	SortSpaceType       string   `json:"Sort Space Type"`
+	Plans               []pgNode `json:"Plans"`
+}
+
+var pgAggregates = map[string]string{
+	"Sorted": "GroupAggregate",
+	"Hashed": "HashAggregate",
+	"Mixed":  "MixedAggregate",
+}
+
+func parsePostgres(text string) ([]Node, error) {
+	var statements []struct {
+		Plan *pgNode `json:"Plan"`
+	}
+	if err := json.Unmarshal([]byte(text), &statements); err != nil {
+		return nil, fmt.Errorf("parse Postgres plan: %v", err)
+	}
+	if len(statements) == 0 || statements[0].Plan == nil {
+		return nil, fmt.Errorf("parse Postgres plan: no plan found")
+	}
+
+	var b builder
+	addPostgres(&b, statements[0].Plan, 0)
+	return b.result()
+}
+
+func addPostgres(b *builder, p *pgNode, parent int) {
+	n := Node{
+		Operation:     pgOperation(p),
+		Relation:      p.RelationName,
+		Index:         p.IndexName,
+		Condition:     firstNonEmpty(p.IndexCond, p.HashCond, p.MergeCond, p.RecheckCond),
+		Filter:        joinConditions(p.Filter, p.JoinFilter),
+		SortKeys:      p.SortKey,
+		EstimatedRows: ptr(p.PlanRows),
+		ActualRows:    p.ActualRows,
+		Cost:          p.TotalCost,
+		Loops:         p.ActualLoops,
+		fullScan:      p.NodeType == "Seq Scan",
+	}
+	if n.Relation == "" {
+		n.Relation = firstNonEmpty(p.CTEName, p.FunctionName)
+	}
+	if p.Alias != "" && p.Alias != n.Relation {
+		n.Alias = p.Alias
+	}
+	if len(n.SortKeys) == 0 {
+		n.SortKeys = p.GroupKey
+	}
+	if p.ActualTotalTime != nil {
+		loops := 1.0
+		if p.ActualLoops != nil {
+			loops = *p.ActualLoops
+		}
+		n.TimeMs = ptr(*p.ActualTotalTime * loops)
+	}
+	// Without analyze, only the rows a scan returns after its filter are
+	// known; SetTableRows fills in the table's size.
+	n.scanned, n.filtered = p.PlanRows, true
+	if p.ActualRows != nil {
+		n.scanned, n.filtered = *p.ActualRows+p.RowsRemovedByFilter, false
+	}
+	if p.SortSpaceType == "Disk" {
+		n.spill = fmt.Sprintf("%s used %s kB of disk", p.SortMethod, formatNumber(p.SortSpaceUsed))
+	}
+
+	id := b.add(n, parent)
+	for i := range p.Plans {
+		addPostgres(b, &p.Plans[i], id)
+	}
+}
+
+// pgOperation names a node the way text EXPLAIN does.
+func pgOperation(p *pgNode) string {
+	op := p.NodeType
+	if p.NodeType == "Aggregate" && pgAggregates[p.Strategy] != "" {
+		op = pgAggregates[p.Strategy]
+	}
+	if p.JoinType != "" && p.JoinType != "Inner" {
+		if strings.HasSuffix(op, " Join") {
+			op = strings.TrimSuffix(op, "Join") + p.JoinType + " Join"
+		} else {
+			op += " " + p.JoinType + " Join"
+		}
+	}
+	if p.ScanDirection == "Backward" {
+		op += " Backward"
+	}
+	if p.ParallelAware {
+		op = "Parallel " + op
+	}
+	return op
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+func joinConditions(conditions ...string) string {
+	var parts []string
+	for _, c := range conditions {
+		if c != "" {
+			parts = append(parts, c)
+		}
+	}
+	return strings.Join(parts, " AND ")
+}
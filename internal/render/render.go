@@ -0,0 +1,239 @@
+package render
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+type Format string
+
+const (
+	JSON     Format = "json"
+	Markdown Format = "markdown"
+	CSV      Format = "csv"
+	NDJSON   Format = "ndjson"
+)
+
+// ParseFormat validates a tool's format argument; empty means JSON.
+func ParseFormat(s string) (Format, error) {
+	switch f := Format(strings.ToLower(s)); f {
+	case "":
+		return JSON, nil
+	case JSON, Markdown, CSV, NDJSON:
+		return f, nil
+	default:
+		return "", fmt.Errorf("unsupported format %q (expected json, markdown, csv or ndjson)", s)
+	}
+}
+
+// Table is tabular tool output: column names and rows of values in column
+// order.
+type Table struct {
+	Columns []string
+	Rows    [][]interface{}
+}
+
+// Render returns the text content for a tool result. JSON renders the
+// structured output as is; the other formats render the table.
+func Render(f Format, structured interface{}, t Table) (string, error) {
+	switch f {
+	case Markdown:
+		return markdown(t), nil
+	case CSV:
+		return writeAll(t, func(w io.Writer) (RowWriter, error) { return NewCSVWriter(w, t.Columns) })
+	case NDJSON:
+		return writeAll(t, func(w io.Writer) (RowWriter, error) { return NewNDJSONWriter(w, t.Columns), nil })
+	default:
+		b, err := json.Marshal(structured)
+		if err != nil {
+			return "", fmt.Errorf("JSON marshal error: %v", err)
+		}
+		return string(b), nil
+	}
+}
+
+func markdown(t Table) string {
+	var b strings.Builder
+	writeMarkdownRow(&b, len(t.Columns), func(i int) string { return escapeMarkdown(t.Columns[i]) })
+	b.WriteString("|")
+	for range t.Columns {
+		b.WriteString(" --- |")
+	}
+	b.WriteString("\n")
+	for _, row := range t.Rows {
+		writeMarkdownRow(&b, len(t.Columns), func(i int) string {
+			if row[i] == nil {
+				return "NULL"
+			}
+			return escapeMarkdown(CellText(row[i]))
+		})
+	}
+	return b.String()
+}
+
+func writeMarkdownRow(b *strings.Builder, n int, cell func(i int) string) {
+	b.WriteString("|")
+	for i := 0; i < n; i++ {
+		b.WriteString(" ")
+		b.WriteString(cell(i))
+		b.WriteString(" |")
+	}
+	b.WriteString("\n")
+}
+
+var markdownEscaper = strings.NewReplacer("|", `\|`, "\r\n", "<br>", "\n", "<br>")
+
+func escapeMarkdown(s string) string {
+	return markdownEscaper.Replace(s)
+}
+
+// RowWriter streams rows of a result to an output, one at a time.
+type RowWriter interface {
+	WriteRow(row []interface{}) error
+	// Flush writes out anything buffered; call it once after the last row.
+	Flush() error
+}
+
+type csvWriter struct {
+	w      *csv.Writer
+	record []string
+}
+
+// NewCSVWriter writes RFC 4180 CSV with a header row; NULL is an empty
+// field.
+func NewCSVWriter(w io.Writer, columns []string) (RowWriter, error) {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(columns); err != nil {
+		return nil, err
+	}
+	return &csvWriter{w: cw, record: make([]string, len(columns))}, nil
+}
+
+func (c *csvWriter) WriteRow(row []interface{}) error {
+	for i, v := range row {
+		c.record[i] = ""
+		if v != nil {
+			c.record[i] = CellText(v)
+		}
+	}
+	return c.w.Write(c.record)
+}
+
+func (c *csvWriter) Flush() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+type ndjsonWriter struct {
+	w    *bufio.Writer
+	keys [][]byte
+}
+
+// NewNDJSONWriter writes one JSON object per row, keys in column order.
+// Repeated column names get a numeric suffix so no value is lost.
+func NewNDJSONWriter(w io.Writer, columns []string) RowWriter {
+	names := UniqueNames(columns)
+	keys := make([][]byte, len(names))
+	for i, name := range names {
+		keys[i], _ = json.Marshal(name)
+	}
+	return &ndjsonWriter{w: bufio.NewWriter(w), keys: keys}
+}
+
+func (n *ndjsonWriter) WriteRow(row []interface{}) error {
+	n.w.WriteByte('{')
+	for i, v := range row {
+		if i > 0 {
+			n.w.WriteByte(',')
+		}
+		value, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Errorf("JSON marshal error: %v", err)
+		}
+		n.w.Write(n.keys[i])
+		n.w.WriteByte(':')
+		n.w.Write(value)
+	}
+	_, err := n.w.WriteString("}\n")
+	return err
+}
+
+func (n *ndjsonWriter) Flush() error {
+	return n.w.Flush()
+}
+
+func writeAll(t Table, newWriter func(io.Writer) (RowWriter, error)) (string, error) {
+	var buf bytes.Buffer
+	w, err := newWriter(&buf)
+	if err != nil {
+		return "", err
+	}
+	for _, row := range t.Rows {
+		if err := w.WriteRow(row); err != nil {
+			return "", err
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// UniqueNames suffixes repeated column names ("id", "id_2") for outputs
+// that key values by name. A suffix skips names already in the list, so
+// "a", "a", "a_2" becomes "a", "a_3", "a_2".
+func UniqueNames(names []string) []string {
+	used := make(map[string]bool, len(names))
+	for _, name := range names {
+		used[name] = true
+	}
+	first := make(map[string]bool, len(names))
+	next := make(map[string]int)
+	unique := make([]string, len(names))
+	for i, name := range names {
+		unique[i] = name
+		if !first[name] {
+			first[name] = true
+			continue
+		}
+		n := max(next[name], 2)
+		for used[name+"_"+strconv.Itoa(n)] {
+			n++
+		}
+		unique[i] = name + "_" + strconv.Itoa(n)
+		used[unique[i]] = true
+		next[name] = n + 1
+	}
+	return unique
+}
+
+// CellText formats a value for a text cell: scalars as they read, anything
+// structured as JSON.
+func CellText(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case bool:
+		return strconv.FormatBool(v)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case uint64:
+		return strconv.FormatUint(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	case int:
+		return strconv.Itoa(v)
+	default:
+		b, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprint(v)
+		}
+		return string(b)
+	}
+}
@@ -0,0 +1,33 @@
+package render
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestUniqueNames(t *testing.T) {
+	tests := []struct {
+		names []string
+		want  []string
+	}{
+		{[]string{"id", "name"}, []string{"id", "name"}},
+		{[]string{"id", "id", "id"}, []string{"id", "id_2", "id_3"}},
+		{[]string{"a", "a", "a_2"}, []string{"a", "a_3", "a_2"}},
+		{[]string{"a_2", "a", "a", "a_2"}, []string{"a_2", "a", "a_3", "a_2_2"}},
+		{[]string{"a", "a", "a_2", "a_2"}, []string{"a", "a_3", "a_2", "a_2_2"}},
+		{nil, []string{}},
+	}
+	for _, tt := range tests {
+		got := UniqueNames(tt.names)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("UniqueNames(%q) = %q, want %q", tt.names, got, tt.want)
+		}
+		seen := make(map[string]bool, len(got))
+		for _, name := range got {
+			if seen[name] {
+				t.Errorf("UniqueNames(%q) repeats %s", tt.names, name)
+			}
+			seen[name] = true
+		}
+	}
+}
@@ -0,0 +1,295 @@
+package resultset
+
+import (
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// MaxBinaryBytes caps how much of a binary value is returned. Longer values
+// come back as a TruncatedBinary.
+const MaxBinaryBytes = 4096
+
+type Column struct {
+	Name         string `json:"name" jsonschema_description:"Column name"`
+	DatabaseType string `json:"database_type" jsonschema_description:"Column type as reported by the database driver"`
+	Nullable     *bool  `json:"nullable,omitempty" jsonschema_description:"Whether the column can be null, when the driver knows"`
+	Precision    *int64 `json:"precision,omitempty" jsonschema_description:"Numeric precision, for decimal columns"`
+	Scale        *int64 `json:"scale,omitempty" jsonschema_description:"Numeric scale, for decimal columns"`
+	Length       *int64 `json:"length,omitempty" jsonschema_description:"Maximum length, for variable-length columns"`
+}
+
+// TruncatedBinary stands in for a binary value longer than MaxBinaryBytes.
+type TruncatedBinary struct {
+	Truncated bool   `json:"truncated"`
+	Size      int    `json:"size"`
+	Base64    string `json:"base64"`
+}
+
+// dateTimeLayouts are the text forms drivers return timestamps in when they
+// do not parse them: MySQL DATETIME over the text protocol, and what SQLite
+// drivers store for a bound time.Time.
+var dateTimeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05.999999999-07:00",
+	"2006-01-02 15:04:05.999999999 -0700",
+	"2006-01-02 15:04:05.999999999",
+}
+
+// Kind is how values of a column are normalized, derived from its database
+// type.
+type Kind int
+
+const (
+	KindText Kind = iota
+	KindInteger
+	KindFloat
+	KindDecimal
+	KindDateTime
+	KindJSON
+	KindBinary
+	KindBoolean
+)
+
+var kindsByType = map[string]Kind{
+	"TINYINT": KindInteger, "SMALLINT": KindInteger, "MEDIUMINT": KindInteger,
+	"INT": KindInteger, "INTEGER": KindInteger, "BIGINT": KindInteger, "YEAR": KindInteger,
+	"INT2": KindInteger, "INT4": KindInteger, "INT8": KindInteger,
+	"FLOAT": KindFloat, "DOUBLE": KindFloat, "REAL": KindFloat, "FLOAT4": KindFloat, "FLOAT8": KindFloat,
+	"DECIMAL": KindDecimal, "NUMERIC": KindDecimal, "MONEY": KindDecimal,
+	"DATETIME": KindDateTime, "TIMESTAMP": KindDateTime,
+	"JSON": KindJSON, "JSONB": KindJSON,
+	"BYTEA": KindBinary, "BLOB": KindBinary, "TINYBLOB": KindBinary, "MEDIUMBLOB": KindBinary,
+	"LONGBLOB": KindBinary, "BINARY": KindBinary, "VARBINARY": KindBinary,
+	"GEOMETRY": KindBinary, "BOOL": KindBoolean, "BOOLEAN": KindBoolean,
+}
+
+// kindsByDialect overrides kindsByType for types whose values one driver
+// returns differently. MySQL returns BIT columns as raw bytes; Postgres
+// returns bit and varbit as text such as "1010", so they stay text there.
+var kindsByDialect = map[string]map[string]Kind{
+	"mysql": {"BIT": KindBinary},
+}
+
+// KindOf returns the kind of a column of databaseType, as the driver for
+// dbType reports it.
+func KindOf(dbType, databaseType string) Kind {
+	name := strings.ToUpper(databaseType)
+	name = strings.TrimPrefix(name, "UNSIGNED ")
+	if i := strings.IndexByte(name, '('); i >= 0 {
+		name = strings.TrimSpace(name[:i])
+	}
+	if k, ok := kindsByDialect[dbType][name]; ok {
+		return k
+	}
+	return kindsByType[name]
+}
+
+// Scanner reads the rows of one result as ordered, JSON-ready values.
+type Scanner struct {
+	rows    *sql.Rows
+	columns []Column
+	kinds   []Kind
+	values  []interface{}
+	ptrs    []interface{}
+}
+
+// NewScanner reads rows returned by the driver for dbType.
+func NewScanner(rows *sql.Rows, dbType string) (*Scanner, error) {
+	types, err := rows.ColumnTypes()
+	if err != nil {
+		return nil, fmt.Errorf("error getting columns: %v", err)
+	}
+
+	s := &Scanner{
+		rows:    rows,
+		columns: make([]Column, len(types)),
+		kinds:   make([]Kind, len(types)),
+		values:  make([]interface{}, len(types)),
+		ptrs:    make([]interface{}, len(types)),
+	}
+	for i, t := range types {
+		s.columns[i] = columnFromType(t)
+		s.kinds[i] = KindOf(dbType, t.DatabaseTypeName())
+		s.ptrs[i] = &s.values[i]
+	}
+	return s, nil
+}
+
+func columnFromType(t *sql.ColumnType) Column {
+	c := Column{Name: t.Name(), DatabaseType: t.DatabaseTypeName()}
+	if nullable, ok := t.Nullable(); ok {
+		c.Nullable = &nullable
+	}
+	if precision, scale, ok := t.DecimalSize(); ok {
+		c.Precision, c.Scale = &precision, &scale
+	}
+	if length, ok := t.Length(); ok && length > 0 && length < math.MaxInt32 {
+		c.Length = &length
+	}
+	return c
+}
+
+func (s *Scanner) Columns() []Column {
+	return s.columns
+}
+
+func (s *Scanner) Kinds() []Kind {
+	return s.kinds
+}
+
+// Scan returns the current row, normalized; call it after rows.Next.
+func (s *Scanner) Scan() ([]interface{}, error) {
+	row, err := s.ScanRaw()
+	if err != nil {
+		return nil, err
+	}
+	for i, v := range row {
+		row[i] = Normalize(v, s.kinds[i], MaxBinaryBytes)
+	}
+	return row, nil
+}
+
+// ScanRaw returns the current row as the driver returned it.
+func (s *Scanner) ScanRaw() ([]interface{}, error) {
+	if err := s.rows.Scan(s.ptrs...); err != nil {
+		return nil, fmt.Errorf("error scanning row: %v", err)
+	}
+	row := make([]interface{}, len(s.values))
+	copy(row, s.values)
+	return row, nil
+}
+
+// ReadAll reads every remaining row.
+func ReadAll(rows *sql.Rows, dbType string) ([]Column, [][]interface{}, error) {
+	s, err := NewScanner(rows, dbType)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	result := make([][]interface{}, 0)
+	for rows.Next() {
+		row, err := s.Scan()
+		if err != nil {
+			return nil, nil, err
+		}
+		result = append(result, row)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, fmt.Errorf("error iterating rows: %v", err)
+	}
+	return s.Columns(), result, nil
+}
+
+// Normalize turns a scanned driver value into a JSON-safe one: decimals stay
+// exact as strings, times are RFC 3339, binary data is base64 and JSON
+// columns are decoded. Binary values longer than binaryLimit bytes are
+// truncated, unless binaryLimit is 0.
+func Normalize(v interface{}, k Kind, binaryLimit int) interface{} {
+	switch v := v.(type) {
+	case nil:
+		return nil
+	case []byte:
+		return normalizeBytes(v, k, binaryLimit)
+	case string:
+		switch k {
+		case KindJSON:
+			return decodeJSON(v)
+		case KindDateTime:
+			if t, ok := ParseDateTime(v); ok {
+				return t.Format(time.RFC3339Nano)
+			}
+		}
+		return v
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	case float64:
+		return normalizeFloat(v)
+	case float32:
+		return normalizeFloat(float64(v))
+	default:
+		return v
+	}
+}
+
+// normalizeBytes handles drivers returning raw text for typed columns, as
+// MySQL does for every column of a text-protocol query.
+func normalizeBytes(b []byte, k Kind, binaryLimit int) interface{} {
+	switch k {
+	case KindBinary:
+		return encodeBinary(b, binaryLimit)
+	case KindJSON:
+		return decodeJSON(string(b))
+	case KindInteger:
+		if i, err := strconv.ParseInt(string(b), 10, 64); err == nil {
+			return i
+		}
+		if u, err := strconv.ParseUint(string(b), 10, 64); err == nil {
+			return u
+		}
+	case KindFloat:
+		if f, err := strconv.ParseFloat(string(b), 64); err == nil {
+			return normalizeFloat(f)
+		}
+	case KindBoolean:
+		if v, err := strconv.ParseBool(string(b)); err == nil {
+			return v
+		}
+	case KindDateTime:
+		if t, ok := ParseDateTime(string(b)); ok {
+			return t.Format(time.RFC3339Nano)
+		}
+	}
+	if !utf8.Valid(b) {
+		return encodeBinary(b, binaryLimit)
+	}
+	return string(b)
+}
+
+// ParseDateTime parses the text forms of dateTimeLayouts.
+func ParseDateTime(s string) (time.Time, bool) {
+	// time.Time.String() ends with a zone name, which need not parse.
+	if fields := strings.Fields(s); len(fields) == 4 {
+		s = strings.Join(fields[:3], " ")
+	}
+	for _, layout := range dateTimeLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+func encodeBinary(b []byte, limit int) interface{} {
+	if limit > 0 && len(b) > limit {
+		return TruncatedBinary{
+			Truncated: true,
+			Size:      len(b),
+			Base64:    base64.StdEncoding.EncodeToString(b[:limit]),
+		}
+	}
+	return base64.StdEncoding.EncodeToString(b)
+}
+
+func decodeJSON(s string) interface{} {
+	var v interface{}
+	if err := json.Unmarshal([]byte(s), &v); err != nil {
+		return s
+	}
+	return v
+}
+
+// normalizeFloat keeps NaN and infinities, which JSON cannot encode, as
+// strings.
+func normalizeFloat(f float64) interface{} {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return strconv.FormatFloat(f, 'g', -1, 64)
+	}
+	return f
+}
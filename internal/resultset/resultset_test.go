@@ -0,0 +1,108 @@
+package resultset
+
+import (
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestKindOf(t *testing.T) {
+	tests := []struct {
+		dbType       string
+		databaseType string
+		want         Kind
+	}{
+		{"postgres", "INT8", KindInteger},
+		{"postgres", "NUMERIC", KindDecimal},
+		{"postgres", "TIMESTAMP", KindDateTime},
+		{"postgres", "JSONB", KindJSON},
+		{"postgres", "BYTEA", KindBinary},
+		{"postgres", "BOOL", KindBoolean},
+		{"postgres", "BIT", KindText},
+		{"postgres", "VARBIT", KindText},
+		{"mysql", "BIT", KindBinary},
+		{"mysql", "UNSIGNED BIGINT", KindInteger},
+		{"mysql", "DECIMAL", KindDecimal},
+		{"mysql", "DATETIME", KindDateTime},
+		{"mysql", "VARBINARY", KindBinary},
+		{"mysql", "VARCHAR", KindText},
+		{"sqlite", "integer", KindInteger},
+		{"sqlite", "VARCHAR(20)", KindText},
+		{"sqlite", "DECIMAL(10, 2)", KindDecimal},
+		{"sqlite", "BIT", KindText},
+		{"sqlite", "", KindText},
+	}
+	for _, tt := range tests {
+		if got := KindOf(tt.dbType, tt.databaseType); got != tt.want {
+			t.Errorf("KindOf(%s, %q) = %v, want %v", tt.dbType, tt.databaseType, got, tt.want)
+		}
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	at := time.Date(2024, 3, 1, 12, 30, 0, 500, time.UTC)
+	tests := []struct {
+		name  string
+		value interface{}
+		kind  Kind
+		limit int
+		want  interface{}
+	}{
+		{"nil", nil, KindInteger, 0, nil},
+		{"integer text", []byte("42"), KindInteger, 0, int64(42)},
+		{"unsigned integer text", []byte("18446744073709551615"), KindInteger, 0, uint64(math.MaxUint64)},
+		{"float text", []byte("1.5"), KindFloat, 0, 1.5},
+		{"NaN", math.NaN(), KindFloat, 0, "NaN"},
+		{"infinity text", []byte("+Inf"), KindFloat, 0, "+Inf"},
+		{"decimal stays exact", []byte("12345678901234567890.123"), KindDecimal, 0, "12345678901234567890.123"},
+		{"boolean text", []byte("1"), KindBoolean, 0, true},
+		{"datetime text", []byte("2024-03-01 12:30:00.0000005"), KindDateTime, 0, "2024-03-01T12:30:00.0000005Z"},
+		{"datetime string", "2024-03-01 12:30:00.0000005 +0000 UTC", KindDateTime, 0, "2024-03-01T12:30:00.0000005Z"},
+		{"time", at, KindDateTime, 0, "2024-03-01T12:30:00.0000005Z"},
+		{"JSON text", []byte(`{"a":[1,2]}`), KindJSON, 0, map[string]interface{}{"a": []interface{}{1.0, 2.0}}},
+		{"invalid JSON", "{", KindJSON, 0, "{"},
+		{"binary", []byte{0x00, 0xff}, KindBinary, 0, "AP8="},
+		{"binary over the limit", []byte{1, 2, 3, 4}, KindBinary, 2, TruncatedBinary{Truncated: true, Size: 4, Base64: "AQI="}},
+		{"Postgres bit string", []byte("1010"), KindText, 0, "1010"},
+		{"invalid UTF-8 text", []byte{0xff, 0xfe}, KindText, 0, "//4="},
+		{"text", []byte("héllo"), KindText, 0, "héllo"},
+		{"integer", int64(7), KindInteger, 0, int64(7)},
+	}
+	for _, tt := range tests {
+		if got := Normalize(tt.value, tt.kind, tt.limit); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: Normalize(%v) = %#v, want %#v", tt.name, tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestNormalizeMySQLBit(t *testing.T) {
+	// MySQL returns BIT(10) b'1000000001' as two raw bytes.
+	got := Normalize([]byte{0x02, 0x01}, KindOf("mysql", "BIT"), MaxBinaryBytes)
+	if got != "AgE=" {
+		t.Errorf("MySQL BIT = %#v, want base64 AgE=", got)
+	}
+	got = Normalize([]byte("1000000001"), KindOf("postgres", "BIT"), MaxBinaryBytes)
+	if got != "1000000001" {
+		t.Errorf("Postgres bit = %#v, want the bit string", got)
+	}
+}
+
+func TestParseDateTime(t *testing.T) {
+	for _, s := range []string{
+		"2024-03-01T12:30:00Z",
+		"2024-03-01 12:30:00",
+		"2024-03-01 12:30:00.123+02:00",
+		"2024-03-01 12:30:00 +0000 UTC",
+	} {
+		if _, ok := ParseDateTime(s); !ok {
+			t.Errorf("ParseDateTime(%q) failed", s)
+		}
+	}
+	for _, s := range []string{"", "2024-03-01", "yesterday", strings.Repeat("9", 20)} {
+		if _, ok := ParseDateTime(s); ok {
+			t.Errorf("ParseDateTime(%q) succeeded", s)
+		}
+	}
+}
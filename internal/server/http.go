@@ -0,0 +1,144 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/AbdelilahOu/DBMcp/internal/auth"
+	"github.com/AbdelilahOu/DBMcp/internal/client"
+	"github.com/AbdelilahOu/DBMcp/internal/config"
+	"github.com/AbdelilahOu/DBMcp/internal/logger"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+const httpShutdownTimeout = 15 * time.Second
+
+type HTTPServerConfig struct {
+	Version           string
+	InitialConnection string
+	Config            *config.Config
+	Addr              string
+	BasePath          string
+	TLSCertFile       string
+	TLSKeyFile        string
+}
+
+func normalizeBasePath(basePath string) string {
+	return "/" + strings.Trim(basePath, "/")
+}
+
+func RunHTTPServer(cfg HTTPServerConfig) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Ensure logger cleanup on shutdown
+	defer func() {
+		if err := logger.Shutdown(); err != nil {
+			fmt.Printf("Error shutting down logger: %v\n", err)
+		}
+	}()
+	defer client.DefaultManager().Close()
+
+	server, err := NewMCPServer(MCPServerConfig{
+		Version:           cfg.Version,
+		InitialConnection: cfg.InitialConnection,
+		Config:            cfg.Config,
+	})
+	if err != nil {
+		logger.Error("Failed to create MCP server", err)
+		return fmt.Errorf("failed to create MCP server: %w", err)
+	}
+
+	basePath := normalizeBasePath(cfg.BasePath)
+	var handler http.Handler = mcp.NewStreamableHTTPHandler(func(*http.Request) *mcp.Server {
+		return server
+	}, nil)
+
+	var authConfig config.AuthConfig
+	if cfg.Config != nil {
+		authConfig = cfg.Config.Auth
+	}
+	if authConfig.Enabled() {
+		authenticator, err := auth.New(authConfig)
+		if err != nil {
+			logger.Error("Failed to configure authentication", err)
+			return fmt.Errorf("failed to configure authentication: %w", err)
+		}
+		handler = auth.Middleware(authenticator, handler)
+	} else {
+		logger.Warn("HTTP server is running without authentication; anyone who can reach it can use every configured connection")
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle(basePath, handler)
+
+	httpServer := &http.Server{
+		Addr:              cfg.Addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	useTLS := cfg.TLSCertFile != "" || cfg.TLSKeyFile != ""
+	if authConfig.ClientCert != nil {
+		if !useTLS {
+			return fmt.Errorf("client certificate authentication requires TLS; set tls.cert_file and tls.key_file")
+		}
+		tlsConfig, err := auth.ServerTLSConfig(authConfig.ClientCert.CAFile)
+		if err != nil {
+			return err
+		}
+		httpServer.TLSConfig = tlsConfig
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		if useTLS {
+			errCh <- httpServer.ListenAndServeTLS(cfg.TLSCertFile, cfg.TLSKeyFile)
+		} else {
+			errCh <- httpServer.ListenAndServe()
+		}
+	}()
+
+	logger.Info("DB MCP Server started and listening", map[string]interface{}{
+		"version":   cfg.Version,
+		"addr":      cfg.Addr,
+		"base_path": basePath,
+		"tls":       useTLS,
+		"auth":      authConfig.Enabled(),
+	})
+	fmt.Printf("DB MCP Server listening on %s%s ...\n", cfg.Addr, basePath)
+
+	select {
+	case err := <-errCh:
+		if errors.Is(err, http.ErrServerClosed) {
+			err = nil
+		}
+		if err != nil {
+			logger.Error("Server stopped with error", err)
+		}
+		return err
+	case <-ctx.Done():
+	}
+
+	logger.Info("Shutdown signal received, draining HTTP connections")
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), httpShutdownTimeout)
+	defer cancel()
+
+	if err := httpServer.Shutdown(shutdownCtx); err != nil {
+		// Long-lived SSE streams can outlast the drain window.
+		httpServer.Close()
+		logger.Error("Server stopped with error", err)
+		return fmt.Errorf("http server shutdown: %w", err)
+	}
+
+	logger.Info("Server stopped gracefully")
+	return nil
+}
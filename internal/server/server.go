@@ -6,6 +6,7 @@ import (
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"github.com/AbdelilahOu/DBMcp/internal/client"
 	"github.com/AbdelilahOu/DBMcp/internal/config"
@@ -41,6 +42,10 @@ func NewMCPServer(cfg MCPServerConfig) (*mcp.Server, error) {
 		"version": cfg.Version,
 	})
 
+	if ttl := cfg.Config.Settings.PoolIdleTTL; ttl > 0 {
+		client.DefaultManager().SetIdleTTL(time.Duration(ttl))
+	}
+
 	// Initialize connection if specified
 	if cfg.InitialConnection != "" {
 		conn, exists := cfg.Config.GetConnection(cfg.InitialConnection)
@@ -51,7 +56,7 @@ func NewMCPServer(cfg MCPServerConfig) (*mcp.Server, error) {
 			})
 			return nil, err
 		}
-		err := initializeConnection(conn, cfg.InitialConnection)
+		err := initializeConnection(conn, cfg.InitialConnection, cfg.Config.IsReadOnly(conn), cfg.Config.SettingsFor(conn))
 		if err != nil {
 			logger.Error("Failed to initialize connection", err, map[string]interface{}{
 				"connection": cfg.InitialConnection,
@@ -61,6 +66,7 @@ func NewMCPServer(cfg MCPServerConfig) (*mcp.Server, error) {
 		logger.Info("Initial connection established", map[string]interface{}{
 			"connection": cfg.InitialConnection,
 			"type":       conn.Type,
+			"read_only":  cfg.Config.IsReadOnly(conn),
 		})
 		fmt.Printf("Successfully initialized connection: %s\n", cfg.InitialConnection)
 	}
@@ -76,25 +82,14 @@ type StdioServerConfig struct {
 	Config            *config.Config
 }
 
-func initializeConnection(conn config.Connection, connectionName string) error {
-	dbClient, err := client.NewDBClient(conn.URL, conn.Type)
+func initializeConnection(conn config.Connection, connectionName string, readOnly bool, settings config.Settings) error {
+	sessionState, _ := state.GetOrCreateSession(state.DefaultSessionID)
+	err := tools.ConnectSession(context.Background(), sessionState, connectionName, conn, readOnly, settings)
 	if err != nil {
-		logger.LogConnectionEvent("initialize_connection", connectionName, conn.Type, err)
-		return fmt.Errorf("failed to connect to database: %w", err)
-	}
-
-	// Create or update the default session with this connection
-	sessionID := "default"
-	sessionState := state.GetOrCreateSession(sessionID, dbClient)
-	if sessionState == nil {
-		err := fmt.Errorf("failed to create session")
 		logger.LogConnectionEvent("initialize_connection", connectionName, conn.Type, err)
 		return err
 	}
 
-	// Ensure the connection is properly set in the session
-	sessionState.Conn = dbClient.DB
-
 	logger.LogConnectionEvent("initialize_connection", connectionName, conn.Type, nil)
 	return nil
 }
@@ -109,6 +104,7 @@ func RunStdioServer(cfg StdioServerConfig) error {
 			fmt.Printf("Error shutting down logger: %v\n", err)
 		}
 	}()
+	defer client.DefaultManager().Close()
 
 	server, err := NewMCPServer(MCPServerConfig{
 		Version:           cfg.Version,
@@ -0,0 +1,269 @@
+// Package snapshot keeps point-in-time copies of schema catalogs as JSON
+// files, one directory per connection and schema.
+package snapshot
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/AbdelilahOu/DBMcp/internal/catalog"
+)
+
+// FormatVersion is written into every snapshot; Load refuses files of
+// other versions.
+const FormatVersion = 1
+
+// stampLayout names snapshot files; it sorts in time order.
+const stampLayout = "20060102T150405.000Z"
+
+type Snapshot struct {
+	FormatVersion int       `json:"format_version"`
+	ID            string    `json:"id"`
+	Connection    string    `json:"connection"`
+	TakenAt       time.Time `json:"taken_at"`
+	// Hash is the SHA-256 of the schema's JSON, to tell unchanged
+	// snapshots apart without comparing them.
+	Hash   string          `json:"hash"`
+	Schema *catalog.Schema `json:"schema"`
+}
+
+// Info describes a stored snapshot without reading it.
+type Info struct {
+	ID         string    `json:"id" jsonschema_description:"Snapshot ID"`
+	Connection string    `json:"connection" jsonschema_description:"Connection the snapshot was taken on"`
+	Schema     string    `json:"schema" jsonschema_description:"Schema the snapshot holds"`
+	TakenAt    time.Time `json:"taken_at" jsonschema_description:"When the snapshot was taken"`
+}
+
+// Store is a directory of snapshots. IDs are connection/schema/time, with
+// the connection and schema path-escaped, and map to files of the same
+// relative path.
+type Store struct {
+	dir string
+}
+
+// saveLocks holds a mutex per snapshot directory, so concurrent saves of
+// the same schema cannot both find it changed and write it twice. It is
+// package-wide because stores are created per call.
+var saveLocks sync.Map
+
+func NewStore(dir string) *Store {
+	return &Store{dir: dir}
+}
+
+// Save stores schema as a new snapshot of connection. When the latest
+// snapshot of the same schema is identical, it is returned instead and
+// the second result is false.
+func (st *Store) Save(connection string, schema *catalog.Schema) (*Snapshot, bool, error) {
+	data, err := json.Marshal(schema)
+	if err != nil {
+		return nil, false, err
+	}
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:])
+
+	dir := filepath.Dir(st.path(makeID(connection, schema.Name, time.Time{})))
+	lock, _ := saveLocks.LoadOrStore(filepath.Clean(dir), &sync.Mutex{})
+	lock.(*sync.Mutex).Lock()
+	defer lock.(*sync.Mutex).Unlock()
+
+	latest, err := st.Latest(connection, schema.Name)
+	if err != nil {
+		return nil, false, err
+	}
+	if latest != nil {
+		previous, err := st.Load(latest.ID)
+		if err == nil && previous.Hash == hash {
+			return previous, false, nil
+		}
+	}
+
+	now := time.Now().UTC()
+	s := &Snapshot{
+		FormatVersion: FormatVersion,
+		ID:            makeID(connection, schema.Name, now),
+		Connection:    connection,
+		TakenAt:       now,
+		Hash:          hash,
+		Schema:        schema,
+	}
+	if err := st.write(s); err != nil {
+		return nil, false, err
+	}
+	return s, true, nil
+}
+
+func (st *Store) write(s *Snapshot) error {
+	path := st.path(s.ID)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("create snapshot directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// Written aside and renamed so a crash never leaves half a snapshot.
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".partial-*")
+	if err != nil {
+		return fmt.Errorf("create snapshot: %w", err)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("write snapshot: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("write snapshot: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("write snapshot: %w", err)
+	}
+	return nil
+}
+
+func (st *Store) Load(id string) (*Snapshot, error) {
+	if _, _, _, err := parseID(id); err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(st.path(id))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("snapshot %s not found", id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read snapshot %s: %w", id, err)
+	}
+
+	var s Snapshot
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("read snapshot %s: %w", id, err)
+	}
+	if s.FormatVersion != FormatVersion {
+		return nil, fmt.Errorf("snapshot %s has format version %d, expected %d", id, s.FormatVersion, FormatVersion)
+	}
+	if s.Schema == nil {
+		return nil, fmt.Errorf("snapshot %s holds no schema", id)
+	}
+	return &s, nil
+}
+
+// List returns the stored snapshots, oldest first. Empty connection or
+// schema match any.
+func (st *Store) List(connection, schema string) ([]Info, error) {
+	infos := make([]Info, 0)
+	err := filepath.WalkDir(st.dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) && path == st.dir {
+				return fs.SkipAll
+			}
+			return err
+		}
+		if entry.IsDir() || !strings.HasSuffix(path, ".json") {
+			return nil
+		}
+		rel, err := filepath.Rel(st.dir, path)
+		if err != nil {
+			return nil
+		}
+		id := strings.TrimSuffix(filepath.ToSlash(rel), ".json")
+		conn, sch, takenAt, err := parseID(id)
+		if err != nil {
+			// Not a snapshot file.
+			return nil
+		}
+		if (connection == "" || conn == connection) && (schema == "" || sch == schema) {
+			infos = append(infos, Info{ID: id, Connection: conn, Schema: sch, TakenAt: takenAt})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list snapshots: %w", err)
+	}
+
+	sort.SliceStable(infos, func(i, j int) bool {
+		return infos[i].TakenAt.Before(infos[j].TakenAt)
+	})
+	return infos, nil
+}
+
+// Latest returns the newest snapshot of connection's schema, or nil when
+// there is none.
+func (st *Store) Latest(connection, schema string) (*Info, error) {
+	infos, err := st.List(connection, schema)
+	if err != nil || len(infos) == 0 {
+		return nil, err
+	}
+	return &infos[len(infos)-1], nil
+}
+
+// Before returns the newest snapshot of connection's schema taken at or
+// before t, or nil when there is none.
+func (st *Store) Before(connection, schema string, t time.Time) (*Info, error) {
+	infos, err := st.List(connection, schema)
+	if err != nil {
+		return nil, err
+	}
+	for i := len(infos) - 1; i >= 0; i-- {
+		if !infos[i].TakenAt.After(t) {
+			return &infos[i], nil
+		}
+	}
+	return nil, nil
+}
+
+func (st *Store) path(id string) string {
+	return filepath.Join(st.dir, filepath.FromSlash(id)+".json")
+}
+
+func makeID(connection, schema string, t time.Time) string {
+	return escapeSegment(connection) + "/" + escapeSegment(schema) + "/" + t.Format(stampLayout)
+}
+
+// escapeSegment path-escapes a name, dots included when they would make it
+// a relative directory reference.
+func escapeSegment(name string) string {
+	escaped := url.PathEscape(name)
+	if escaped == "." || escaped == ".." {
+		escaped = strings.ReplaceAll(escaped, ".", "%2E")
+	}
+	return escaped
+}
+
+// parseID splits an ID, refusing anything that would resolve outside the
+// store.
+func parseID(id string) (connection, schema string, takenAt time.Time, err error) {
+	parts := strings.Split(id, "/")
+	if len(parts) != 3 {
+		return "", "", time.Time{}, fmt.Errorf("invalid snapshot id %q", id)
+	}
+	for _, p := range parts[:2] {
+		if p == "" || p == "." || p == ".." || strings.ContainsAny(p, `\`) {
+			return "", "", time.Time{}, fmt.Errorf("invalid snapshot id %q", id)
+		}
+	}
+	if connection, err = url.PathUnescape(parts[0]); err != nil {
+		return "", "", time.Time{}, fmt.Errorf("invalid snapshot id %q", id)
+	}
+	if schema, err = url.PathUnescape(parts[1]); err != nil {
+		return "", "", time.Time{}, fmt.Errorf("invalid snapshot id %q", id)
+	}
+	if takenAt, err = time.Parse(stampLayout, parts[2]); err != nil {
+		return "", "", time.Time{}, fmt.Errorf("invalid snapshot id %q", id)
+	}
+	return connection, schema, takenAt, nil
+}
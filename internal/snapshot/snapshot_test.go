@@ -0,0 +1,128 @@
+package snapshot
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/AbdelilahOu/DBMcp/internal/catalog"
+)
+
+func testSchema(name string) *catalog.Schema {
+	return &catalog.Schema{Name: name, Dialect: "postgres", Tables: []catalog.Table{{
+		Name:    "orders",
+		Columns: []catalog.Column{{Name: "id", DataType: "bigint", IsPrimaryKey: true}},
+	}}}
+}
+
+func TestParseIDRefusesTraversal(t *testing.T) {
+	for _, id := range []string{
+		"../prod/20240301T120000.000Z",
+		"prod/../20240301T120000.000Z",
+		"./public/20240301T120000.000Z",
+		"prod/public/../../../etc/passwd",
+		"prod/public/20240301T120000.000Z/..",
+		`prod\..\x/public/20240301T120000.000Z`,
+		"/prod/public/20240301T120000.000Z",
+		"prod/public",
+		"prod/public/yesterday",
+		"prod/%zz/20240301T120000.000Z",
+	} {
+		if _, _, _, err := parseID(id); err == nil {
+			t.Errorf("parseID(%q) accepted", id)
+		}
+	}
+
+	conn, schema, takenAt, err := parseID("prod/public/20240301T120000.000Z")
+	if err != nil {
+		t.Fatalf("parseID: %v", err)
+	}
+	if conn != "prod" || schema != "public" || !takenAt.Equal(time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)) {
+		t.Errorf("parseID = %s, %s, %v", conn, schema, takenAt)
+	}
+}
+
+func TestLoadRefusesTraversal(t *testing.T) {
+	root := t.TempDir()
+	st := NewStore(filepath.Join(root, "snapshots"))
+	if err := os.WriteFile(filepath.Join(root, "secret.json"), []byte(`{"format_version":1,"schema":{}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := st.Load("../secret"); err == nil {
+		t.Error("Load read a file outside the store")
+	}
+}
+
+func TestEscapedNamesRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	st := NewStore(dir)
+
+	for _, tt := range []struct{ connection, schema string }{
+		{"prod/eu", "public"},
+		{"..", "."},
+		{"a%2Fb", "sales data"},
+		{"prod", "../escape"},
+	} {
+		s, created, err := st.Save(tt.connection, testSchema(tt.schema))
+		if err != nil {
+			t.Fatalf("Save(%q, %q): %v", tt.connection, tt.schema, err)
+		}
+		if !created {
+			t.Errorf("Save(%q, %q) was not created", tt.connection, tt.schema)
+		}
+
+		conn, schema, _, err := parseID(s.ID)
+		if err != nil || conn != tt.connection || schema != tt.schema {
+			t.Errorf("ID %s parses to %q, %q, %v", s.ID, conn, schema, err)
+		}
+		loaded, err := st.Load(s.ID)
+		if err != nil {
+			t.Fatalf("Load(%s): %v", s.ID, err)
+		}
+		if loaded.Connection != tt.connection || loaded.Schema.Name != tt.schema {
+			t.Errorf("Load(%s) = %s/%s", s.ID, loaded.Connection, loaded.Schema.Name)
+		}
+		infos, err := st.List(tt.connection, tt.schema)
+		if err != nil || len(infos) != 1 || infos[0].ID != s.ID {
+			t.Errorf("List(%q, %q) = %v, %v, want %s", tt.connection, tt.schema, infos, err, s.ID)
+		}
+
+		path, err := filepath.Abs(st.path(s.ID))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.HasPrefix(path, dir+string(filepath.Separator)) {
+			t.Errorf("%s is stored outside the store at %s", s.ID, path)
+		}
+	}
+}
+
+func TestSaveSkipsUnchanged(t *testing.T) {
+	st := NewStore(t.TempDir())
+
+	first, created, err := st.Save("prod", testSchema("public"))
+	if err != nil || !created {
+		t.Fatalf("first Save = %v, %v", created, err)
+	}
+	second, created, err := st.Save("prod", testSchema("public"))
+	if err != nil {
+		t.Fatalf("second Save: %v", err)
+	}
+	if created || second.ID != first.ID {
+		t.Errorf("an identical schema was saved again as %s (created %v)", second.ID, created)
+	}
+
+	// Stamps have millisecond precision.
+	time.Sleep(2 * time.Millisecond)
+	changed := testSchema("public")
+	changed.Tables[0].Columns = append(changed.Tables[0].Columns, catalog.Column{Name: "status", DataType: "text"})
+	third, created, err := st.Save("prod", changed)
+	if err != nil || !created || third.ID == first.ID {
+		t.Fatalf("changed schema: %v, created %v, id %s", err, created, third.ID)
+	}
+	if infos, _ := st.List("prod", "public"); len(infos) != 2 {
+		t.Errorf("store holds %d snapshots, want 2", len(infos))
+	}
+}
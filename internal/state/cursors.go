@@ -0,0 +1,194 @@
+package state
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"time"
+
+	"github.com/AbdelilahOu/DBMcp/internal/client"
+	"github.com/AbdelilahOu/DBMcp/internal/config"
+)
+
+// maxCursorsPerSession bounds the pool connections one session can hold
+// through unfinished results; opening another closes the oldest.
+const maxCursorsPerSession = 4
+
+// cursorsPerPool counts the open cursors on each connection's pool across
+// sessions. Each holds one of the pool's connections, so they may only take
+// half of them and leave the rest to other tools.
+var cursorsPerPool = make(map[string]int)
+
+// HeldCursor is a paged query result kept open between tool calls.
+type HeldCursor struct {
+	*client.Cursor
+	Query    string
+	PageSize int
+
+	pool     string
+	released bool
+}
+
+// OpenCursor opens a cursor on the session's pool with open. It fails
+// without calling open when cursors already hold half of the pool's
+// connections, unless the session is at its own limit and the new cursor
+// will replace its oldest.
+func (s *DBSessionState) OpenCursor(open func() (*client.Cursor, error), query string, pageSize int) (*HeldCursor, error) {
+	mu.Lock()
+	pool := s.ConnectionName
+	limit := cursorLimit(s.Settings)
+	if len(s.session().cursors) < maxCursorsPerSession && cursorsPerPool[pool] >= limit {
+		mu.Unlock()
+		return nil, fmt.Errorf("connection '%s' already has the most open cursors it allows (%d); read them to the end or let them expire", pool, limit)
+	}
+	cursorsPerPool[pool]++
+	mu.Unlock()
+
+	c, err := open()
+	if err != nil {
+		releaseCursor(pool)
+		return nil, err
+	}
+	return &HeldCursor{Cursor: c, Query: query, PageSize: pageSize, pool: pool}, nil
+}
+
+// Close closes the cursor and gives its connection back to the pool.
+func (h *HeldCursor) Close() error {
+	err := h.Cursor.Close()
+	mu.Lock()
+	defer mu.Unlock()
+	if !h.released {
+		h.released = true
+		releaseCursorLocked(h.pool)
+	}
+	return err
+}
+
+func releaseCursor(pool string) {
+	mu.Lock()
+	defer mu.Unlock()
+	releaseCursorLocked(pool)
+}
+
+func releaseCursorLocked(pool string) {
+	if cursorsPerPool[pool]--; cursorsPerPool[pool] <= 0 {
+		delete(cursorsPerPool, pool)
+	}
+}
+
+// cursorIdleTTL is how long an unread cursor keeps its connection and its
+// transaction, which holds back vacuum on Postgres and writers on SQLite.
+func cursorIdleTTL(settings config.Settings) time.Duration {
+	if settings.CursorIdleTTL <= 0 {
+		return config.DefaultCursorIdleTTL
+	}
+	return time.Duration(settings.CursorIdleTTL)
+}
+
+// cursorLimit is half of the pool's max_connections, and at least one.
+func cursorLimit(settings config.Settings) int {
+	maxConns := settings.MaxConnections
+	if maxConns <= 0 {
+		maxConns = config.DefaultMaxConnections
+	}
+	return max(1, maxConns/2)
+}
+
+type heldCursor struct {
+	*HeldCursor
+	stored time.Time
+	timer  *time.Timer
+}
+
+// PutCursor stores c for a later page and returns its opaque ID. The cursor
+// is closed if it is not taken again within the idle TTL. If the session
+// switched connections since s was taken, c is closed and PutCursor fails.
+func (s *DBSessionState) PutCursor(c *HeldCursor) (string, error) {
+	id := newCursorID()
+	settings, conn := s.Settings, s.Conn
+	s = s.session()
+
+	mu.Lock()
+	if s.ConnectionName != c.pool || s.Conn != conn {
+		current := s.ConnectionName
+		mu.Unlock()
+		c.Close()
+		return "", fmt.Errorf("the session switched to connection '%s' while the query ran; run it again", current)
+	}
+	if s.cursors == nil {
+		s.cursors = make(map[string]*heldCursor)
+	}
+	var evicted *HeldCursor
+	if len(s.cursors) >= maxCursorsPerSession {
+		evicted = s.removeOldestCursorLocked()
+	}
+	h := &heldCursor{HeldCursor: c, stored: time.Now()}
+	h.timer = time.AfterFunc(cursorIdleTTL(settings), func() {
+		if c, ok := s.TakeCursor(id); ok {
+			c.Close()
+		}
+	})
+	s.cursors[id] = h
+	mu.Unlock()
+
+	if evicted != nil {
+		evicted.Close()
+	}
+	return id, nil
+}
+
+// TakeCursor removes the cursor from the session; the caller either puts it
+// back or closes it.
+func (s *DBSessionState) TakeCursor(id string) (*HeldCursor, bool) {
+	s = s.session()
+	mu.Lock()
+	defer mu.Unlock()
+
+	h, ok := s.cursors[id]
+	if !ok {
+		return nil, false
+	}
+	h.timer.Stop()
+	delete(s.cursors, id)
+	return h.HeldCursor, true
+}
+
+func (s *DBSessionState) removeOldestCursorLocked() *HeldCursor {
+	var oldestID string
+	var oldest *heldCursor
+	for id, h := range s.cursors {
+		if oldest == nil || h.stored.Before(oldest.stored) {
+			oldestID, oldest = id, h
+		}
+	}
+	if oldest == nil {
+		return nil
+	}
+	oldest.timer.Stop()
+	delete(s.cursors, oldestID)
+	return oldest.HeldCursor
+}
+
+// detachCursorsLocked empties the session's cursors so the caller can close
+// them once mu is released.
+func (s *DBSessionState) detachCursorsLocked() []*HeldCursor {
+	var detached []*HeldCursor
+	for id, h := range s.cursors {
+		h.timer.Stop()
+		detached = append(detached, h.HeldCursor)
+		delete(s.cursors, id)
+	}
+	return detached
+}
+
+func closeCursors(cursors []*HeldCursor) {
+	for _, c := range cursors {
+		c.Close()
+	}
+}
+
+func newCursorID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
@@ -0,0 +1,156 @@
+package state
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/AbdelilahOu/DBMcp/internal/client"
+	"github.com/AbdelilahOu/DBMcp/internal/config"
+	"github.com/AbdelilahOu/DBMcp/internal/dialect"
+	_ "modernc.org/sqlite"
+)
+
+func openTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "cursors.db"))
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func openTestCursor(t *testing.T, s *DBSessionState, db *sql.DB) (*HeldCursor, error) {
+	t.Helper()
+	return s.OpenCursor(func() (*client.Cursor, error) {
+		return client.OpenCursor(context.Background(), db, dialect.SQLite{}, "SELECT 1 UNION ALL SELECT 2")
+	}, "SELECT 1 UNION ALL SELECT 2", 1)
+}
+
+func putTestCursor(t *testing.T, s *DBSessionState, c *HeldCursor) string {
+	t.Helper()
+	id, err := s.PutCursor(c)
+	if err != nil {
+		t.Fatalf("PutCursor: %v", err)
+	}
+	return id
+}
+
+func closeSessionCursors(s *DBSessionState) {
+	mu.Lock()
+	cursors := s.detachCursorsLocked()
+	mu.Unlock()
+	closeCursors(cursors)
+}
+
+func poolCursors(pool string) int {
+	mu.Lock()
+	defer mu.Unlock()
+	return cursorsPerPool[pool]
+}
+
+func TestCursorsPerSessionEvictOldest(t *testing.T) {
+	db := openTestDB(t)
+	s := &DBSessionState{ConnectionName: t.Name(), Settings: config.Settings{MaxConnections: 20}}
+	defer closeSessionCursors(s)
+
+	var ids []string
+	for i := 0; i < maxCursorsPerSession+1; i++ {
+		c, err := openTestCursor(t, s, db)
+		if err != nil {
+			t.Fatalf("cursor %d: %v", i+1, err)
+		}
+		ids = append(ids, putTestCursor(t, s, c))
+		// Eviction picks the oldest by store time.
+		time.Sleep(time.Millisecond)
+	}
+
+	if _, ok := s.TakeCursor(ids[0]); ok {
+		t.Errorf("oldest cursor is still open after %d more were stored", maxCursorsPerSession)
+	}
+	if got := len(s.cursors); got != maxCursorsPerSession {
+		t.Errorf("session holds %d cursors, want %d", got, maxCursorsPerSession)
+	}
+	if got := poolCursors(t.Name()); got != maxCursorsPerSession {
+		t.Errorf("pool counts %d cursors, want %d", got, maxCursorsPerSession)
+	}
+}
+
+func TestCursorsPerPoolLimit(t *testing.T) {
+	db := openTestDB(t)
+	settings := config.Settings{MaxConnections: 4}
+	a := &DBSessionState{ConnectionName: t.Name(), Settings: settings}
+	b := &DBSessionState{ConnectionName: t.Name(), Settings: settings}
+	defer closeSessionCursors(a)
+
+	limit := cursorLimit(settings)
+	if limit != 2 {
+		t.Fatalf("cursorLimit = %d, want 2", limit)
+	}
+	for i := 0; i < limit; i++ {
+		c, err := openTestCursor(t, a, db)
+		if err != nil {
+			t.Fatalf("cursor %d: %v", i+1, err)
+		}
+		putTestCursor(t, a, c)
+	}
+
+	opened := false
+	_, err := b.OpenCursor(func() (*client.Cursor, error) {
+		opened = true
+		return nil, nil
+	}, "SELECT 1", 1)
+	if err == nil {
+		t.Fatal("OpenCursor succeeded past the pool's limit")
+	}
+	if opened {
+		t.Error("OpenCursor opened a cursor before refusing it")
+	}
+
+	closeSessionCursors(a)
+	if got := poolCursors(t.Name()); got != 0 {
+		t.Fatalf("pool counts %d cursors after closing them all, want 0", got)
+	}
+	c, err := openTestCursor(t, b, db)
+	if err != nil {
+		t.Fatalf("OpenCursor after the pool's cursors closed: %v", err)
+	}
+	c.Close()
+}
+
+func TestCursorIdleExpiry(t *testing.T) {
+	db := openTestDB(t)
+	s := &DBSessionState{ConnectionName: t.Name(), Settings: config.Settings{CursorIdleTTL: config.Duration(20 * time.Millisecond)}}
+
+	c, err := openTestCursor(t, s, db)
+	if err != nil {
+		t.Fatalf("OpenCursor: %v", err)
+	}
+	id := putTestCursor(t, s, c)
+
+	deadline := time.Now().Add(2 * time.Second)
+	for poolCursors(t.Name()) > 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if got := poolCursors(t.Name()); got != 0 {
+		t.Fatalf("pool counts %d cursors after the idle TTL, want 0", got)
+	}
+	if _, ok := s.TakeCursor(id); ok {
+		t.Error("cursor can still be taken after the idle TTL")
+	}
+	if got := db.Stats().InUse; got != 0 {
+		t.Errorf("%d connections still in use after the cursor expired", got)
+	}
+}
+
+func TestCursorIdleTTLDefault(t *testing.T) {
+	if got := cursorIdleTTL(config.Settings{}); got != config.DefaultCursorIdleTTL {
+		t.Errorf("cursorIdleTTL with no setting = %s, want %s", got, config.DefaultCursorIdleTTL)
+	}
+	if got := cursorIdleTTL(config.Settings{CursorIdleTTL: config.Duration(time.Second)}); got != time.Second {
+		t.Errorf("cursorIdleTTL = %s, want 1s", got)
+	}
+}
@@ -5,12 +5,25 @@ import (
 	"sync"
 
 	"github.com/AbdelilahOu/DBMcp/internal/client"
-	"github.com/google/uuid"
+	"github.com/AbdelilahOu/DBMcp/internal/config"
+	"github.com/AbdelilahOu/DBMcp/internal/dialect"
 )
 
+// DefaultSessionID keys the session used by transports without session IDs
+// (stdio) and holds the connection opened at startup.
+const DefaultSessionID = "default"
+
 type DBSessionState struct {
-	Conn          *sql.DB
-	CurrentSchema string
+	ConnectionName string
+	Conn           *sql.DB
+	Dialect        dialect.Dialect
+	ReadOnly       bool
+	CurrentSchema  string
+	Settings       config.Settings
+
+	cursors map[string]*heldCursor
+	// live is the session a snapshot was taken from.
+	live *DBSessionState
 }
 
 var (
@@ -18,45 +31,37 @@ var (
 	mu       sync.RWMutex
 )
 
-func GetOrCreateSession(sessionID string, globalClient *client.DBClient) *DBSessionState {
+// GetOrCreateSession returns the state for sessionID, creating it if needed.
+// A new session starts on the default session's connection, if any. The
+// second result reports whether the session was created.
+func GetOrCreateSession(sessionID string) (*DBSessionState, bool) {
 	mu.RLock()
 	if s, ok := sessions[sessionID]; ok {
 		mu.RUnlock()
-		return s
+		return s, false
 	}
 	mu.RUnlock()
 
 	mu.Lock()
 	defer mu.Unlock()
 
-	if globalClient == nil {
-		id := sessionID
-		if id == "" {
-			id = uuid.New().String()
-		}
-		s := &DBSessionState{
-			Conn:          nil,
-			CurrentSchema: "public",
-		}
-		sessions[id] = s
-		return s
-	}
-
-	conn := globalClient.DB
-	if err := conn.Ping(); err != nil {
-		return nil
+	if s, ok := sessions[sessionID]; ok {
+		return s, false
 	}
 
-	id := sessionID
-	if id == "" {
-		id = uuid.New().String()
-	}
-	s := &DBSessionState{
-		Conn:          conn,
-		CurrentSchema: "public",
+	s := &DBSessionState{}
+	if def, ok := sessions[DefaultSessionID]; ok && def.Conn != nil {
+		if conn, ok := client.DefaultManager().Retain(def.ConnectionName); ok {
+			s.ConnectionName = def.ConnectionName
+			s.Conn = conn
+			s.Dialect = def.Dialect
+			s.ReadOnly = def.ReadOnly
+			s.CurrentSchema = def.CurrentSchema
+			s.Settings = def.Settings
+		}
 	}
-	sessions[id] = s
-	return s
+	sessions[sessionID] = s
+	return s, true
 }
 
 func GetSession(sessionID string) *DBSessionState {
@@ -65,11 +70,63 @@ func GetSession(sessionID string) *DBSessionState {
 	return sessions[sessionID]
 }
 
+// Snapshot returns a copy of the session's connection fields taken under the
+// lock, so a tool call keeps one consistent connection even when
+// switch_connection runs meanwhile. Cursor methods on the copy act on the
+// session itself.
+func (s *DBSessionState) Snapshot() *DBSessionState {
+	mu.RLock()
+	defer mu.RUnlock()
+	return &DBSessionState{
+		ConnectionName: s.ConnectionName,
+		Conn:           s.Conn,
+		Dialect:        s.Dialect,
+		ReadOnly:       s.ReadOnly,
+		CurrentSchema:  s.CurrentSchema,
+		Settings:       s.Settings,
+		live:           s.session(),
+	}
+}
+
+// session returns the session s was taken from, or s itself.
+func (s *DBSessionState) session() *DBSessionState {
+	if s.live != nil {
+		return s.live
+	}
+	return s
+}
+
+// SetConnection points the session at a pool the caller acquired from the
+// connection manager, releasing the session's previous pool.
+func (s *DBSessionState) SetConnection(name string, conn *sql.DB, d dialect.Dialect, readOnly bool, settings config.Settings, currentSchema string) {
+	s = s.session()
+	mu.Lock()
+	cursors := s.detachCursorsLocked()
+	defer closeCursors(cursors)
+	defer mu.Unlock()
+
+	if s.Conn != nil {
+		client.DefaultManager().Release(s.ConnectionName)
+	}
+	s.ConnectionName = name
+	s.Conn = conn
+	s.Dialect = d
+	s.ReadOnly = readOnly
+	s.CurrentSchema = currentSchema
+	s.Settings = settings
+}
+
 func CloseSession(sessionID string) {
+	var cursors []*HeldCursor
+	defer func() { closeCursors(cursors) }()
+
 	mu.Lock()
 	defer mu.Unlock()
 	if s, ok := sessions[sessionID]; ok {
-		s.Conn.Close()
+		cursors = s.detachCursorsLocked()
+		if s.Conn != nil {
+			client.DefaultManager().Release(s.ConnectionName)
+		}
 		delete(sessions, sessionID)
 	}
 }
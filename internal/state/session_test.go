@@ -0,0 +1,51 @@
+package state
+
+import (
+	"testing"
+
+	"github.com/AbdelilahOu/DBMcp/internal/config"
+	"github.com/AbdelilahOu/DBMcp/internal/dialect"
+)
+
+func TestSnapshot(t *testing.T) {
+	db := openTestDB(t)
+	s := &DBSessionState{ConnectionName: t.Name(), Dialect: dialect.SQLite{}, CurrentSchema: "main", Settings: config.Settings{MaxConnections: 20}}
+	defer closeSessionCursors(s)
+
+	snap := s.Snapshot()
+	s.SetConnection(t.Name()+"-other", nil, dialect.Postgres{}, true, config.Settings{}, "public")
+	if snap.ConnectionName != t.Name() || snap.Dialect.Name() != "sqlite" || snap.ReadOnly || snap.CurrentSchema != "main" {
+		t.Errorf("snapshot = %+v, changed with the session", snap)
+	}
+
+	// A cursor opened on the connection the session has left is closed
+	// rather than stored under the new one.
+	stale, err := openTestCursor(t, snap, db)
+	if err != nil {
+		t.Fatalf("OpenCursor: %v", err)
+	}
+	if _, err := snap.PutCursor(stale); err == nil {
+		t.Error("PutCursor stored a cursor from the previous connection")
+	}
+	if n := poolCursors(t.Name()); n != 0 {
+		t.Errorf("previous pool holds %d cursors, want 0", n)
+	}
+	if got := db.Stats().InUse; got != 0 {
+		t.Errorf("%d connections still in use after the stale cursor", got)
+	}
+
+	// Cursors stored through a snapshot belong to the session.
+	snap = s.Snapshot()
+	c, err := openTestCursor(t, snap, db)
+	if err != nil {
+		t.Fatalf("OpenCursor: %v", err)
+	}
+	id := putTestCursor(t, snap, c)
+	if _, ok := s.Snapshot().TakeCursor(id); !ok {
+		t.Fatal("a cursor put through one snapshot is missing from the next")
+	}
+	c.Close()
+	if n := poolCursors(snap.ConnectionName); n != 0 {
+		t.Errorf("pool holds %d cursors after close, want 0", n)
+	}
+}
@@ -0,0 +1,311 @@
+package tools
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/AbdelilahOu/DBMcp/internal/config"
+	"github.com/AbdelilahOu/DBMcp/internal/logger"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+type ActiveSessionsInput struct {
+	IncludeIdle   bool    `json:"include_idle,omitempty" jsonschema_description:"Also list idle sessions; idle sessions that block others are always listed"`
+	MinDurationMs float64 `json:"min_duration_ms,omitempty" jsonschema_description:"Only list sessions whose query has run at least this long; blocked and blocking sessions are always listed"`
+	TimeoutMs     int     `json:"timeout_ms,omitempty" jsonschema_description:"Optional timeout in milliseconds, capped by the connection's max_query_timeout"`
+}
+
+type ActiveSession struct {
+	ID            int64    `json:"id" jsonschema_description:"Session ID: the backend pid on Postgres, the processlist id on MySQL"`
+	User          string   `json:"user" jsonschema_description:"Database user"`
+	Database      string   `json:"database" jsonschema_description:"Database the session is connected to"`
+	ClientAddress string   `json:"client_address" jsonschema_description:"Client address; empty for local socket connections on Postgres"`
+	Application   string   `json:"application,omitempty" jsonschema_description:"Application name the client reported (Postgres)"`
+	State         string   `json:"state" jsonschema_description:"Session state (Postgres) or command (MySQL)"`
+	Query         string   `json:"query" jsonschema_description:"Current query, or the last one of an idle Postgres session"`
+	DurationMs    *float64 `json:"duration_ms,omitempty" jsonschema_description:"How long the query has been running, in milliseconds"`
+	WaitEvent     string   `json:"wait_event,omitempty" jsonschema_description:"What the session is waiting on: the wait event on Postgres, the thread state on MySQL"`
+	WaitingFor    string   `json:"waiting_for,omitempty" jsonschema_description:"Lock the session is waiting to acquire"`
+	BlockedBy     []int64  `json:"blocked_by,omitempty" jsonschema_description:"IDs of the sessions holding the locks this one waits on"`
+	Blocking      []int64  `json:"blocking,omitempty" jsonschema_description:"IDs of the sessions waiting on this one's locks"`
+}
+
+// BlockingEntry is a line of the blocking tree. The tree is flattened depth
+// first: each entry follows the session blocking it, one level deeper.
+type BlockingEntry struct {
+	ID         int64    `json:"id" jsonschema_description:"Session ID"`
+	BlockedBy  int64    `json:"blocked_by,omitempty" jsonschema_description:"ID of the session above it in the tree; absent at the root"`
+	Depth      int      `json:"depth" jsonschema_description:"Level in the tree, 0 for sessions that block others without being blocked"`
+	Query      string   `json:"query" jsonschema_description:"Current query of the session"`
+	DurationMs *float64 `json:"duration_ms,omitempty" jsonschema_description:"How long the query has been running, in milliseconds"`
+}
+
+type ActiveSessionsOutput struct {
+	Sessions     []ActiveSession `json:"sessions" jsonschema_description:"Sessions, longest-running first"`
+	BlockingTree []BlockingEntry `json:"blocking_tree" jsonschema_description:"Who blocks whom, flattened depth first"`
+	Message      string          `json:"message" jsonschema_description:"Summary message"`
+}
+
+func GetActiveSessionsTool() *ToolDefinition[ActiveSessionsInput, ActiveSessionsOutput] {
+	return NewToolDefinition[ActiveSessionsInput, ActiveSessionsOutput](
+		"active_sessions",
+		"List the sessions on the database server (Postgres, MySQL) with their running query, duration, wait events, client address and the locks they wait on, plus a tree of which sessions block which.",
+		func(ctx context.Context, req *mcp.CallToolRequest, input ActiveSessionsInput) (*mcp.CallToolResult, ActiveSessionsOutput, error) {
+			return activeSessionsHandler(ctx, req, input)
+		},
+	)
+}
+
+func activeSessionsHandler(ctx context.Context, req *mcp.CallToolRequest, input ActiveSessionsInput) (*mcp.CallToolResult, ActiveSessionsOutput, error) {
+	sessionState, err := getActiveSession(req)
+	if err != nil {
+		return nil, ActiveSessionsOutput{}, err
+	}
+	d := sessionState.Dialect
+	if input.MinDurationMs < 0 {
+		return nil, ActiveSessionsOutput{}, fmt.Errorf("min_duration_ms must not be negative")
+	}
+
+	query := d.SessionsQuery()
+	if query == "" {
+		return nil, ActiveSessionsOutput{}, fmt.Errorf("%s has no server sessions to list", d.Name())
+	}
+
+	ctx, cancel, err := queryContext(ctx, sessionState, input.TimeoutMs)
+	if err != nil {
+		return nil, ActiveSessionsOutput{}, err
+	}
+	defer cancel()
+
+	rows, err := sessionState.Conn.QueryContext(ctx, query)
+	if err != nil {
+		logger.LogDatabaseOperation("ACTIVE_SESSIONS", query, 0, err)
+		return nil, ActiveSessionsOutput{}, fmt.Errorf("failed to list sessions: %v", err)
+	}
+	defer rows.Close()
+
+	var all []ActiveSession
+	idle := map[int64]bool{}
+	for rows.Next() {
+		var s ActiveSession
+		var duration sql.NullFloat64
+		var waitEvent, waitingFor, blockedBy sql.NullString
+		var isIdle bool
+		if err := rows.Scan(&s.ID, &s.User, &s.Database, &s.ClientAddress, &s.Application, &s.State, &s.Query,
+			&duration, &waitEvent, &waitingFor, &blockedBy, &isIdle); err != nil {
+			return nil, ActiveSessionsOutput{}, fmt.Errorf("scan error: %v", err)
+		}
+		if duration.Valid {
+			s.DurationMs = &duration.Float64
+		}
+		s.WaitEvent, s.WaitingFor = waitEvent.String, waitingFor.String
+		for _, id := range strings.Split(blockedBy.String, ",") {
+			if blocker, err := strconv.ParseInt(strings.TrimSpace(id), 10, 64); err == nil {
+				s.BlockedBy = append(s.BlockedBy, blocker)
+			}
+		}
+		idle[s.ID] = isIdle
+		all = append(all, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, ActiveSessionsOutput{}, fmt.Errorf("rows iteration error: %v", err)
+	}
+	logger.LogDatabaseOperation("ACTIVE_SESSIONS", query, int64(len(all)), nil)
+
+	blocking := map[int64][]int64{}
+	for _, s := range all {
+		for _, blocker := range s.BlockedBy {
+			blocking[blocker] = append(blocking[blocker], s.ID)
+		}
+	}
+
+	sessions := make([]ActiveSession, 0, len(all))
+	blocked := 0
+	for _, s := range all {
+		s.Blocking = blocking[s.ID]
+		if len(s.BlockedBy) > 0 {
+			blocked++
+		}
+		involved := len(s.BlockedBy) > 0 || len(s.Blocking) > 0
+		if !involved && idle[s.ID] && !input.IncludeIdle {
+			continue
+		}
+		if !involved && input.MinDurationMs > 0 && (s.DurationMs == nil || *s.DurationMs < input.MinDurationMs) {
+			continue
+		}
+		sessions = append(sessions, s)
+	}
+	sort.SliceStable(sessions, func(i, j int) bool {
+		a, b := sessions[i].DurationMs, sessions[j].DurationMs
+		return a != nil && (b == nil || *a > *b)
+	})
+
+	output := ActiveSessionsOutput{
+		Sessions:     sessions,
+		BlockingTree: blockingTree(all, blocking),
+		Message:      fmt.Sprintf("%d sessions listed", len(sessions)),
+	}
+	if blocked > 0 {
+		output.Message += fmt.Sprintf("; %d waiting on locks held by other sessions", blocked)
+	}
+
+	jsonBytes, err := json.Marshal(output)
+	if err != nil {
+		return nil, ActiveSessionsOutput{}, fmt.Errorf("JSON marshal error: %v", err)
+	}
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: string(jsonBytes)},
+		},
+	}, output, nil
+}
+
+// blockingTree walks from the sessions that block others without being
+// blocked themselves. A session blocked by several others appears under
+// each. Sessions only blocked in a cycle are walked from the lowest ID.
+func blockingTree(sessions []ActiveSession, blocking map[int64][]int64) []BlockingEntry {
+	byID := map[int64]ActiveSession{}
+	for _, s := range sessions {
+		byID[s.ID] = s
+	}
+
+	var roots []int64
+	for id := range blocking {
+		if len(byID[id].BlockedBy) == 0 {
+			roots = append(roots, id)
+		}
+	}
+	sort.Slice(roots, func(i, j int) bool { return roots[i] < roots[j] })
+
+	tree := make([]BlockingEntry, 0)
+	seen := map[int64]bool{}
+	var walk func(id, parent int64, depth int, path map[int64]bool)
+	walk = func(id, parent int64, depth int, path map[int64]bool) {
+		s := byID[id]
+		tree = append(tree, BlockingEntry{ID: id, BlockedBy: parent, Depth: depth, Query: s.Query, DurationMs: s.DurationMs})
+		seen[id] = true
+		path[id] = true
+		defer delete(path, id)
+		for _, child := range blocking[id] {
+			if !path[child] {
+				walk(child, id, depth+1, path)
+			}
+		}
+	}
+	for _, root := range roots {
+		walk(root, 0, 0, map[int64]bool{})
+	}
+
+	var rest []int64
+	for id := range blocking {
+		if !seen[id] {
+			rest = append(rest, id)
+		}
+	}
+	sort.Slice(rest, func(i, j int) bool { return rest[i] < rest[j] })
+	for _, id := range rest {
+		if !seen[id] {
+			walk(id, 0, 0, map[int64]bool{})
+		}
+	}
+	return tree
+}
+
+type CancelBackendInput struct {
+	SessionID int64 `json:"session_id" jsonschema:"required" jsonschema_description:"ID of the session, as active_sessions lists it"`
+	Terminate bool  `json:"terminate,omitempty" jsonschema_description:"End the whole session instead of cancelling its running query; its open transaction is rolled back"`
+}
+
+type CancelBackendOutput struct {
+	SessionID  int64  `json:"session_id" jsonschema_description:"ID of the session"`
+	Terminated bool   `json:"terminated" jsonschema_description:"Whether the session was ended rather than its query cancelled"`
+	Message    string `json:"message" jsonschema_description:"Summary message"`
+}
+
+func GetCancelBackendTool(cfg *config.Config) *ToolDefinition[CancelBackendInput, CancelBackendOutput] {
+	return NewToolDefinition[CancelBackendInput, CancelBackendOutput](
+		"cancel_backend",
+		"Cancel the running query of a database session, or terminate the session (Postgres, MySQL). Only allowed on connections configured with admin that are not read-only.",
+		func(ctx context.Context, req *mcp.CallToolRequest, input CancelBackendInput) (*mcp.CallToolResult, CancelBackendOutput, error) {
+			return cancelBackendHandler(ctx, req, input, cfg)
+		},
+	)
+}
+
+func cancelBackendHandler(ctx context.Context, req *mcp.CallToolRequest, input CancelBackendInput, cfg *config.Config) (*mcp.CallToolResult, CancelBackendOutput, error) {
+	sessionState, err := getActiveSession(req)
+	if err != nil {
+		return nil, CancelBackendOutput{}, err
+	}
+	d := sessionState.Dialect
+
+	if err := requireAdmin(cfg, sessionState); err != nil {
+		return nil, CancelBackendOutput{}, err
+	}
+	// Ending a session rolls back other clients' work, so read-only wins
+	// over admin.
+	if sessionState.ReadOnly {
+		return nil, CancelBackendOutput{}, fmt.Errorf("the active connection is read-only; cancel_backend is disabled")
+	}
+	if input.SessionID <= 0 {
+		return nil, CancelBackendOutput{}, fmt.Errorf("session_id must be positive")
+	}
+	query := d.CancelSessionQuery(input.SessionID, input.Terminate)
+	if query == "" {
+		return nil, CancelBackendOutput{}, fmt.Errorf("%s has no server sessions to cancel", d.Name())
+	}
+
+	ctx, cancel, err := queryContext(ctx, sessionState, 0)
+	if err != nil {
+		return nil, CancelBackendOutput{}, err
+	}
+	defer cancel()
+
+	rows, err := sessionState.Conn.QueryContext(ctx, query)
+	if err != nil {
+		logger.LogDatabaseOperation("CANCEL_BACKEND", query, 0, err)
+		return nil, CancelBackendOutput{}, fmt.Errorf("failed to signal session %d: %v", input.SessionID, err)
+	}
+	defer rows.Close()
+	signalled := true
+	if rows.Next() {
+		if err := rows.Scan(&signalled); err != nil {
+			return nil, CancelBackendOutput{}, fmt.Errorf("scan error: %v", err)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, CancelBackendOutput{}, fmt.Errorf("rows iteration error: %v", err)
+	}
+	if !signalled {
+		err := fmt.Errorf("session %d could not be signalled; it may have already ended", input.SessionID)
+		logger.LogDatabaseOperation("CANCEL_BACKEND", query, 0, err)
+		return nil, CancelBackendOutput{}, err
+	}
+	logger.LogDatabaseOperation("CANCEL_BACKEND", query, 1, nil)
+
+	output := CancelBackendOutput{
+		SessionID:  input.SessionID,
+		Terminated: input.Terminate,
+		Message:    fmt.Sprintf("Cancelled the running query of session %d", input.SessionID),
+	}
+	if input.Terminate {
+		output.Message = fmt.Sprintf("Terminated session %d", input.SessionID)
+	}
+
+	jsonBytes, err := json.Marshal(output)
+	if err != nil {
+		return nil, CancelBackendOutput{}, fmt.Errorf("JSON marshal error: %v", err)
+	}
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: string(jsonBytes)},
+		},
+	}, output, nil
+}
@@ -4,10 +4,14 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"time"
 
+	"github.com/AbdelilahOu/DBMcp/internal/catalog"
 	"github.com/AbdelilahOu/DBMcp/internal/client"
 	"github.com/AbdelilahOu/DBMcp/internal/config"
+	"github.com/AbdelilahOu/DBMcp/internal/dialect"
 	"github.com/AbdelilahOu/DBMcp/internal/logger"
+	"github.com/AbdelilahOu/DBMcp/internal/snapshot"
 	"github.com/AbdelilahOu/DBMcp/internal/state"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
@@ -18,8 +22,9 @@ type ListConnectionsInput struct{}
 type ConnectionInfo struct {
 	Name        string `json:"name" jsonschema_description:"Connection name"`
 	DisplayName string `json:"display_name" jsonschema_description:"Human-readable connection name"`
-	Type        string `json:"type" jsonschema_description:"Database type (postgres, mysql)"`
+	Type        string `json:"type" jsonschema_description:"Database type (postgres, mysql, sqlite)"`
 	Description string `json:"description" jsonschema_description:"Connection description"`
+	ReadOnly    bool   `json:"read_only" jsonschema_description:"Whether writes are refused on this connection"`
 }
 
 type ListConnectionsOutput struct {
@@ -34,6 +39,7 @@ type SwitchConnectionInput struct {
 type SwitchConnectionOutput struct {
 	Message    string `json:"message" jsonschema_description:"Success message"`
 	Connection string `json:"connection" jsonschema_description:"Active connection name"`
+	ReadOnly   bool   `json:"read_only" jsonschema_description:"Whether writes are refused on this connection"`
 }
 
 type TestConnectionInput struct {
@@ -46,6 +52,26 @@ type TestConnectionOutput struct {
 	Connection string `json:"connection" jsonschema_description:"Connection that was tested"`
 }
 
+type ConnectionStatusInput struct{}
+
+type PoolStatus struct {
+	Connection     string `json:"connection" jsonschema_description:"Connection name"`
+	Driver         string `json:"driver" jsonschema_description:"Database driver"`
+	Sessions       int    `json:"sessions" jsonschema_description:"Number of sessions using the pool"`
+	MaxOpen        int    `json:"max_open" jsonschema_description:"Maximum open connections (0 means unlimited)"`
+	Open           int    `json:"open" jsonschema_description:"Open connections, in use or idle"`
+	InUse          int    `json:"in_use" jsonschema_description:"Connections currently in use"`
+	Idle           int    `json:"idle" jsonschema_description:"Idle connections"`
+	WaitCount      int64  `json:"wait_count" jsonschema_description:"Total number of waits for a free connection"`
+	WaitDurationMs int64  `json:"wait_duration_ms" jsonschema_description:"Total time spent waiting for a free connection"`
+	IdleSince      string `json:"idle_since,omitempty" jsonschema_description:"When the last session released the pool (RFC3339), if unused"`
+}
+
+type ConnectionStatusOutput struct {
+	ActiveConnection string       `json:"active_connection" jsonschema_description:"Connection used by this session"`
+	Pools            []PoolStatus `json:"pools" jsonschema_description:"Open connection pools"`
+}
+
 func GetListConnectionsTool(cfg *config.Config) *ToolDefinition[ListConnectionsInput, ListConnectionsOutput] {
 	return NewToolDefinition[ListConnectionsInput, ListConnectionsOutput](
 		"list_connections",
@@ -69,6 +95,7 @@ func listConnectionsHandler(ctx context.Context, req *mcp.CallToolRequest, input
 			DisplayName: conn.Name,
 			Type:        conn.Type,
 			Description: conn.Description,
+			ReadOnly:    cfg.IsReadOnly(conn),
 		})
 	}
 
@@ -109,27 +136,23 @@ func switchConnectionHandler(ctx context.Context, req *mcp.CallToolRequest, inpu
 		return nil, SwitchConnectionOutput{}, fmt.Errorf("connection '%s' not found", input.Connection)
 	}
 
-	dbClient, err := client.NewDBClient(conn.URL, conn.Type)
-	if err != nil {
+	sessionState := sessionFor(req)
+	if err := ConnectSession(ctx, sessionState, input.Connection, conn, cfg.IsReadOnly(conn), cfg.SettingsFor(conn)); err != nil {
 		logger.LogConnectionEvent("switch_connection", input.Connection, conn.Type, err)
-		return nil, SwitchConnectionOutput{}, fmt.Errorf("failed to connect to '%s': %v", input.Connection, err)
-	}
-
-	sessionID := "default"
-	sessionState := state.GetOrCreateSession(sessionID, dbClient)
-	if sessionState == nil {
-		logger.LogConnectionEvent("switch_connection", input.Connection, conn.Type, fmt.Errorf("failed to create session"))
-		return nil, SwitchConnectionOutput{}, fmt.Errorf("failed to create session")
+		return nil, SwitchConnectionOutput{}, err
 	}
 
-	sessionState.Conn = dbClient.DB
-
 	// Log successful connection switch
 	logger.LogConnectionEvent("switch_connection", input.Connection, conn.Type, nil)
 
+	if cfg.Snapshots.Dir != "" && cfg.Snapshots.OnConnect {
+		autoSnapshot(snapshot.NewStore(cfg.Snapshots.Dir), input.Connection, sessionState.Settings, sessionState.Dialect, sessionState.CurrentSchema)
+	}
+
 	output := SwitchConnectionOutput{
 		Message:    fmt.Sprintf("Successfully switched to connection '%s'", input.Connection),
 		Connection: input.Connection,
+		ReadOnly:   sessionState.ReadOnly,
 	}
 
 	jsonBytes, err := json.Marshal(output)
@@ -144,6 +167,34 @@ func switchConnectionHandler(ctx context.Context, req *mcp.CallToolRequest, inpu
 	}, output, nil
 }
 
+// ConnectSession acquires the pool for the named connection and makes it the
+// session's active one.
+func ConnectSession(ctx context.Context, sessionState *state.DBSessionState, name string, conn config.Connection, readOnly bool, settings config.Settings) error {
+	d, err := dialect.New(conn.Type)
+	if err != nil {
+		return err
+	}
+
+	db, err := client.DefaultManager().Acquire(ctx, name, conn.URL, d.DriverName(), poolOptions(settings))
+	if err != nil {
+		return fmt.Errorf("failed to connect to '%s': %v", name, err)
+	}
+
+	// Not every connection has a current schema (MySQL without a database
+	// in the URL); tools then ask for one explicitly.
+	currentSchema, _ := catalog.CurrentSchema(ctx, db, d)
+
+	sessionState.SetConnection(name, db, d, readOnly, settings, currentSchema)
+	return nil
+}
+
+func poolOptions(settings config.Settings) client.PoolOptions {
+	return client.PoolOptions{
+		MaxOpenConns:    settings.MaxConnections,
+		ConnMaxLifetime: time.Duration(settings.ConnectionLifetime),
+	}
+}
+
 func GetTestConnectionTool(cfg *config.Config) *ToolDefinition[TestConnectionInput, TestConnectionOutput] {
 	return NewToolDefinition[TestConnectionInput, TestConnectionOutput](
 		"test_connection",
@@ -169,7 +220,12 @@ func testConnectionHandler(ctx context.Context, req *mcp.CallToolRequest, input
 			return nil, TestConnectionOutput{}, fmt.Errorf("connection '%s' not found", input.Connection)
 		}
 
-		testClient, err = client.NewDBClient(conn.URL, conn.Type)
+		d, err := dialect.New(conn.Type)
+		if err != nil {
+			return nil, TestConnectionOutput{}, err
+		}
+
+		testClient, err = client.NewDBClient(ctx, conn.URL, d.DriverName(), poolOptions(cfg.SettingsFor(conn)))
 		if err != nil {
 			logger.LogConnectionEvent("test_connection", input.Connection, conn.Type, err)
 			output := TestConnectionOutput{
@@ -189,8 +245,8 @@ func testConnectionHandler(ctx context.Context, req *mcp.CallToolRequest, input
 
 		connectionName = input.Connection
 	} else {
-		sessionState := state.GetSession("default")
-		if sessionState == nil || sessionState.Conn == nil {
+		sessionState := sessionFor(req).Snapshot()
+		if sessionState.Conn == nil {
 			output := TestConnectionOutput{
 				Success:    false,
 				Message:    "No active connection to test",
@@ -205,12 +261,12 @@ func testConnectionHandler(ctx context.Context, req *mcp.CallToolRequest, input
 			}, output, nil
 		}
 
-		if err := sessionState.Conn.Ping(); err != nil {
-			logger.LogConnectionEvent("test_connection", "current", "unknown", err)
+		if err := sessionState.Conn.PingContext(ctx); err != nil {
+			logger.LogConnectionEvent("test_connection", sessionState.ConnectionName, sessionState.Dialect.Name(), err)
 			output := TestConnectionOutput{
 				Success:    false,
 				Message:    fmt.Sprintf("Connection test failed: %v", err),
-				Connection: "current",
+				Connection: sessionState.ConnectionName,
 			}
 
 			jsonBytes, _ := json.Marshal(output)
@@ -221,7 +277,7 @@ func testConnectionHandler(ctx context.Context, req *mcp.CallToolRequest, input
 			}, output, nil
 		}
 
-		connectionName = "current"
+		connectionName = sessionState.ConnectionName
 	}
 
 	// Log successful connection test
@@ -244,3 +300,51 @@ func testConnectionHandler(ctx context.Context, req *mcp.CallToolRequest, input
 		},
 	}, output, nil
 }
+
+func GetConnectionStatusTool() *ToolDefinition[ConnectionStatusInput, ConnectionStatusOutput] {
+	return NewToolDefinition[ConnectionStatusInput, ConnectionStatusOutput](
+		"connection_status",
+		"Show open connection pools with their session count and connection usage.",
+		connectionStatusHandler,
+	)
+}
+
+func connectionStatusHandler(ctx context.Context, req *mcp.CallToolRequest, input ConnectionStatusInput) (*mcp.CallToolResult, ConnectionStatusOutput, error) {
+	sessionState := sessionFor(req).Snapshot()
+
+	stats := client.DefaultManager().Stats()
+	pools := make([]PoolStatus, 0, len(stats))
+	for _, s := range stats {
+		pool := PoolStatus{
+			Connection:     s.Name,
+			Driver:         s.Driver,
+			Sessions:       s.Sessions,
+			MaxOpen:        s.MaxOpen,
+			Open:           s.OpenConnections,
+			InUse:          s.InUse,
+			Idle:           s.Idle,
+			WaitCount:      s.WaitCount,
+			WaitDurationMs: s.WaitDuration.Milliseconds(),
+		}
+		if !s.IdleSince.IsZero() {
+			pool.IdleSince = s.IdleSince.Format(time.RFC3339)
+		}
+		pools = append(pools, pool)
+	}
+
+	output := ConnectionStatusOutput{
+		ActiveConnection: sessionState.ConnectionName,
+		Pools:            pools,
+	}
+
+	jsonBytes, err := json.Marshal(output)
+	if err != nil {
+		return nil, ConnectionStatusOutput{}, fmt.Errorf("JSON marshal error: %v", err)
+	}
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: string(jsonBytes)},
+		},
+	}, output, nil
+}
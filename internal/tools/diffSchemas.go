@@ -0,0 +1,143 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/AbdelilahOu/DBMcp/internal/catalog"
+	"github.com/AbdelilahOu/DBMcp/internal/client"
+	"github.com/AbdelilahOu/DBMcp/internal/config"
+	"github.com/AbdelilahOu/DBMcp/internal/dialect"
+	"github.com/AbdelilahOu/DBMcp/internal/logger"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+type DiffSchemasInput struct {
+	Source     string `json:"source" jsonschema:"required" jsonschema_description:"Connection name from the config file holding the reference schema"`
+	Target     string `json:"target" jsonschema:"required" jsonschema_description:"Connection name from the config file holding the schema to compare"`
+	Schema     string `json:"schema,omitempty" jsonschema_description:"Optional schema name to compare on both sides (defaults to each connection's current schema)"`
+	IncludeDDL bool   `json:"include_ddl,omitempty" jsonschema_description:"Also return DDL that brings the target in line with the source (both connections must be the same database type)"`
+	TimeoutMs  int    `json:"timeout_ms,omitempty" jsonschema_description:"Optional timeout in milliseconds for reading each schema, capped by the connection's max_query_timeout"`
+}
+
+type DiffSchemasOutput struct {
+	Source       string       `json:"source" jsonschema_description:"Source connection name"`
+	SourceSchema string       `json:"source_schema" jsonschema_description:"Schema read on the source"`
+	Target       string       `json:"target" jsonschema_description:"Target connection name"`
+	TargetSchema string       `json:"target_schema" jsonschema_description:"Schema read on the target"`
+	Identical    bool         `json:"identical" jsonschema_description:"Whether no differences were found"`
+	Diff         catalog.Diff `json:"diff" jsonschema_description:"Differences, from the source's point of view"`
+	DDL          string       `json:"ddl,omitempty" jsonschema_description:"Statements that bring the target in line with the source, when include_ddl is set; changes the database cannot make in place are SQL comments"`
+	Message      string       `json:"message" jsonschema_description:"Summary message"`
+}
+
+func GetDiffSchemasTool(cfg *config.Config) *ToolDefinition[DiffSchemasInput, DiffSchemasOutput] {
+	return NewToolDefinition[DiffSchemasInput, DiffSchemasOutput](
+		"diff_schemas",
+		"Compare the schemas of two configured connections: tables, columns (type, nullability, default), indexes, constraints, views and routines. Optionally returns the DDL that brings the target in line with the source. Does not need an active connection.",
+		func(ctx context.Context, req *mcp.CallToolRequest, input DiffSchemasInput) (*mcp.CallToolResult, DiffSchemasOutput, error) {
+			return diffSchemasHandler(ctx, req, input, cfg)
+		},
+	)
+}
+
+func diffSchemasHandler(ctx context.Context, req *mcp.CallToolRequest, input DiffSchemasInput, cfg *config.Config) (*mcp.CallToolResult, DiffSchemasOutput, error) {
+	output, err := DiffSchemas(ctx, cfg, input)
+	if err != nil {
+		return nil, DiffSchemasOutput{}, err
+	}
+
+	jsonBytes, err := json.Marshal(output)
+	if err != nil {
+		return nil, DiffSchemasOutput{}, fmt.Errorf("JSON marshal error: %v", err)
+	}
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: string(jsonBytes)},
+		},
+	}, output, nil
+}
+
+// DiffSchemas reads the schemas of two configured connections and compares
+// them. It backs both the diff_schemas tool and the diff command.
+func DiffSchemas(ctx context.Context, cfg *config.Config, input DiffSchemasInput) (DiffSchemasOutput, error) {
+	if cfg == nil {
+		return DiffSchemasOutput{}, fmt.Errorf("config not loaded - server must be started with a valid config file")
+	}
+
+	source, d, err := loadConnectionSchema(ctx, cfg, input.Source, input.Schema, input.TimeoutMs)
+	if err != nil {
+		return DiffSchemasOutput{}, err
+	}
+	target, _, err := loadConnectionSchema(ctx, cfg, input.Target, input.Schema, input.TimeoutMs)
+	if err != nil {
+		return DiffSchemasOutput{}, err
+	}
+
+	diff := catalog.Compare(source, target)
+	output := DiffSchemasOutput{
+		Source:       input.Source,
+		SourceSchema: source.Name,
+		Target:       input.Target,
+		TargetSchema: target.Name,
+		Identical:    diff.Empty(),
+		Diff:         *diff,
+	}
+
+	if input.IncludeDDL {
+		if output.DDL, err = catalog.DDL(d, source, target); err != nil {
+			return DiffSchemasOutput{}, err
+		}
+	}
+
+	if output.Identical {
+		output.Message = fmt.Sprintf("%s.%s and %s.%s are identical", input.Source, source.Name, input.Target, target.Name)
+	} else {
+		output.Message = fmt.Sprintf("%s.%s and %s.%s differ in %d tables, %d views and %d routines",
+			input.Source, source.Name, input.Target, target.Name, len(diff.Tables), len(diff.Views), len(diff.Routines))
+	}
+	logger.LogDatabaseOperation("DIFF_SCHEMAS", input.Source+" -> "+input.Target, int64(len(diff.Tables)+len(diff.Views)+len(diff.Routines)), nil)
+
+	return output, nil
+}
+
+// loadConnectionSchema reads schema, or the current schema when it is
+// empty, through the shared pool of the named connection.
+func loadConnectionSchema(ctx context.Context, cfg *config.Config, name, schema string, timeoutMs int) (*catalog.Schema, dialect.Dialect, error) {
+	conn, exists := cfg.GetConnection(name)
+	if !exists {
+		return nil, nil, fmt.Errorf("connection '%s' not found", name)
+	}
+	d, err := dialect.New(conn.Type)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	settings := cfg.SettingsFor(conn)
+	ctx, cancel, err := timeoutContext(ctx, settings, timeoutMs)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer cancel()
+
+	db, err := client.DefaultManager().Acquire(ctx, name, conn.URL, d.DriverName(), poolOptions(settings))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to connect to '%s': %v", name, err)
+	}
+	defer client.DefaultManager().Release(name)
+
+	if schema == "" {
+		if schema, err = catalog.CurrentSchema(ctx, db, d); err != nil {
+			return nil, nil, fmt.Errorf("%s: %v", name, err)
+		}
+	}
+
+	s, err := catalog.Load(ctx, db, d, schema)
+	if err != nil {
+		logger.LogDatabaseOperation("DIFF_SCHEMAS", name+"."+schema, 0, err)
+		return nil, nil, fmt.Errorf("%s: %v", name, err)
+	}
+	return s, d, nil
+}
@@ -4,15 +4,17 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"strings"
-	"time"
 
+	"github.com/AbdelilahOu/DBMcp/internal/classifier"
 	"github.com/AbdelilahOu/DBMcp/internal/logger"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
 
 type ExecuteQueryInput struct {
-	Query string `json:"query" jsonschema:"required" jsonschema_description:"SQL query to execute (INSERT, UPDATE, DELETE, etc.)"`
+	Query       string                 `json:"query" jsonschema:"required" jsonschema_description:"SQL query to execute (INSERT, UPDATE, DELETE, etc.)"`
+	Params      []interface{}          `json:"params,omitempty" jsonschema_description:"Optional positional bind values for the dialect's placeholders ($1 on Postgres, ? on MySQL, ?1 on SQLite). Numbers, strings, booleans and null are passed as is; use {\"type\": \"timestamp\", \"value\": \"<ISO 8601>\"}, {\"type\": \"bytes\", \"value\": \"<base64>\"} or {\"type\": \"int\", \"value\": \"<digits>\"} for other types"`
+	NamedParams map[string]interface{} `json:"named_params,omitempty" jsonschema_description:"Optional bind values for :name placeholders, in the same forms as params. Cannot be combined with params"`
+	TimeoutMs   int                    `json:"timeout_ms,omitempty" jsonschema_description:"Optional timeout in milliseconds, capped by the connection's max_query_timeout"`
 }
 
 type ExecuteQueryOutput struct {
@@ -23,7 +25,7 @@ type ExecuteQueryOutput struct {
 func GetExecuteQueryTool() *ToolDefinition[ExecuteQueryInput, ExecuteQueryOutput] {
 	return NewToolDefinition[ExecuteQueryInput, ExecuteQueryOutput](
 		"execute_query",
-		"Execute any SQL query (INSERT, UPDATE, DELETE, etc.) with proper permissions.",
+		"Execute a single DML or DDL statement (INSERT, UPDATE, DELETE, CREATE, ALTER, etc.). Reads, DROP, TRUNCATE, permission and administrative statements are refused.",
 		func(ctx context.Context, req *mcp.CallToolRequest, input ExecuteQueryInput) (*mcp.CallToolResult, ExecuteQueryOutput, error) {
 			return executeQueryHandler(ctx, req, input)
 		},
@@ -31,51 +33,58 @@ func GetExecuteQueryTool() *ToolDefinition[ExecuteQueryInput, ExecuteQueryOutput
 }
 
 func executeQueryHandler(ctx context.Context, req *mcp.CallToolRequest, input ExecuteQueryInput) (*mcp.CallToolResult, ExecuteQueryOutput, error) {
-	sessionState, err := getActiveSession("default")
+	sessionState, err := getActiveSession(req)
 	if err != nil {
 		return nil, ExecuteQueryOutput{}, err
 	}
 
-	queryLower := strings.ToLower(strings.TrimSpace(input.Query))
-	dangerousOperations := []string{"drop database", "drop schema", "truncate"}
-	for _, dangerous := range dangerousOperations {
-		if strings.Contains(queryLower, dangerous) {
-			return nil, ExecuteQueryOutput{}, fmt.Errorf("dangerous operation detected: %s", dangerous)
-		}
+	if sessionState.ReadOnly {
+		return nil, ExecuteQueryOutput{}, fmt.Errorf("the active connection is read-only; execute_query is disabled")
 	}
 
-	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	stmt, err := classifier.ClassifySingle(sessionState.Dialect, input.Query)
+	if err != nil {
+		return nil, ExecuteQueryOutput{}, err
+	}
+	switch {
+	case stmt.Category == classifier.Read:
+		// Reads run in a read-only transaction through select_query or
+		// show_query; running them here would not.
+		return nil, ExecuteQueryOutput{}, fmt.Errorf("%s is a read statement; use select_query or show_query instead", stmt.Keyword)
+	case stmt.Category == classifier.DCL || stmt.Category == classifier.Admin:
+		return nil, ExecuteQueryOutput{}, fmt.Errorf("%s statements (%s) are not allowed through execute_query", stmt.Category, stmt.Keyword)
+	case stmt.Destructive:
+		return nil, ExecuteQueryOutput{}, fmt.Errorf("destructive operation detected: %s", stmt.Keyword)
+	}
+
+	query, args, err := bindParams(sessionState.Dialect, input.Query, input.Params, input.NamedParams)
+	if err != nil {
+		return nil, ExecuteQueryOutput{}, err
+	}
+
+	ctx, cancel, err := queryContext(ctx, sessionState, input.TimeoutMs)
+	if err != nil {
+		return nil, ExecuteQueryOutput{}, err
+	}
 	defer cancel()
 
-	result, err := sessionState.Conn.ExecContext(ctx, input.Query)
+	result, err := sessionState.Conn.ExecContext(ctx, query, args...)
 
 	if err != nil {
 		logger.LogDatabaseOperation("EXECUTE", input.Query, 0, err)
 		return nil, ExecuteQueryOutput{}, fmt.Errorf("query execution error: %v", err)
 	}
 
+	if stmt.Category == classifier.DDL {
+		invalidateForeignKeys(sessionState.ConnectionName)
+	}
+
 	rowsAffected, err := result.RowsAffected()
 	if err != nil {
 		rowsAffected = 0
 	}
 
-	var operation string
-	switch {
-	case strings.HasPrefix(queryLower, "insert"):
-		operation = "INSERT"
-	case strings.HasPrefix(queryLower, "update"):
-		operation = "UPDATE"
-	case strings.HasPrefix(queryLower, "delete"):
-		operation = "DELETE"
-	case strings.HasPrefix(queryLower, "create"):
-		operation = "CREATE"
-	case strings.HasPrefix(queryLower, "alter"):
-		operation = "ALTER"
-	case strings.HasPrefix(queryLower, "drop"):
-		operation = "DROP"
-	default:
-		operation = "QUERY"
-	}
+	operation := stmt.Keyword
 
 	// Log successful database operation
 	logger.LogDatabaseOperation(operation, input.Query, rowsAffected, nil)
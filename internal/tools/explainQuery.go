@@ -2,27 +2,38 @@ package tools
 
 import (
 	"context"
+	"database/sql"
 	"encoding/json"
 	"fmt"
 	"strings"
-	"time"
 
+	"github.com/AbdelilahOu/DBMcp/internal/classifier"
+	"github.com/AbdelilahOu/DBMcp/internal/client"
+	"github.com/AbdelilahOu/DBMcp/internal/dialect"
 	"github.com/AbdelilahOu/DBMcp/internal/logger"
+	"github.com/AbdelilahOu/DBMcp/internal/plan"
+	"github.com/AbdelilahOu/DBMcp/internal/state"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
 
 type ExplainQueryInput struct {
-	Query string `json:"query" jsonschema:"required" jsonschema_description:"SQL query to explain"`
+	Query             string  `json:"query" jsonschema:"required" jsonschema_description:"SQL query to explain"`
+	Analyze           bool    `json:"analyze,omitempty" jsonschema_description:"Run the statement to report actual row counts, timings and buffers (Postgres, MySQL 8.0.18+). It runs in a transaction that is rolled back; data-changing statements need a writable connection and are refused on MySQL"`
+	TimeoutMs         int     `json:"timeout_ms,omitempty" jsonschema_description:"Optional timeout in milliseconds, capped by the connection's max_query_timeout, or by its analyze_timeout with analyze"`
+	EstimateThreshold float64 `json:"estimate_threshold,omitempty" jsonschema_description:"Ratio between actual and estimated rows from which the summary reports an estimate miss (default 10)"`
 }
 
 type ExplainQueryOutput struct {
-	Plan string `json:"plan" jsonschema_description:"Query execution plan"`
+	Plan         string        `json:"plan" jsonschema_description:"Query execution plan"`
+	Nodes        []plan.Node   `json:"nodes,omitempty" jsonschema_description:"The plan as a tree of nodes in depth-first order, each naming its parent (Postgres and MySQL)"`
+	Summary      *plan.Summary `json:"summary,omitempty" jsonschema_description:"Hotspots of the plan: most expensive nodes, full scans of large tables, estimate misses, sorts spilling to disk and nested loops with many loops"`
+	SummaryError string        `json:"summary_error,omitempty" jsonschema_description:"Why nodes and summary are missing or incomplete, when the plan could not be read"`
 }
 
 func GetExplainQueryTool() *ToolDefinition[ExplainQueryInput, ExplainQueryOutput] {
 	return NewToolDefinition[ExplainQueryInput, ExplainQueryOutput](
 		"explain_query",
-		"Get query execution plan for performance analysis.",
+		"Get query execution plan for performance analysis. With analyze, the statement is run inside a rolled-back transaction to report actual rows and timings.",
 		func(ctx context.Context, req *mcp.CallToolRequest, input ExplainQueryInput) (*mcp.CallToolResult, ExplainQueryOutput, error) {
 			return explainQueryHandler(ctx, req, input)
 		},
@@ -31,51 +42,193 @@ func GetExplainQueryTool() *ToolDefinition[ExplainQueryInput, ExplainQueryOutput
 
 func explainQueryHandler(ctx context.Context, req *mcp.CallToolRequest, input ExplainQueryInput) (*mcp.CallToolResult, ExplainQueryOutput, error) {
 
-	sessionState, err := getActiveSession("default")
+	sessionState, err := getActiveSession(req)
 	if err != nil {
 		return nil, ExplainQueryOutput{}, err
 	}
 
-	ctx, cancel := context.WithTimeout(ctx, 15*time.Second)
-	defer cancel()
+	planText, planLines, err := explainPlan(ctx, sessionState, input.Query, input.Analyze, input.TimeoutMs)
+	if err != nil {
+		logger.LogDatabaseOperation("EXPLAIN", input.Query, 0, err)
+		return nil, ExplainQueryOutput{}, fmt.Errorf("failed to explain query: %v", err)
+	}
 
-	query := strings.TrimSpace(input.Query)
-	queryLower := strings.ToLower(query)
-	if strings.HasPrefix(queryLower, "explain") {
-		parts := strings.SplitN(query, " ", 2)
-		if len(parts) > 1 {
-			query = strings.TrimSpace(parts[1])
+	logger.LogDatabaseOperation("EXPLAIN", input.Query, int64(planLines), nil)
+
+	output := ExplainQueryOutput{
+		Plan: planText,
+	}
+	if nodes, err := plan.Parse(sessionState.Dialect.Name(), planText); err != nil {
+		output.SummaryError = err.Error()
+	} else {
+		if err := setTableRows(ctx, sessionState, nodes); err != nil {
+			output.SummaryError = fmt.Sprintf("table row estimates are not available, so full scans count the rows left after their filter: %v", err)
 		}
+		summary := plan.Summarize(nodes, plan.Options{MissFactor: input.EstimateThreshold})
+		output.Nodes = nodes
+		output.Summary = &summary
+	}
+
+	jsonBytes, err := json.Marshal(output)
+	if err != nil {
+		return nil, ExplainQueryOutput{}, fmt.Errorf("JSON marshal error: %v", err)
+	}
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: string(jsonBytes)},
+		},
+	}, output, nil
+}
+
+// explainPlan returns the plan of query as the database prints it, and the
+// number of rows it was printed in. Only a single read or DML statement is
+// explained, and without analyze it is planned inside a read-only
+// transaction, so nothing appended to it can run.
+func explainPlan(ctx context.Context, sessionState *state.DBSessionState, query string, analyze bool, timeoutMs int) (string, int, error) {
+	query, err := stripExplain(sessionState.Dialect, query, analyze)
+	if err != nil {
+		return "", 0, err
+	}
+	stmt, err := classifier.ClassifySingle(sessionState.Dialect, query)
+	if err != nil {
+		return "", 0, err
+	}
+	if stmt.Category != classifier.Read && stmt.Category != classifier.DML {
+		return "", 0, fmt.Errorf("only read and DML statements can be explained (got %s statement classified as %s)", stmt.Keyword, stmt.Category)
+	}
+	if analyze {
+		return explainAnalyze(ctx, sessionState, query, stmt, timeoutMs)
 	}
 
-	var explainQuery string
+	ctx, cancel, err := queryContext(ctx, sessionState, timeoutMs)
+	if err != nil {
+		return "", 0, err
+	}
+	defer cancel()
+
 	var plan string
+	var planLines int
+	err = client.ReadOnlyTx(ctx, sessionState.Conn, sessionState.Dialect, func(tx *sql.Tx) error {
+		rows, err := tx.QueryContext(ctx, sessionState.Dialect.ExplainQuery(query))
+		if err != nil {
+			return err
+		}
+		plan, planLines, err = readPlan(rows)
+		return err
+	})
+	return plan, planLines, err
+}
 
-	explainQuery = fmt.Sprintf("EXPLAIN (FORMAT JSON, ANALYZE false) %s", query)
-	rows, err := sessionState.Conn.QueryContext(ctx, explainQuery)
+// setTableRows looks up the size of the tables nodes scan in full when the
+// plan only says how many rows are left after the scans' filters.
+func setTableRows(ctx context.Context, sessionState *state.DBSessionState, nodes []plan.Node) error {
+	tables := plan.ScannedTables(nodes)
+	if len(tables) == 0 {
+		return nil
+	}
+	query, args := sessionState.Dialect.TableRowEstimatesQuery(tables)
+	if query == "" {
+		return nil
+	}
+
+	ctx, cancel, err := queryContext(ctx, sessionState, 0)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+
+	rows, err := sessionState.Conn.QueryContext(ctx, query, args...)
 	if err != nil {
-		explainQuery = fmt.Sprintf("EXPLAIN %s", query)
-		rows, err = sessionState.Conn.QueryContext(ctx, explainQuery)
+		return err
+	}
+	defer rows.Close()
+
+	estimates := map[string]float64{}
+	for rows.Next() {
+		var table string
+		var estimate float64
+		if err := rows.Scan(&table, &estimate); err != nil {
+			return err
+		}
+		estimates[table] = estimate
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	plan.SetTableRows(nodes, estimates)
+	return nil
+}
+
+// stripExplain drops a leading EXPLAIN and its options from query. An
+// EXPLAIN ANALYZE is refused unless the caller asked for analyze, so the
+// statement is never run by accident and the request is never lost.
+func stripExplain(d dialect.Dialect, query string, analyze bool) (string, error) {
+	stripped, asksAnalyze, err := classifier.StripExplain(d, query)
+	if err != nil {
+		return "", err
+	}
+	if asksAnalyze && !analyze {
+		return "", fmt.Errorf("the query asks for EXPLAIN ANALYZE; send the statement alone with analyze: true instead")
+	}
+	return stripped, nil
+}
+
+// explainAnalyze runs query under EXPLAIN ANALYZE in a transaction that is
+// rolled back. Reads run read-only; DML needs a writable connection and a
+// database whose rollback undoes it.
+func explainAnalyze(ctx context.Context, sessionState *state.DBSessionState, query string, stmt classifier.Statement, timeoutMs int) (string, int, error) {
+	explainQuery := sessionState.Dialect.ExplainAnalyzeQuery(query)
+	if explainQuery == "" {
+		return "", 0, fmt.Errorf("analyze is not supported on %s", sessionState.Dialect.Name())
+	}
+
+	switch {
+	case stmt.Category == classifier.Read:
+	case stmt.Category == classifier.DML && !stmt.Destructive:
+		if sessionState.ReadOnly {
+			return "", 0, fmt.Errorf("the active connection is read-only; analyze only runs read statements on it (got %s)", stmt.Keyword)
+		}
+		if !sessionState.Dialect.RollbackUndoesWrites() {
+			return "", 0, fmt.Errorf("analyze only runs read statements on %s, where a rollback does not undo writes to non-transactional tables (got %s)", sessionState.Dialect.Name(), stmt.Keyword)
+		}
+	default:
+		return "", 0, fmt.Errorf("analyze runs the statement, so only read and DML statements are allowed (got %s statement classified as %s)", stmt.Keyword, stmt.Category)
+	}
+
+	ctx, cancel, err := analyzeContext(ctx, sessionState.Settings, timeoutMs)
+	if err != nil {
+		return "", 0, err
+	}
+	defer cancel()
+
+	var plan string
+	var planLines int
+	run := func(tx *sql.Tx) error {
+		rows, err := tx.QueryContext(ctx, explainQuery)
 		if err != nil {
-			explainQuery = fmt.Sprintf("EXPLAIN FORMAT=JSON %s", query)
-			rows, err = sessionState.Conn.QueryContext(ctx, explainQuery)
-			if err != nil {
-				explainQuery = fmt.Sprintf("EXPLAIN %s", query)
-				rows, err = sessionState.Conn.QueryContext(ctx, explainQuery)
-				if err != nil {
-
-					logger.LogDatabaseOperation("EXPLAIN", input.Query, 0, err)
-					return nil, ExplainQueryOutput{}, fmt.Errorf("failed to explain query: %v", err)
-				}
-			}
+			return err
 		}
+		plan, planLines, err = readPlan(rows)
+		return err
 	}
+	if stmt.Category == classifier.Read {
+		err = client.ReadOnlyTx(ctx, sessionState.Conn, sessionState.Dialect, run)
+	} else {
+		err = client.RollbackTx(ctx, sessionState.Conn, run)
+	}
+	return plan, planLines, err
+}
+
+// readPlan joins the rows of an EXPLAIN into one plan, indenting JSON plans,
+// and closes rows.
+func readPlan(rows *sql.Rows) (string, int, error) {
 	defer rows.Close()
 
 	var planLines []string
 	columns, err := rows.Columns()
 	if err != nil {
-		return nil, ExplainQueryOutput{}, fmt.Errorf("failed to get columns: %v", err)
+		return "", 0, fmt.Errorf("failed to get columns: %v", err)
 	}
 
 	values := make([]interface{}, len(columns))
@@ -87,7 +240,7 @@ func explainQueryHandler(ctx context.Context, req *mcp.CallToolRequest, input Ex
 	for rows.Next() {
 		err := rows.Scan(valuePtrs...)
 		if err != nil {
-			return nil, ExplainQueryOutput{}, fmt.Errorf("scan error: %v", err)
+			return "", 0, fmt.Errorf("scan error: %v", err)
 		}
 
 		var rowParts []string
@@ -116,10 +269,10 @@ func explainQueryHandler(ctx context.Context, req *mcp.CallToolRequest, input Ex
 	}
 
 	if err = rows.Err(); err != nil {
-		return nil, ExplainQueryOutput{}, fmt.Errorf("rows iteration error: %v", err)
+		return "", 0, fmt.Errorf("rows iteration error: %v", err)
 	}
 
-	plan = strings.Join(planLines, "\n")
+	plan := strings.Join(planLines, "\n")
 
 	if strings.HasPrefix(strings.TrimSpace(plan), "[") || strings.HasPrefix(strings.TrimSpace(plan), "{") {
 		var planJSON interface{}
@@ -130,20 +283,5 @@ func explainQueryHandler(ctx context.Context, req *mcp.CallToolRequest, input Ex
 		}
 	}
 
-	logger.LogDatabaseOperation("EXPLAIN", input.Query, int64(len(planLines)), nil)
-
-	output := ExplainQueryOutput{
-		Plan: plan,
-	}
-
-	jsonBytes, err := json.Marshal(output)
-	if err != nil {
-		return nil, ExplainQueryOutput{}, fmt.Errorf("JSON marshal error: %v", err)
-	}
-
-	return &mcp.CallToolResult{
-		Content: []mcp.Content{
-			&mcp.TextContent{Text: string(jsonBytes)},
-		},
-	}, output, nil
+	return plan, len(planLines), nil
 }
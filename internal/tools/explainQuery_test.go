@@ -0,0 +1,21 @@
+package tools
+
+import (
+	"testing"
+
+	"github.com/AbdelilahOu/DBMcp/internal/dialect"
+)
+
+func TestStripExplain(t *testing.T) {
+	for _, query := range []string{"EXPLAIN ANALYZE SELECT 1", "EXPLAIN\nANALYZE SELECT 1", "EXPLAIN(ANALYZE) SELECT 1"} {
+		if got, err := stripExplain(dialect.Postgres{}, query, false); err == nil {
+			t.Errorf("stripExplain(%q) = %q without analyze, want an error", query, got)
+		}
+		if got, err := stripExplain(dialect.Postgres{}, query, true); err != nil || got != "SELECT 1" {
+			t.Errorf("stripExplain(%q) with analyze = %q, %v, want SELECT 1", query, got, err)
+		}
+	}
+	if got, err := stripExplain(dialect.Postgres{}, "EXPLAIN (FORMAT JSON) SELECT 1", false); err != nil || got != "SELECT 1" {
+		t.Errorf("stripExplain = %q, %v, want SELECT 1", got, err)
+	}
+}
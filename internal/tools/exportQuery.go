@@ -0,0 +1,126 @@
+package tools
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/AbdelilahOu/DBMcp/internal/classifier"
+	"github.com/AbdelilahOu/DBMcp/internal/client"
+	"github.com/AbdelilahOu/DBMcp/internal/export"
+	"github.com/AbdelilahOu/DBMcp/internal/logger"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+type ExportQueryInput struct {
+	Query       string                 `json:"query" jsonschema:"required" jsonschema_description:"SELECT SQL query whose results are exported"`
+	Params      []interface{}          `json:"params,omitempty" jsonschema_description:"Optional positional bind values, as for select_query"`
+	NamedParams map[string]interface{} `json:"named_params,omitempty" jsonschema_description:"Optional bind values for :name placeholders, as for select_query. Cannot be combined with params"`
+	Format      string                 `json:"format,omitempty" jsonschema_description:"File format: csv (default), jsonl or parquet"`
+	FileName    string                 `json:"file_name,omitempty" jsonschema_description:"Optional file name, relative to the export directory; subdirectories are created. The format's extension is added when missing; an extension of another format is refused. Defaults to a timestamped name"`
+	Overwrite   bool                   `json:"overwrite,omitempty" jsonschema_description:"Replace the file if it already exists"`
+	TimeoutMs   int                    `json:"timeout_ms,omitempty" jsonschema_description:"Optional timeout in milliseconds; it can only shorten the connection's export_timeout"`
+}
+
+type ExportQueryOutput struct {
+	Path    string `json:"path" jsonschema_description:"Absolute path of the exported file"`
+	Format  string `json:"format" jsonschema_description:"File format"`
+	Rows    int64  `json:"rows" jsonschema_description:"Number of rows written"`
+	Bytes   int64  `json:"bytes" jsonschema_description:"File size in bytes"`
+	SHA256  string `json:"sha256" jsonschema_description:"Hex SHA-256 checksum of the file"`
+	Message string `json:"message" jsonschema_description:"Success message"`
+}
+
+func GetExportQueryTool(dir string) *ToolDefinition[ExportQueryInput, ExportQueryOutput] {
+	return NewToolDefinition[ExportQueryInput, ExportQueryOutput](
+		"export_query",
+		"Run a SELECT query and stream its results to a CSV, JSON Lines or Parquet file in the server's export directory. Returns the file path, row count, size and checksum instead of the rows.",
+		func(ctx context.Context, req *mcp.CallToolRequest, input ExportQueryInput) (*mcp.CallToolResult, ExportQueryOutput, error) {
+			return exportQueryHandler(ctx, req, input, dir)
+		},
+	)
+}
+
+func exportQueryHandler(ctx context.Context, req *mcp.CallToolRequest, input ExportQueryInput, dir string) (*mcp.CallToolResult, ExportQueryOutput, error) {
+	sessionState, err := getActiveSession(req)
+	if err != nil {
+		return nil, ExportQueryOutput{}, err
+	}
+
+	format, err := export.ParseFormat(input.Format)
+	if err != nil {
+		return nil, ExportQueryOutput{}, err
+	}
+
+	stmt, err := classifier.ClassifySingle(sessionState.Dialect, input.Query)
+	if err != nil {
+		return nil, ExportQueryOutput{}, err
+	}
+	if stmt.Category != classifier.Read || !stmt.IsQuery() {
+		return nil, ExportQueryOutput{}, fmt.Errorf("only SELECT queries can be exported (got %s statement classified as %s)", stmt.Keyword, stmt.Category)
+	}
+
+	query, args, err := bindParams(sessionState.Dialect, input.Query, input.Params, input.NamedParams)
+	if err != nil {
+		return nil, ExportQueryOutput{}, err
+	}
+
+	fileName := input.FileName
+	if fileName == "" {
+		now := time.Now()
+		fileName = fmt.Sprintf("export-%s-%03d", now.Format("20060102-150405"), now.Nanosecond()/int(time.Millisecond))
+	}
+
+	sandbox, err := export.OpenSandbox(dir)
+	if err != nil {
+		return nil, ExportQueryOutput{}, err
+	}
+	defer sandbox.Close()
+
+	ctx, cancel, err := exportContext(ctx, sessionState.Settings, input.TimeoutMs)
+	if err != nil {
+		return nil, ExportQueryOutput{}, err
+	}
+	defer cancel()
+
+	var exported export.Result
+	err = client.ReadOnlyTx(ctx, sessionState.Conn, sessionState.Dialect, func(tx *sql.Tx) error {
+		rows, err := tx.QueryContext(ctx, query, args...)
+		if err != nil {
+			return fmt.Errorf("query execution error: %v", err)
+		}
+		defer rows.Close()
+
+		exported, err = sandbox.Write(fileName, input.Overwrite, format, sessionState.Dialect.Name(), rows)
+		return err
+	})
+	if err != nil {
+		logger.LogDatabaseOperation("EXPORT", input.Query, 0, err)
+		return nil, ExportQueryOutput{}, err
+	}
+
+	// Log successful database operation
+	logger.LogDatabaseOperation("EXPORT", input.Query, exported.Rows, nil)
+
+	output := ExportQueryOutput{
+		Path:    exported.Path,
+		Format:  string(format),
+		Rows:    exported.Rows,
+		Bytes:   exported.Bytes,
+		SHA256:  exported.SHA256,
+		Message: fmt.Sprintf("Exported %d rows to %s (%d bytes)", exported.Rows, exported.Path, exported.Bytes),
+	}
+
+	jsonBytes, err := json.Marshal(output)
+	if err != nil {
+		return nil, ExportQueryOutput{}, fmt.Errorf("JSON marshal error: %v", err)
+	}
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: string(jsonBytes)},
+		},
+	}, output, nil
+}
@@ -0,0 +1,245 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/AbdelilahOu/DBMcp/internal/catalog"
+	"github.com/AbdelilahOu/DBMcp/internal/dialect"
+	"github.com/AbdelilahOu/DBMcp/internal/logger"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+type FindJoinPathInput struct {
+	Source    string   `json:"source" jsonschema:"required" jsonschema_description:"Table the path starts from"`
+	Target    string   `json:"target" jsonschema:"required" jsonschema_description:"Table the path ends at"`
+	Through   []string `json:"through,omitempty" jsonschema_description:"Optional tables the path must pass through, in order"`
+	Schema    string   `json:"schema,omitempty" jsonschema_description:"Optional schema name (defaults to the connection's current schema)"`
+	Refresh   bool     `json:"refresh,omitempty" jsonschema_description:"Reload foreign keys from the catalog instead of using the cached copy"`
+	TimeoutMs int      `json:"timeout_ms,omitempty" jsonschema_description:"Optional timeout in milliseconds, capped by the connection's max_query_timeout"`
+}
+
+type JoinStep struct {
+	Table      string `json:"table" jsonschema_description:"Table joined at this step"`
+	Alias      string `json:"alias" jsonschema_description:"Alias of the table in the skeleton"`
+	JoinsTo    string `json:"joins_to" jsonschema_description:"Alias of the table it joins to"`
+	ForeignKey string `json:"foreign_key" jsonschema_description:"Foreign key the join follows"`
+	Direction  string `json:"direction" jsonschema_description:"many_to_one when the previous table holds the foreign key, one_to_many when this table does (the join can multiply rows)"`
+	Condition  string `json:"condition" jsonschema_description:"Join condition"`
+}
+
+type FindJoinPathOutput struct {
+	Tables  []string   `json:"tables" jsonschema_description:"Tables along the path, in order"`
+	Joins   []JoinStep `json:"joins" jsonschema_description:"Joins along the path, in order"`
+	SQL     string     `json:"sql" jsonschema_description:"FROM/JOIN skeleton for the path"`
+	Cached  bool       `json:"cached" jsonschema_description:"Whether the foreign keys came from the cache"`
+	Message string     `json:"message" jsonschema_description:"Success message"`
+}
+
+func GetFindJoinPathTool() *ToolDefinition[FindJoinPathInput, FindJoinPathOutput] {
+	return NewToolDefinition[FindJoinPathInput, FindJoinPathOutput](
+		"find_join_path",
+		"Find the shortest chain of foreign keys joining two tables, optionally through given tables, and return the join conditions and a FROM/JOIN skeleton.",
+		func(ctx context.Context, req *mcp.CallToolRequest, input FindJoinPathInput) (*mcp.CallToolResult, FindJoinPathOutput, error) {
+			return findJoinPathHandler(ctx, req, input)
+		},
+	)
+}
+
+func findJoinPathHandler(ctx context.Context, req *mcp.CallToolRequest, input FindJoinPathInput) (*mcp.CallToolResult, FindJoinPathOutput, error) {
+	sessionState, err := getActiveSession(req)
+	if err != nil {
+		return nil, FindJoinPathOutput{}, err
+	}
+
+	ctx, cancel, err := queryContext(ctx, sessionState, input.TimeoutMs)
+	if err != nil {
+		return nil, FindJoinPathOutput{}, err
+	}
+	defer cancel()
+
+	schema, err := resolveSchema(ctx, sessionState, input.Schema)
+	if err != nil {
+		return nil, FindJoinPathOutput{}, err
+	}
+
+	graph, cached, err := cachedSchemaGraph(ctx, sessionState, schema, input.Refresh)
+	if err != nil {
+		logger.LogDatabaseOperation("JOIN_PATH", schema, 0, err)
+		return nil, FindJoinPathOutput{}, err
+	}
+
+	stops := append(append([]string{input.Source}, input.Through...), input.Target)
+	edges, err := graph.path(stops)
+	if err != nil {
+		return nil, FindJoinPathOutput{}, err
+	}
+
+	output := joinSkeleton(sessionState.Dialect, schema, input.Source, edges)
+	output.Cached = cached
+	output.Message = fmt.Sprintf("Found a path of %d joins from %s to %s", len(edges), input.Source, input.Target)
+
+	logger.LogDatabaseOperation("JOIN_PATH", fmt.Sprintf("%s -> %s", input.Source, input.Target), int64(len(edges)), nil)
+
+	jsonBytes, err := json.Marshal(output)
+	if err != nil {
+		return nil, FindJoinPathOutput{}, fmt.Errorf("JSON marshal error: %v", err)
+	}
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: string(jsonBytes)},
+		},
+	}, output, nil
+}
+
+// joinEdge is a foreign key followed from one table to another.
+type joinEdge struct {
+	fk   *catalog.ForeignKey
+	from string
+	to   string
+	// fromChild is set when From holds the foreign key.
+	fromChild bool
+}
+
+// path returns the edges of the shortest path visiting stops in order.
+func (g *schemaGraph) path(stops []string) ([]joinEdge, error) {
+	known := make(map[string]bool, len(g.Tables))
+	for _, t := range g.Tables {
+		known[t] = true
+	}
+	for _, t := range stops {
+		if !known[t] {
+			return nil, fmt.Errorf("table %s not found", t)
+		}
+	}
+
+	adjacent := make(map[string][]joinEdge)
+	for i := range g.ForeignKeys {
+		fk := &g.ForeignKeys[i]
+		adjacent[fk.Table] = append(adjacent[fk.Table], joinEdge{fk: fk, from: fk.Table, to: fk.ReferencedTable, fromChild: true})
+		if fk.ReferencedTable != fk.Table {
+			adjacent[fk.ReferencedTable] = append(adjacent[fk.ReferencedTable], joinEdge{fk: fk, from: fk.ReferencedTable, to: fk.Table})
+		}
+	}
+
+	var edges []joinEdge
+	for i := 0; i+1 < len(stops); i++ {
+		segment, ok := shortestPath(adjacent, stops[i], stops[i+1])
+		if !ok {
+			return nil, fmt.Errorf("no foreign key path from %s to %s", stops[i], stops[i+1])
+		}
+		edges = append(edges, segment...)
+	}
+	return edges, nil
+}
+
+// shortestPath is a breadth-first search; ties go to the foreign key
+// listed first.
+func shortestPath(adjacent map[string][]joinEdge, from, to string) ([]joinEdge, bool) {
+	if from == to {
+		return nil, true
+	}
+	via := map[string]joinEdge{}
+	visited := map[string]bool{from: true}
+	queue := []string{from}
+	for len(queue) > 0 {
+		t := queue[0]
+		queue = queue[1:]
+		for _, e := range adjacent[t] {
+			if visited[e.to] {
+				continue
+			}
+			visited[e.to] = true
+			via[e.to] = e
+			if e.to == to {
+				var path []joinEdge
+				for n := to; n != from; n = via[n].from {
+					path = append([]joinEdge{via[n]}, path...)
+				}
+				return path, true
+			}
+			queue = append(queue, e.to)
+		}
+	}
+	return nil, false
+}
+
+func joinSkeleton(d dialect.Dialect, schema, source string, edges []joinEdge) FindJoinPathOutput {
+	aliases := aliasSet{}
+	output := FindJoinPathOutput{
+		Tables: []string{source},
+		Joins:  make([]JoinStep, 0, len(edges)),
+	}
+
+	alias := aliases.next(source)
+	var sql strings.Builder
+	fmt.Fprintf(&sql, "FROM %s %s", d.QualifiedName(schema, source), alias)
+
+	for _, e := range edges {
+		prev := alias
+		alias = aliases.next(e.to)
+
+		childAlias, parentAlias := prev, alias
+		direction := "many_to_one"
+		if !e.fromChild {
+			childAlias, parentAlias = alias, prev
+			direction = "one_to_many"
+		}
+		conditions := make([]string, min(len(e.fk.Columns), len(e.fk.ReferencedColumns)))
+		for i := range conditions {
+			conditions[i] = fmt.Sprintf("%s.%s = %s.%s",
+				childAlias, d.QuoteIdentifier(e.fk.Columns[i]),
+				parentAlias, d.QuoteIdentifier(e.fk.ReferencedColumns[i]))
+		}
+		condition := strings.Join(conditions, " AND ")
+
+		output.Tables = append(output.Tables, e.to)
+		output.Joins = append(output.Joins, JoinStep{
+			Table:      e.to,
+			Alias:      alias,
+			JoinsTo:    prev,
+			ForeignKey: e.fk.Name,
+			Direction:  direction,
+			Condition:  condition,
+		})
+		fmt.Fprintf(&sql, "\nJOIN %s %s ON %s", d.QualifiedName(schema, e.to), alias, condition)
+	}
+
+	output.SQL = sql.String()
+	return output
+}
+
+// aliasSet hands out short table aliases built from the initials of the
+// table name: patient_program becomes pp, then pp2 if it is joined again.
+type aliasSet map[string]bool
+
+// reservedAliases are initials that would read as SQL keywords.
+var reservedAliases = map[string]bool{
+	"as": true, "at": true, "by": true, "do": true, "if": true, "in": true, "is": true,
+	"of": true, "on": true, "or": true, "to": true, "and": true, "asc": true, "all": true,
+	"any": true, "end": true, "for": true, "key": true, "not": true, "set": true, "use": true,
+}
+
+func (a aliasSet) next(table string) string {
+	var b strings.Builder
+	for _, part := range strings.FieldsFunc(strings.ToLower(table), func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9')
+	}) {
+		b.WriteByte(part[0])
+	}
+	base := b.String()
+	if base == "" || base[0] < 'a' || base[0] > 'z' {
+		base = "t" + base
+	}
+
+	alias := base
+	for n := 2; a[alias] || reservedAliases[alias]; n++ {
+		alias = base + strconv.Itoa(n)
+	}
+	a[alias] = true
+	return alias
+}
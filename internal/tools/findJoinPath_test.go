@@ -0,0 +1,172 @@
+package tools
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/AbdelilahOu/DBMcp/internal/catalog"
+	"github.com/AbdelilahOu/DBMcp/internal/dialect"
+)
+
+func fk(name, table string, columns []string, referenced string, referencedColumns []string) catalog.ForeignKey {
+	return catalog.ForeignKey{Name: name, Table: table, Columns: columns, ReferencedTable: referenced, ReferencedColumns: referencedColumns}
+}
+
+func testGraph() *schemaGraph {
+	return &schemaGraph{
+		Tables: []string{"customers", "orders", "order_items", "products", "categories", "employees", "audit_log"},
+		ForeignKeys: []catalog.ForeignKey{
+			fk("orders_customer_fkey", "orders", []string{"customer_id"}, "customers", []string{"id"}),
+			fk("orders_billing_fkey", "orders", []string{"billing_customer_id"}, "customers", []string{"id"}),
+			fk("items_order_fkey", "order_items", []string{"order_id"}, "orders", []string{"id"}),
+			fk("items_product_fkey", "order_items", []string{"product_id"}, "products", []string{"id"}),
+			fk("products_category_fkey", "products", []string{"category_id"}, "categories", []string{"id"}),
+			fk("employees_manager_fkey", "employees", []string{"manager_id"}, "employees", []string{"id"}),
+		},
+	}
+}
+
+// describe lists edges as from>to:foreign key, with < when from is the
+// referenced table.
+func describe(edges []joinEdge) []string {
+	var steps []string
+	for _, e := range edges {
+		arrow := ">"
+		if !e.fromChild {
+			arrow = "<"
+		}
+		steps = append(steps, e.from+arrow+e.to+":"+e.fk.Name)
+	}
+	return steps
+}
+
+func TestSchemaGraphPath(t *testing.T) {
+	tests := []struct {
+		name  string
+		stops []string
+		want  []string
+	}{
+		{
+			name:  "many to one",
+			stops: []string{"orders", "customers"},
+			// Ties go to the foreign key listed first.
+			want: []string{"orders>customers:orders_customer_fkey"},
+		},
+		{
+			name:  "one to many then many to one",
+			stops: []string{"orders", "products"},
+			want:  []string{"orders<order_items:items_order_fkey", "order_items>products:items_product_fkey"},
+		},
+		{
+			name:  "shortest of several",
+			stops: []string{"customers", "categories"},
+			want: []string{
+				"customers<orders:orders_customer_fkey",
+				"orders<order_items:items_order_fkey",
+				"order_items>products:items_product_fkey",
+				"products>categories:products_category_fkey",
+			},
+		},
+		{
+			name:  "through stops",
+			stops: []string{"products", "customers", "order_items"},
+			want: []string{
+				"products<order_items:items_product_fkey",
+				"order_items>orders:items_order_fkey",
+				"orders>customers:orders_customer_fkey",
+				"customers<orders:orders_customer_fkey",
+				"orders<order_items:items_order_fkey",
+			},
+		},
+		{name: "same table", stops: []string{"orders", "orders"}, want: nil},
+	}
+	for _, tt := range tests {
+		edges, err := testGraph().path(tt.stops)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if got := describe(edges); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: path = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSchemaGraphPathErrors(t *testing.T) {
+	for _, stops := range [][]string{
+		{"orders", "audit_log"},
+		{"employees", "customers"},
+		{"orders", "missing"},
+		{"customers", "missing", "orders"},
+	} {
+		if edges, err := testGraph().path(stops); err == nil {
+			t.Errorf("path(%q) = %q, want an error", stops, describe(edges))
+		}
+	}
+}
+
+func TestShortestPathSelfReference(t *testing.T) {
+	g := testGraph()
+	adjacent := map[string][]joinEdge{}
+	for i := range g.ForeignKeys {
+		f := &g.ForeignKeys[i]
+		adjacent[f.Table] = append(adjacent[f.Table], joinEdge{fk: f, from: f.Table, to: f.ReferencedTable, fromChild: true})
+	}
+	if _, ok := shortestPath(adjacent, "employees", "customers"); ok {
+		t.Error("found a path out of a table that only references itself")
+	}
+}
+
+func TestJoinSkeleton(t *testing.T) {
+	edges, err := testGraph().path([]string{"orders", "products", "orders"})
+	if err != nil {
+		t.Fatalf("path: %v", err)
+	}
+	output := joinSkeleton(dialect.Postgres{}, "shop", "orders", edges)
+
+	wantTables := []string{"orders", "order_items", "products", "order_items", "orders"}
+	if !reflect.DeepEqual(output.Tables, wantTables) {
+		t.Errorf("tables = %q, want %q", output.Tables, wantTables)
+	}
+	wantJoins := []JoinStep{
+		{Table: "order_items", Alias: "oi", JoinsTo: "o", ForeignKey: "items_order_fkey", Direction: "one_to_many", Condition: `oi."order_id" = o."id"`},
+		{Table: "products", Alias: "p", JoinsTo: "oi", ForeignKey: "items_product_fkey", Direction: "many_to_one", Condition: `oi."product_id" = p."id"`},
+		{Table: "order_items", Alias: "oi2", JoinsTo: "p", ForeignKey: "items_product_fkey", Direction: "one_to_many", Condition: `oi2."product_id" = p."id"`},
+		{Table: "orders", Alias: "o2", JoinsTo: "oi2", ForeignKey: "items_order_fkey", Direction: "many_to_one", Condition: `oi2."order_id" = o2."id"`},
+	}
+	if !reflect.DeepEqual(output.Joins, wantJoins) {
+		t.Errorf("joins =\n%+v\nwant\n%+v", output.Joins, wantJoins)
+	}
+	wantSQL := strings.Join([]string{
+		`FROM "shop"."orders" o`,
+		`JOIN "shop"."order_items" oi ON oi."order_id" = o."id"`,
+		`JOIN "shop"."products" p ON oi."product_id" = p."id"`,
+		`JOIN "shop"."order_items" oi2 ON oi2."product_id" = p."id"`,
+		`JOIN "shop"."orders" o2 ON oi2."order_id" = o2."id"`,
+	}, "\n")
+	if output.SQL != wantSQL {
+		t.Errorf("sql =\n%s\nwant\n%s", output.SQL, wantSQL)
+	}
+}
+
+func TestAliasSet(t *testing.T) {
+	aliases := aliasSet{}
+	for _, tt := range []struct{ table, want string }{
+		{"patient_program", "pp"},
+		{"patient_program", "pp2"},
+		{"PatientProgram", "p"},
+		{"parent_project", "pp3"},
+		{"order_note", "on2"},
+		{"item_set", "is2"},
+		{"access_system", "as2"},
+		{"audit_numbers_daily", "and2"},
+		{"2024_orders", "t2o"},
+		{"__", "t"},
+		{"t", "t2"},
+	} {
+		if got := aliases.next(tt.table); got != tt.want {
+			t.Errorf("next(%s) = %s, want %s", tt.table, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package tools
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/AbdelilahOu/DBMcp/internal/catalog"
+	"github.com/AbdelilahOu/DBMcp/internal/state"
+)
+
+// foreignKeyCacheTTL bounds how stale cached catalog data can get after a
+// schema change made outside this server.
+const foreignKeyCacheTTL = 5 * time.Minute
+
+// schemaGraph is the catalog data join path searches need: the tables of a
+// schema and the foreign keys among them.
+type schemaGraph struct {
+	Tables      []string
+	ForeignKeys []catalog.ForeignKey
+	loadedAt    time.Time
+}
+
+// foreignKeyCache holds a schemaGraph per connection and schema. It is
+// shared by every session on the same connection.
+var foreignKeyCache = struct {
+	sync.Mutex
+	graphs map[string]*schemaGraph
+}{graphs: make(map[string]*schemaGraph)}
+
+func foreignKeyCacheKey(connectionName, schema string) string {
+	return connectionName + "\x00" + schema
+}
+
+// cachedSchemaGraph returns the schema's graph, loading it when it is not
+// cached, is older than foreignKeyCacheTTL, or refresh is set. The second
+// result reports whether it came from the cache.
+func cachedSchemaGraph(ctx context.Context, sessionState *state.DBSessionState, schema string, refresh bool) (*schemaGraph, bool, error) {
+	key := foreignKeyCacheKey(sessionState.ConnectionName, schema)
+
+	foreignKeyCache.Lock()
+	g, ok := foreignKeyCache.graphs[key]
+	foreignKeyCache.Unlock()
+	if ok && !refresh && time.Since(g.loadedAt) < foreignKeyCacheTTL {
+		return g, true, nil
+	}
+
+	tables, err := catalog.Tables(ctx, sessionState.Conn, sessionState.Dialect, schema)
+	if err != nil {
+		return nil, false, err
+	}
+	foreignKeys, err := catalog.ForeignKeys(ctx, sessionState.Conn, sessionState.Dialect, schema, "")
+	if err != nil {
+		return nil, false, err
+	}
+
+	g = &schemaGraph{
+		Tables:      tables,
+		ForeignKeys: catalog.ForeignKeysWithin(foreignKeys, schema),
+		loadedAt:    time.Now(),
+	}
+	foreignKeyCache.Lock()
+	foreignKeyCache.graphs[key] = g
+	foreignKeyCache.Unlock()
+	return g, false, nil
+}
+
+// invalidateForeignKeys drops every cached graph of a connection, after a
+// DDL statement ran on it.
+func invalidateForeignKeys(connectionName string) {
+	prefix := foreignKeyCacheKey(connectionName, "")
+
+	foreignKeyCache.Lock()
+	defer foreignKeyCache.Unlock()
+	for key := range foreignKeyCache.graphs {
+		if strings.HasPrefix(key, prefix) {
+			delete(foreignKeyCache.graphs, key)
+		}
+	}
+}
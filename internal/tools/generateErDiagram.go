@@ -0,0 +1,268 @@
+package tools
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+
+	"github.com/AbdelilahOu/DBMcp/internal/catalog"
+	"github.com/AbdelilahOu/DBMcp/internal/dialect"
+	"github.com/AbdelilahOu/DBMcp/internal/erd"
+	"github.com/AbdelilahOu/DBMcp/internal/logger"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+type GenerateERDiagramInput struct {
+	Schema    string   `json:"schema,omitempty" jsonschema_description:"Optional schema name (defaults to the connection's current schema)"`
+	Tables    []string `json:"tables,omitempty" jsonschema_description:"Optional tables to draw; the whole schema when omitted"`
+	Hops      int      `json:"hops,omitempty" jsonschema_description:"With tables, also draw the tables up to this many foreign keys away, in either direction (default 0)"`
+	Format    string   `json:"format,omitempty" jsonschema_description:"Diagram format: mermaid (default, an erDiagram) or dot (Graphviz)"`
+	KeysOnly  bool     `json:"keys_only,omitempty" jsonschema_description:"List only primary key, foreign key and unique columns, for large schemas"`
+	TimeoutMs int      `json:"timeout_ms,omitempty" jsonschema_description:"Optional timeout in milliseconds, capped by the connection's max_query_timeout"`
+}
+
+type GenerateERDiagramOutput struct {
+	Format        string   `json:"format" jsonschema_description:"Diagram format"`
+	Diagram       string   `json:"diagram" jsonschema_description:"Diagram source"`
+	Tables        []string `json:"tables" jsonschema_description:"Tables in the diagram"`
+	Relationships int      `json:"relationships" jsonschema_description:"Number of foreign keys drawn"`
+	Message       string   `json:"message" jsonschema_description:"Success message"`
+}
+
+func GetGenerateERDiagramTool() *ToolDefinition[GenerateERDiagramInput, GenerateERDiagramOutput] {
+	return NewToolDefinition[GenerateERDiagramInput, GenerateERDiagramOutput](
+		"generate_er_diagram",
+		"Draw an entity-relationship diagram of a schema, or of some tables and their neighbours, as Mermaid or Graphviz DOT. Shows columns, primary and foreign keys, and relationship cardinality.",
+		func(ctx context.Context, req *mcp.CallToolRequest, input GenerateERDiagramInput) (*mcp.CallToolResult, GenerateERDiagramOutput, error) {
+			return generateERDiagramHandler(ctx, req, input)
+		},
+	)
+}
+
+func generateERDiagramHandler(ctx context.Context, req *mcp.CallToolRequest, input GenerateERDiagramInput) (*mcp.CallToolResult, GenerateERDiagramOutput, error) {
+	sessionState, err := getActiveSession(req)
+	if err != nil {
+		return nil, GenerateERDiagramOutput{}, err
+	}
+
+	format, err := erd.ParseFormat(input.Format)
+	if err != nil {
+		return nil, GenerateERDiagramOutput{}, err
+	}
+	if input.Hops < 0 {
+		return nil, GenerateERDiagramOutput{}, fmt.Errorf("hops must not be negative")
+	}
+
+	ctx, cancel, err := queryContext(ctx, sessionState, input.TimeoutMs)
+	if err != nil {
+		return nil, GenerateERDiagramOutput{}, err
+	}
+	defer cancel()
+
+	schema, err := resolveSchema(ctx, sessionState, input.Schema)
+	if err != nil {
+		return nil, GenerateERDiagramOutput{}, err
+	}
+
+	allTables, err := catalog.Tables(ctx, sessionState.Conn, sessionState.Dialect, schema)
+	if err != nil {
+		logger.LogDatabaseOperation("ER_DIAGRAM", schema, 0, err)
+		return nil, GenerateERDiagramOutput{}, err
+	}
+
+	foreignKeys, err := catalog.ForeignKeys(ctx, sessionState.Conn, sessionState.Dialect, schema, "")
+	if err != nil {
+		logger.LogDatabaseOperation("ER_DIAGRAM", schema, 0, err)
+		return nil, GenerateERDiagramOutput{}, err
+	}
+	foreignKeys = catalog.ForeignKeysWithin(foreignKeys, schema)
+
+	tables := allTables
+	if len(input.Tables) > 0 {
+		tables, err = neighbourhood(allTables, foreignKeys, input.Tables, input.Hops)
+		if err != nil {
+			return nil, GenerateERDiagramOutput{}, err
+		}
+	}
+
+	diagram, err := buildDiagram(ctx, sessionState.Conn, sessionState.Dialect, schema, tables, foreignKeys, input.KeysOnly)
+	if err != nil {
+		logger.LogDatabaseOperation("ER_DIAGRAM", schema, 0, err)
+		return nil, GenerateERDiagramOutput{}, err
+	}
+
+	logger.LogDatabaseOperation("ER_DIAGRAM", schema, int64(len(tables)), nil)
+
+	output := GenerateERDiagramOutput{
+		Format:        string(format),
+		Diagram:       erd.Render(format, diagram),
+		Tables:        tables,
+		Relationships: len(diagram.Relations),
+		Message:       fmt.Sprintf("Diagram of %d tables and %d relationships in %s", len(tables), len(diagram.Relations), schema),
+	}
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: output.Diagram},
+			&mcp.TextContent{Text: output.Message},
+		},
+	}, output, nil
+}
+
+// neighbourhood returns the start tables and every table up to hops foreign
+// keys away from one of them, in allTables order.
+func neighbourhood(allTables []string, foreignKeys []catalog.ForeignKey, start []string, hops int) ([]string, error) {
+	known := make(map[string]bool, len(allTables))
+	for _, t := range allTables {
+		known[t] = true
+	}
+
+	adjacent := make(map[string][]string)
+	for _, fk := range foreignKeys {
+		adjacent[fk.Table] = append(adjacent[fk.Table], fk.ReferencedTable)
+		adjacent[fk.ReferencedTable] = append(adjacent[fk.ReferencedTable], fk.Table)
+	}
+
+	included := make(map[string]bool)
+	frontier := make([]string, 0, len(start))
+	for _, t := range start {
+		if !known[t] {
+			return nil, fmt.Errorf("table %s not found", t)
+		}
+		if !included[t] {
+			included[t] = true
+			frontier = append(frontier, t)
+		}
+	}
+	for hop := 0; hop < hops && len(frontier) > 0; hop++ {
+		var next []string
+		for _, t := range frontier {
+			for _, n := range adjacent[t] {
+				if !included[n] {
+					included[n] = true
+					next = append(next, n)
+				}
+			}
+		}
+		frontier = next
+	}
+
+	tables := make([]string, 0, len(included))
+	for _, t := range allTables {
+		if included[t] {
+			tables = append(tables, t)
+		}
+	}
+	return tables, nil
+}
+
+// buildDiagram reads the columns and indexes of tables and draws the
+// foreign keys among them.
+func buildDiagram(ctx context.Context, conn *sql.DB, d dialect.Dialect, schema string, tables []string, foreignKeys []catalog.ForeignKey, keysOnly bool) (erd.Diagram, error) {
+	diagram := erd.Diagram{}
+	included := make(map[string]bool, len(tables))
+	for _, t := range tables {
+		included[t] = true
+	}
+
+	fkColumns := make(map[string]map[string]bool)
+	for _, fk := range foreignKeys {
+		if fkColumns[fk.Table] == nil {
+			fkColumns[fk.Table] = make(map[string]bool)
+		}
+		for _, c := range fk.Columns {
+			fkColumns[fk.Table][c] = true
+		}
+	}
+
+	// Per table: nullable columns, and the column sets that are unique.
+	nullable := make(map[string]map[string]bool)
+	uniqueKeys := make(map[string][][]string)
+
+	for _, name := range tables {
+		columns, err := catalog.Columns(ctx, conn, d, schema, name)
+		if err != nil {
+			return erd.Diagram{}, fmt.Errorf("get columns of %s: %v", name, err)
+		}
+		indexes, err := catalog.Indexes(ctx, conn, d, schema, name)
+		if err != nil {
+			return erd.Diagram{}, fmt.Errorf("get indexes of %s: %v", name, err)
+		}
+
+		nullable[name] = make(map[string]bool)
+		uniqueColumns := make(map[string]bool)
+		var primaryKey []string
+		for _, c := range columns {
+			nullable[name][c.Name] = c.IsNullable
+			if c.IsPrimaryKey {
+				primaryKey = append(primaryKey, c.Name)
+			}
+		}
+		if len(primaryKey) > 0 {
+			uniqueKeys[name] = append(uniqueKeys[name], primaryKey)
+		}
+		for _, idx := range indexes {
+			if idx.IsUnique && !idx.IsPartial && !idx.IsExpression {
+				uniqueKeys[name] = append(uniqueKeys[name], idx.Columns)
+				if len(idx.Columns) == 1 {
+					uniqueColumns[idx.Columns[0]] = true
+				}
+			}
+		}
+
+		table := erd.Table{Name: name}
+		for _, c := range columns {
+			col := erd.Column{
+				Name:       c.Name,
+				Type:       c.DataType,
+				PrimaryKey: c.IsPrimaryKey,
+				ForeignKey: fkColumns[name][c.Name],
+				Unique:     uniqueColumns[c.Name],
+			}
+			if keysOnly && !col.PrimaryKey && !col.ForeignKey && !col.Unique {
+				continue
+			}
+			table.Columns = append(table.Columns, col)
+		}
+		diagram.Tables = append(diagram.Tables, table)
+	}
+
+	for _, fk := range foreignKeys {
+		if !included[fk.Table] || !included[fk.ReferencedTable] {
+			continue
+		}
+		relation := erd.Relation{Name: fk.Name, Child: fk.Table, Parent: fk.ReferencedTable}
+		for _, key := range uniqueKeys[fk.Table] {
+			if sameColumns(key, fk.Columns) {
+				relation.OneToOne = true
+			}
+		}
+		for _, c := range fk.Columns {
+			if nullable[fk.Table][c] {
+				relation.Optional = true
+			}
+		}
+		diagram.Relations = append(diagram.Relations, relation)
+	}
+
+	return diagram, nil
+}
+
+// sameColumns reports whether a and b hold the same column names, in any
+// order.
+func sameColumns(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	sa := append([]string(nil), a...)
+	sb := append([]string(nil), b...)
+	sort.Strings(sa)
+	sort.Strings(sb)
+	for i := range sa {
+		if sa[i] != sb[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -5,14 +5,14 @@ import (
 	"database/sql"
 	"encoding/json"
 	"fmt"
-	"strings"
-	"time"
 
 	"github.com/AbdelilahOu/DBMcp/internal/logger"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
 
-type GetDBInfoInput struct{}
+type GetDBInfoInput struct {
+	TimeoutMs int `json:"timeout_ms,omitempty" jsonschema_description:"Optional timeout in milliseconds, capped by the connection's max_query_timeout"`
+}
 
 type GetDBInfoOutput struct {
 	DatabaseName string   `json:"database_name" jsonschema_description:"Name of the database"`
@@ -33,73 +33,42 @@ func GetDbInfoTool() *ToolDefinition[GetDBInfoInput, GetDBInfoOutput] {
 
 func getDBInfoHandler(ctx context.Context, req *mcp.CallToolRequest, input GetDBInfoInput) (*mcp.CallToolResult, GetDBInfoOutput, error) {
 
-	sessionState, err := getActiveSession("default")
+	sessionState, err := getActiveSession(req)
 	if err != nil {
 		return nil, GetDBInfoOutput{}, err
 	}
 
-	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	ctx, cancel, err := queryContext(ctx, sessionState, input.TimeoutMs)
+	if err != nil {
+		return nil, GetDBInfoOutput{}, err
+	}
 	defer cancel()
 
 	var dbName, version string
 	var schemas []string
 	var tableCount int
 
-	pgDbNameQuery := "SELECT current_database()"
-	pgVersionQuery := "SELECT version()"
-	pgSchemasQuery := "SELECT schema_name FROM information_schema.schemata WHERE schema_name NOT IN ('information_schema', 'pg_catalog', 'pg_toast')"
-	pgTableCountQuery := "SELECT COUNT(*) FROM information_schema.tables WHERE table_schema NOT IN ('information_schema', 'pg_catalog', 'pg_toast')"
+	d := sessionState.Dialect
 
-	err = sessionState.Conn.QueryRowContext(ctx, pgDbNameQuery).Scan(&dbName)
+	err = sessionState.Conn.QueryRowContext(ctx, d.DatabaseNameQuery()).Scan(&dbName)
 	if err != nil {
-		mysqlDbNameQuery := "SELECT DATABASE()"
-		mysqlVersionQuery := "SELECT VERSION()"
-		mysqlSchemasQuery := "SELECT SCHEMA_NAME FROM information_schema.SCHEMATA WHERE SCHEMA_NAME NOT IN ('information_schema', 'mysql', 'performance_schema', 'sys')"
-		mysqlTableCountQuery := "SELECT COUNT(*) FROM information_schema.tables WHERE table_schema NOT IN ('information_schema', 'mysql', 'performance_schema', 'sys')"
-
-		err = sessionState.Conn.QueryRowContext(ctx, mysqlDbNameQuery).Scan(&dbName)
-		if err != nil {
-			return nil, GetDBInfoOutput{}, fmt.Errorf("failed to get database name: %v", err)
-		}
-
-		err = sessionState.Conn.QueryRowContext(ctx, mysqlVersionQuery).Scan(&version)
-		if err != nil {
-			return nil, GetDBInfoOutput{}, fmt.Errorf("failed to get version: %v", err)
-		}
-
-		schemas, err = getStringSliceFromQuery(ctx, sessionState.Conn, mysqlSchemasQuery)
-		if err != nil {
-			return nil, GetDBInfoOutput{}, fmt.Errorf("failed to get schemas: %v", err)
-		}
-
-		err = sessionState.Conn.QueryRowContext(ctx, mysqlTableCountQuery).Scan(&tableCount)
-		if err != nil {
-			return nil, GetDBInfoOutput{}, fmt.Errorf("failed to get table count: %v", err)
-		}
-
-		version = "MySQL " + version
-	} else {
-		err = sessionState.Conn.QueryRowContext(ctx, pgVersionQuery).Scan(&version)
-		if err != nil {
-			return nil, GetDBInfoOutput{}, fmt.Errorf("failed to get version: %v", err)
-		}
+		return nil, GetDBInfoOutput{}, fmt.Errorf("failed to get database name: %v", err)
+	}
 
-		if strings.Contains(version, "PostgreSQL") {
-			parts := strings.Fields(version)
-			if len(parts) >= 2 {
-				version = "PostgreSQL " + parts[1]
-			}
-		}
+	err = sessionState.Conn.QueryRowContext(ctx, d.VersionQuery()).Scan(&version)
+	if err != nil {
+		return nil, GetDBInfoOutput{}, fmt.Errorf("failed to get version: %v", err)
+	}
+	version = d.FormatVersion(version)
 
-		schemas, err = getStringSliceFromQuery(ctx, sessionState.Conn, pgSchemasQuery)
-		if err != nil {
-			return nil, GetDBInfoOutput{}, fmt.Errorf("failed to get schemas: %v", err)
-		}
+	schemas, err = getStringSliceFromQuery(ctx, sessionState.Conn, d.SchemasQuery())
+	if err != nil {
+		return nil, GetDBInfoOutput{}, fmt.Errorf("failed to get schemas: %v", err)
+	}
 
-		err = sessionState.Conn.QueryRowContext(ctx, pgTableCountQuery).Scan(&tableCount)
-		if err != nil {
-			return nil, GetDBInfoOutput{}, fmt.Errorf("failed to get table count: %v", err)
-		}
+	err = sessionState.Conn.QueryRowContext(ctx, d.TableCountQuery()).Scan(&tableCount)
+	if err != nil {
+		return nil, GetDBInfoOutput{}, fmt.Errorf("failed to get table count: %v", err)
 	}
 
 	output := GetDBInfoOutput{
@@ -0,0 +1,156 @@
+package tools
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/AbdelilahOu/DBMcp/internal/logger"
+	"github.com/AbdelilahOu/DBMcp/internal/pgddl"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+type GetDDLInput struct {
+	Name      string `json:"name" jsonschema:"required" jsonschema_description:"Object name"`
+	Type      string `json:"type,omitempty" jsonschema_description:"Object type: table (default), view, materialized_view, index, sequence, function, procedure or trigger"`
+	Schema    string `json:"schema,omitempty" jsonschema_description:"Optional schema name (defaults to the connection's current schema)"`
+	Table     string `json:"table,omitempty" jsonschema_description:"For a Postgres trigger, the table it is on; trigger names are only unique per table there"`
+	TimeoutMs int    `json:"timeout_ms,omitempty" jsonschema_description:"Optional timeout in milliseconds, capped by the connection's max_query_timeout"`
+}
+
+type GetDDLOutput struct {
+	Type    string `json:"type" jsonschema_description:"Object type"`
+	Schema  string `json:"schema" jsonschema_description:"Schema of the object"`
+	Name    string `json:"name" jsonschema_description:"Object name"`
+	DDL     string `json:"ddl" jsonschema_description:"Statements that create the object"`
+	Message string `json:"message" jsonschema_description:"Success message"`
+}
+
+var ddlTypes = map[string]bool{
+	"table": true, "view": true, "materialized_view": true, "index": true,
+	"sequence": true, "function": true, "procedure": true, "trigger": true,
+}
+
+// ddlBuilders rebuild DDL for engines without a CREATE statement query.
+var ddlBuilders = map[string]func(ctx context.Context, db *sql.DB, kind, schema, name, table string) (string, error){
+	"postgres": pgddl.Build,
+}
+
+func GetDDLTool() *ToolDefinition[GetDDLInput, GetDDLOutput] {
+	return NewToolDefinition[GetDDLInput, GetDDLOutput](
+		"get_ddl",
+		"Get runnable DDL for a table, view, materialized view, index, sequence, function, procedure or trigger. Uses SHOW CREATE on MySQL and the stored SQL on SQLite, and rebuilds the statements from pg_catalog on Postgres, with constraints, defaults, identity columns, partitioning, indexes, triggers and comments.",
+		func(ctx context.Context, req *mcp.CallToolRequest, input GetDDLInput) (*mcp.CallToolResult, GetDDLOutput, error) {
+			return getDDLHandler(ctx, req, input)
+		},
+	)
+}
+
+func getDDLHandler(ctx context.Context, req *mcp.CallToolRequest, input GetDDLInput) (*mcp.CallToolResult, GetDDLOutput, error) {
+	sessionState, err := getActiveSession(req)
+	if err != nil {
+		return nil, GetDDLOutput{}, err
+	}
+
+	kind := strings.ToLower(input.Type)
+	if kind == "" {
+		kind = "table"
+	}
+	if !ddlTypes[kind] {
+		return nil, GetDDLOutput{}, fmt.Errorf("unsupported type %q: use table, view, materialized_view, index, sequence, function, procedure or trigger", input.Type)
+	}
+
+	ctx, cancel, err := queryContext(ctx, sessionState, input.TimeoutMs)
+	if err != nil {
+		return nil, GetDDLOutput{}, err
+	}
+	defer cancel()
+
+	schema, err := resolveSchema(ctx, sessionState, input.Schema)
+	if err != nil {
+		return nil, GetDDLOutput{}, err
+	}
+
+	var ddl string
+	if build, ok := ddlBuilders[sessionState.Dialect.Name()]; ok {
+		ddl, err = build(ctx, sessionState.Conn, kind, schema, input.Name, input.Table)
+	} else {
+		ddl, err = createStatement(ctx, sessionState.Conn, sessionState.Dialect.Name(), sessionState.Dialect.CreateStatementQuery, kind, schema, input.Name)
+	}
+	logger.LogDatabaseOperation("GET_DDL", fmt.Sprintf("%s %s.%s", kind, schema, input.Name), 0, err)
+	if err != nil {
+		return nil, GetDDLOutput{}, err
+	}
+
+	output := GetDDLOutput{
+		Type:    kind,
+		Schema:  schema,
+		Name:    input.Name,
+		DDL:     ddl,
+		Message: fmt.Sprintf("DDL of %s %s.%s", strings.ReplaceAll(kind, "_", " "), schema, input.Name),
+	}
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: output.DDL},
+			&mcp.TextContent{Text: output.Message},
+		},
+	}, output, nil
+}
+
+// createStatement runs the dialect's CREATE statement query and returns
+// its statement column, terminated by a semicolon.
+func createStatement(ctx context.Context, db *sql.DB, dbType string, queryFor func(kind, schema, name string) (string, []interface{}, string), kind, schema, name string) (string, error) {
+	query, args, column := queryFor(kind, schema, name)
+	if query == "" {
+		if kind == "index" {
+			return "", fmt.Errorf("%s indexes are part of their table's DDL; ask for the table instead", dbType)
+		}
+		return "", fmt.Errorf("%s has no %s objects", dbType, strings.ReplaceAll(kind, "_", " "))
+	}
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return "", err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return "", err
+	}
+	index := -1
+	for i, c := range columns {
+		if strings.EqualFold(c, column) {
+			index = i
+		}
+	}
+	if index < 0 {
+		return "", fmt.Errorf("no %q column in the result", column)
+	}
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return "", err
+		}
+		return "", fmt.Errorf("%s %s.%s not found", strings.ReplaceAll(kind, "_", " "), schema, name)
+	}
+	values := make([]sql.NullString, len(columns))
+	dest := make([]interface{}, len(columns))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+	if err := rows.Scan(dest...); err != nil {
+		return "", fmt.Errorf("scan error: %v", err)
+	}
+	if !values[index].Valid {
+		return "", fmt.Errorf("the definition of %s.%s is not visible to this user", schema, name)
+	}
+
+	ddl := strings.TrimSpace(values[index].String)
+	if !strings.HasSuffix(ddl, ";") {
+		ddl += ";"
+	}
+	return ddl + "\n", nil
+}
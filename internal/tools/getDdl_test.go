@@ -0,0 +1,61 @@
+package tools
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/AbdelilahOu/DBMcp/internal/dialect"
+	_ "modernc.org/sqlite"
+)
+
+func TestCreateStatement(t *testing.T) {
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "ddl.db"))
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	defer db.Close()
+	for _, stmt := range []string{
+		`CREATE TABLE items (id INTEGER PRIMARY KEY, name TEXT)`,
+		`CREATE INDEX items_name ON items (name)`,
+		`CREATE TRIGGER items_touch AFTER UPDATE ON items BEGIN SELECT 1; END`,
+		`CREATE VIEW named AS SELECT name FROM items`,
+	} {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatalf("%s: %v", stmt, err)
+		}
+	}
+
+	d := dialect.SQLite{}
+	build := func(kind, name string) (string, error) {
+		return createStatement(context.Background(), db, d.Name(), d.CreateStatementQuery, kind, "main", name)
+	}
+
+	ddl, err := build("table", "items")
+	if err != nil {
+		t.Fatalf("table: %v", err)
+	}
+	want := "CREATE TABLE items (id INTEGER PRIMARY KEY, name TEXT);\n" +
+		"CREATE INDEX items_name ON items (name);\n" +
+		"CREATE TRIGGER items_touch AFTER UPDATE ON items BEGIN SELECT 1; END;\n"
+	if ddl != want {
+		t.Errorf("table DDL =\n%s\nwant\n%s", ddl, want)
+	}
+
+	if ddl, err := build("view", "named"); err != nil || ddl != "CREATE VIEW named AS SELECT name FROM items;\n" {
+		t.Errorf("view DDL = %q, %v", ddl, err)
+	}
+
+	for _, tt := range []struct{ kind, name, want string }{
+		{"table", "missing", "not found"},
+		{"table", "named", "not found"},
+		{"function", "f", "has no function objects"},
+		{"materialized_view", "m", "has no materialized view objects"},
+	} {
+		if _, err := build(tt.kind, tt.name); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s %s: err = %v, want %q", tt.kind, tt.name, err, tt.want)
+		}
+	}
+}
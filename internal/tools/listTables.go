@@ -2,18 +2,18 @@ package tools
 
 import (
 	"context"
-	"database/sql"
-	"encoding/json"
 	"fmt"
 	"strings"
-	"time"
 
 	"github.com/AbdelilahOu/DBMcp/internal/logger"
+	"github.com/AbdelilahOu/DBMcp/internal/render"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
 
 type ListTablesInput struct {
-	Schema string `json:"schema,omitempty" jsonschema_description:"Optional schema name to filter tables (defaults to 'public' for PostgreSQL)"`
+	Schema    string `json:"schema,omitempty" jsonschema_description:"Optional schema name to filter tables (lists all non-system schemas when omitted)"`
+	Format    string `json:"format,omitempty" jsonschema_description:"Text output format: json (default), markdown, csv or ndjson. The structured output is the same for every format"`
+	TimeoutMs int    `json:"timeout_ms,omitempty" jsonschema_description:"Optional timeout in milliseconds, capped by the connection's max_query_timeout"`
 }
 
 type TableInfo struct {
@@ -37,80 +37,32 @@ func GetListTablesTool() *ToolDefinition[ListTablesInput, ListTablesOutput] {
 }
 
 func listTablesHandler(ctx context.Context, req *mcp.CallToolRequest, input ListTablesInput) (*mcp.CallToolResult, ListTablesOutput, error) {
-	sessionState, err := getActiveSession("default")
+	sessionState, err := getActiveSession(req)
 	if err != nil {
 		return nil, ListTablesOutput{}, err
 	}
 
-	schema := input.Schema
-	if schema == "" {
-		schema = "public"
+	format, err := render.ParseFormat(input.Format)
+	if err != nil {
+		return nil, ListTablesOutput{}, err
 	}
 
-	var query string
-
-	detectQuery := "SELECT 1 FROM information_schema.tables WHERE table_schema = 'information_schema' LIMIT 1"
-	_, err = sessionState.Conn.QueryContext(ctx, detectQuery)
+	query, args := sessionState.Dialect.ListTablesQuery(input.Schema)
 
+	ctx, cancel, err := queryContext(ctx, sessionState, input.TimeoutMs)
 	if err != nil {
-		query = `
-			SELECT
-				table_name as name,
-				table_schema as schema_name,
-				table_type as table_type
-			FROM information_schema.tables
-			WHERE table_schema NOT IN ('information_schema', 'mysql', 'performance_schema', 'sys')
-			ORDER BY table_name`
-
-		if input.Schema != "" {
-			query = `
-				SELECT
-					table_name as name,
-					table_schema as schema_name,
-					table_type as table_type
-				FROM information_schema.tables
-				WHERE table_schema = ?
-				ORDER BY table_name`
-		}
-	} else {
-		if input.Schema != "" {
-			query = `
-				SELECT
-					table_name as name,
-					table_schema as schema_name,
-					table_type as table_type
-				FROM information_schema.tables
-				WHERE table_schema = $1
-				ORDER BY table_name`
-		} else {
-			query = `
-				SELECT
-					table_name as name,
-					table_schema as schema_name,
-					table_type as table_type
-				FROM information_schema.tables
-				WHERE table_schema NOT IN ('information_schema', 'pg_catalog')
-				ORDER BY table_name`
-		}
+		return nil, ListTablesOutput{}, err
 	}
-
-	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 
-	var rows *sql.Rows
-	if input.Schema != "" && !strings.Contains(query, "NOT IN") {
-		rows, err = sessionState.Conn.QueryContext(ctx, query, schema)
-	} else {
-		rows, err = sessionState.Conn.QueryContext(ctx, query)
-	}
-
+	rows, err := sessionState.Conn.QueryContext(ctx, query, args...)
 	if err != nil {
 		logger.LogDatabaseOperation("LIST_TABLES", query, 0, err)
 		return nil, ListTablesOutput{}, fmt.Errorf("query error: %v", err)
 	}
 	defer rows.Close()
 
-	var tables []TableInfo
+	tables := make([]TableInfo, 0)
 	for rows.Next() {
 		var name, schemaName, tableType string
 		if err := rows.Scan(&name, &schemaName, &tableType); err != nil {
@@ -140,14 +92,14 @@ func listTablesHandler(ctx context.Context, req *mcp.CallToolRequest, input List
 
 	output := ListTablesOutput{Tables: tables}
 
-	jsonBytes, err := json.Marshal(output)
-	if err != nil {
-		return nil, ListTablesOutput{}, fmt.Errorf("JSON marshal error: %v", err)
+	table := render.Table{Columns: []string{"name", "schema", "type"}}
+	for _, t := range tables {
+		table.Rows = append(table.Rows, []interface{}{t.Name, t.Schema, t.Type})
 	}
 
-	return &mcp.CallToolResult{
-		Content: []mcp.Content{
-			&mcp.TextContent{Text: string(jsonBytes)},
-		},
-	}, output, nil
+	result, err := renderedResult(format, output, table, "")
+	if err != nil {
+		return nil, ListTablesOutput{}, err
+	}
+	return result, output, nil
 }
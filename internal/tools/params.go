@@ -0,0 +1,166 @@
+package tools
+
+import (
+	"encoding/base64"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/AbdelilahOu/DBMcp/internal/classifier"
+	"github.com/AbdelilahOu/DBMcp/internal/dialect"
+)
+
+// timestampLayouts are the ISO 8601 forms accepted for timestamp parameters.
+var timestampLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02",
+}
+
+// bindParams returns the query and driver arguments for a tool call. With
+// named, ":name" placeholders are rewritten to the dialect's placeholder
+// style; params are passed through for the dialect's own placeholders.
+func bindParams(d dialect.Dialect, query string, params []interface{}, named map[string]interface{}) (string, []interface{}, error) {
+	if len(params) > 0 && len(named) > 0 {
+		return "", nil, fmt.Errorf("use either params or named_params, not both")
+	}
+	if len(named) > 0 {
+		return bindNamedParams(d, query, named)
+	}
+
+	args := make([]interface{}, len(params))
+	for i, p := range params {
+		v, err := convertParam(p)
+		if err != nil {
+			return "", nil, fmt.Errorf("params[%d]: %v", i, err)
+		}
+		args[i] = v
+	}
+	return query, args, nil
+}
+
+func bindNamedParams(d dialect.Dialect, query string, named map[string]interface{}) (string, []interface{}, error) {
+	placeholders, err := classifier.NamedPlaceholders(d, query)
+	if err != nil {
+		return "", nil, err
+	}
+	if len(placeholders) == 0 {
+		return "", nil, fmt.Errorf("named_params given but the query has no :name placeholders")
+	}
+
+	// Numbered styles ($1, ?1) bind a repeated name once; "?" needs the value
+	// again at every occurrence.
+	numbered := d.Placeholder(1) != d.Placeholder(2)
+
+	var b strings.Builder
+	var args []interface{}
+	index := make(map[string]int)
+	last := 0
+	for _, p := range placeholders {
+		value, ok := named[p.Name]
+		if !ok {
+			return "", nil, fmt.Errorf("no value in named_params for placeholder :%s", p.Name)
+		}
+
+		n, seen := index[p.Name]
+		if !seen || !numbered {
+			v, err := convertParam(value)
+			if err != nil {
+				return "", nil, fmt.Errorf("named_params.%s: %v", p.Name, err)
+			}
+			args = append(args, v)
+			n = len(args)
+			index[p.Name] = n
+		}
+
+		b.WriteString(query[last:p.Start])
+		b.WriteString(d.Placeholder(n))
+		last = p.End
+	}
+	b.WriteString(query[last:])
+
+	for name := range named {
+		if _, ok := index[name]; !ok {
+			return "", nil, fmt.Errorf("named_params.%s does not match any placeholder in the query", name)
+		}
+	}
+	return b.String(), args, nil
+}
+
+// convertParam turns a decoded JSON value into a driver argument. Scalars
+// map directly; timestamps, binary data and integers too large for a JSON
+// number are passed as {"type": ..., "value": ...} objects.
+func convertParam(v interface{}) (interface{}, error) {
+	switch v := v.(type) {
+	case nil, string, bool:
+		return v, nil
+	case float64:
+		if v == math.Trunc(v) && math.Abs(v) <= 1<<53 {
+			return int64(v), nil
+		}
+		return v, nil
+	case map[string]interface{}:
+		return convertTypedParam(v)
+	default:
+		return nil, fmt.Errorf("unsupported value of type %T; use a scalar or a {\"type\", \"value\"} object", v)
+	}
+}
+
+func convertTypedParam(m map[string]interface{}) (interface{}, error) {
+	typ, _ := m["type"].(string)
+	raw, ok := m["value"]
+	if !ok {
+		return nil, fmt.Errorf("typed parameter needs a \"value\"")
+	}
+	if raw == nil {
+		return nil, nil
+	}
+
+	switch typ {
+	case "timestamp":
+		s, ok := raw.(string)
+		if !ok {
+			return nil, fmt.Errorf("timestamp value must be an ISO 8601 string")
+		}
+		for _, layout := range timestampLayouts {
+			if t, err := time.Parse(layout, s); err == nil {
+				return t, nil
+			}
+		}
+		return nil, fmt.Errorf("invalid ISO 8601 timestamp %q", s)
+	case "bytes":
+		s, ok := raw.(string)
+		if !ok {
+			return nil, fmt.Errorf("bytes value must be a base64 string")
+		}
+		b, err := base64.StdEncoding.DecodeString(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid base64: %v", err)
+		}
+		return b, nil
+	case "int":
+		switch n := raw.(type) {
+		case string:
+			i, err := strconv.ParseInt(n, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid int %q", n)
+			}
+			return i, nil
+		case float64:
+			if n != math.Trunc(n) {
+				return nil, fmt.Errorf("invalid int %v", n)
+			}
+			// Beyond 2^53 the JSON number may already have lost digits.
+			if math.Abs(n) > 1<<53 {
+				return nil, fmt.Errorf("int %v is too large for a JSON number; pass it as a decimal string", n)
+			}
+			return int64(n), nil
+		}
+		return nil, fmt.Errorf("int value must be a number or a decimal string")
+	default:
+		return nil, fmt.Errorf("unsupported parameter type %q (expected timestamp, bytes or int)", typ)
+	}
+}
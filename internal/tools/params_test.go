@@ -0,0 +1,176 @@
+package tools
+
+import (
+	"math"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/AbdelilahOu/DBMcp/internal/dialect"
+)
+
+func TestBindNamedParams(t *testing.T) {
+	tests := []struct {
+		name     string
+		dialect  dialect.Dialect
+		query    string
+		named    map[string]interface{}
+		want     string
+		wantArgs []interface{}
+	}{
+		{
+			name:     "numbered on postgres",
+			dialect:  dialect.Postgres{},
+			query:    "SELECT * FROM t WHERE a = :a AND b = :b",
+			named:    map[string]interface{}{"a": 1.0, "b": "x"},
+			want:     "SELECT * FROM t WHERE a = $1 AND b = $2",
+			wantArgs: []interface{}{int64(1), "x"},
+		},
+		{
+			name:     "repeated name bound once when numbered",
+			dialect:  dialect.Postgres{},
+			query:    "SELECT :id, :other, :id",
+			named:    map[string]interface{}{"id": 7.0, "other": true},
+			want:     "SELECT $1, $2, $1",
+			wantArgs: []interface{}{int64(7), true},
+		},
+		{
+			name:     "repeated name bound at every ? on mysql",
+			dialect:  dialect.MySQL{},
+			query:    "SELECT :id, :other, :id",
+			named:    map[string]interface{}{"id": 7.0, "other": true},
+			want:     "SELECT ?, ?, ?",
+			wantArgs: []interface{}{int64(7), true, int64(7)},
+		},
+		{
+			name:     "numbered on sqlite",
+			dialect:  dialect.SQLite{},
+			query:    "SELECT :id, :id",
+			named:    map[string]interface{}{"id": "a"},
+			want:     "SELECT ?1, ?1",
+			wantArgs: []interface{}{"a"},
+		},
+		{
+			name:     "casts and assignments kept",
+			dialect:  dialect.Postgres{},
+			query:    "SELECT :v::int, now()::date",
+			named:    map[string]interface{}{"v": "5"},
+			want:     "SELECT $1::int, now()::date",
+			wantArgs: []interface{}{"5"},
+		},
+		{
+			name:     "mysql assignment kept",
+			dialect:  dialect.MySQL{},
+			query:    "SELECT @n := :start",
+			named:    map[string]interface{}{"start": 1.0},
+			want:     "SELECT @n := ?",
+			wantArgs: []interface{}{int64(1)},
+		},
+		{
+			name:     "literals and comments untouched",
+			dialect:  dialect.Postgres{},
+			query:    "SELECT ':a' /* :a */, :a -- :a",
+			named:    map[string]interface{}{"a": nil},
+			want:     "SELECT ':a' /* :a */, $1 -- :a",
+			wantArgs: []interface{}{nil},
+		},
+	}
+	for _, tt := range tests {
+		got, args, err := bindParams(tt.dialect, tt.query, nil, tt.named)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s: query = %s, want %s", tt.name, got, tt.want)
+		}
+		if !reflect.DeepEqual(args, tt.wantArgs) {
+			t.Errorf("%s: args = %#v, want %#v", tt.name, args, tt.wantArgs)
+		}
+	}
+}
+
+func TestBindParamsErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		query  string
+		params []interface{}
+		named  map[string]interface{}
+	}{
+		{"params and named_params", "SELECT $1, :a", []interface{}{1.0}, map[string]interface{}{"a": 1.0}},
+		{"no placeholders", "SELECT 1", nil, map[string]interface{}{"a": 1.0}},
+		{"placeholder without a value", "SELECT :a, :b", nil, map[string]interface{}{"a": 1.0}},
+		{"unused name", "SELECT :a", nil, map[string]interface{}{"a": 1.0, "b": 2.0}},
+		{"placeholder only in a literal", "SELECT ':a'", nil, map[string]interface{}{"a": 1.0}},
+		{"bad positional value", "SELECT $1", []interface{}{[]interface{}{1.0}}, nil},
+		{"bad named value", "SELECT :a", nil, map[string]interface{}{"a": map[string]interface{}{"type": "uuid", "value": "x"}}},
+	}
+	for _, tt := range tests {
+		if query, _, err := bindParams(dialect.Postgres{}, tt.query, tt.params, tt.named); err == nil {
+			t.Errorf("%s: bound as %s, want an error", tt.name, query)
+		}
+	}
+}
+
+func TestBindParamsPositional(t *testing.T) {
+	query, args, err := bindParams(dialect.MySQL{}, "SELECT ?, ?", []interface{}{"a", 2.0}, nil)
+	if err != nil {
+		t.Fatalf("bindParams: %v", err)
+	}
+	if query != "SELECT ?, ?" || !reflect.DeepEqual(args, []interface{}{"a", int64(2)}) {
+		t.Errorf("bindParams = %s %#v", query, args)
+	}
+}
+
+func TestConvertParam(t *testing.T) {
+	typed := func(typ string, value interface{}) map[string]interface{} {
+		return map[string]interface{}{"type": typ, "value": value}
+	}
+	tests := []struct {
+		name    string
+		value   interface{}
+		want    interface{}
+		wantErr bool
+	}{
+		{name: "string", value: "x", want: "x"},
+		{name: "bool", value: true, want: true},
+		{name: "null", value: nil, want: nil},
+		{name: "whole number", value: 42.0, want: int64(42)},
+		{name: "negative whole number", value: -3.0, want: int64(-3)},
+		{name: "fraction", value: 1.5, want: 1.5},
+		{name: "2^53", value: float64(1 << 53), want: int64(1 << 53)},
+		{name: "above 2^53 stays a float", value: float64(1<<53) * 4, want: float64(1<<53) * 4},
+		{name: "timestamp", value: typed("timestamp", "2024-03-01T12:30:00Z"), want: time.Date(2024, 3, 1, 12, 30, 0, 0, time.UTC)},
+		{name: "timestamp without zone", value: typed("timestamp", "2024-03-01 12:30:00.5"), want: time.Date(2024, 3, 1, 12, 30, 0, 5e8, time.UTC)},
+		{name: "date", value: typed("timestamp", "2024-03-01"), want: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)},
+		{name: "bad timestamp", value: typed("timestamp", "yesterday"), wantErr: true},
+		{name: "timestamp number", value: typed("timestamp", 1.0), wantErr: true},
+		{name: "bytes", value: typed("bytes", "AP8="), want: []byte{0x00, 0xff}},
+		{name: "bad bytes", value: typed("bytes", "not base64!"), wantErr: true},
+		{name: "int string", value: typed("int", "9223372036854775807"), want: int64(math.MaxInt64)},
+		{name: "int string out of range", value: typed("int", "9223372036854775808"), wantErr: true},
+		{name: "int number", value: typed("int", 12.0), want: int64(12)},
+		{name: "int fraction", value: typed("int", 1.5), wantErr: true},
+		{name: "int number above 2^53", value: typed("int", float64(1<<53)*4), wantErr: true},
+		{name: "typed null", value: typed("int", nil), want: nil},
+		{name: "typed without value", value: map[string]interface{}{"type": "int"}, wantErr: true},
+		{name: "unknown type", value: typed("uuid", "x"), wantErr: true},
+		{name: "array", value: []interface{}{1.0}, wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := convertParam(tt.value)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("%s: converted to %#v, want an error", tt.name, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: convertParam = %#v, want %#v", tt.name, got, tt.want)
+		}
+	}
+}
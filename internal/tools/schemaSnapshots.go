@@ -0,0 +1,337 @@
+package tools
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/AbdelilahOu/DBMcp/internal/catalog"
+	"github.com/AbdelilahOu/DBMcp/internal/client"
+	"github.com/AbdelilahOu/DBMcp/internal/config"
+	"github.com/AbdelilahOu/DBMcp/internal/dialect"
+	"github.com/AbdelilahOu/DBMcp/internal/logger"
+	"github.com/AbdelilahOu/DBMcp/internal/snapshot"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+type SnapshotSchemaInput struct {
+	Schema    string `json:"schema,omitempty" jsonschema_description:"Optional schema name (defaults to the connection's current schema)"`
+	TimeoutMs int    `json:"timeout_ms,omitempty" jsonschema_description:"Optional timeout in milliseconds, capped by the connection's max_query_timeout"`
+}
+
+type SnapshotSchemaOutput struct {
+	ID         string    `json:"id" jsonschema_description:"Snapshot ID, for schema_changes"`
+	Connection string    `json:"connection" jsonschema_description:"Connection the snapshot was taken on"`
+	Schema     string    `json:"schema" jsonschema_description:"Schema the snapshot holds"`
+	TakenAt    time.Time `json:"taken_at" jsonschema_description:"When the snapshot was taken"`
+	Created    bool      `json:"created" jsonschema_description:"False when the schema matched the latest snapshot, which is returned instead"`
+	Tables     int       `json:"tables" jsonschema_description:"Number of tables"`
+	Views      int       `json:"views" jsonschema_description:"Number of views"`
+	Routines   int       `json:"routines" jsonschema_description:"Number of functions and procedures"`
+	Message    string    `json:"message" jsonschema_description:"Success message"`
+}
+
+type SchemaChangesInput struct {
+	From      string `json:"from,omitempty" jsonschema_description:"Snapshot ID to compare from; defaults to the latest snapshot older than 'to', or the latest one taken at or before 'since'"`
+	Since     string `json:"since,omitempty" jsonschema_description:"Instead of 'from': an RFC 3339 time or a duration back from now such as 36h or 7d"`
+	To        string `json:"to,omitempty" jsonschema_description:"Snapshot ID to compare to; defaults to the live schema of the active connection"`
+	Schema    string `json:"schema,omitempty" jsonschema_description:"Optional schema name for the live side and snapshot lookups (defaults to the connection's current schema)"`
+	TimeoutMs int    `json:"timeout_ms,omitempty" jsonschema_description:"Optional timeout in milliseconds for reading the live schema, capped by the connection's max_query_timeout"`
+}
+
+// SchemaVersion is one side of a schema_changes comparison.
+type SchemaVersion struct {
+	ID         string    `json:"id,omitempty" jsonschema_description:"Snapshot ID; empty for the live schema"`
+	Connection string    `json:"connection" jsonschema_description:"Connection name"`
+	Schema     string    `json:"schema" jsonschema_description:"Schema name"`
+	TakenAt    time.Time `json:"taken_at" jsonschema_description:"When the snapshot was taken, or when the live schema was read"`
+}
+
+type SchemaChangesOutput struct {
+	From      SchemaVersion `json:"from" jsonschema_description:"Older side"`
+	To        SchemaVersion `json:"to" jsonschema_description:"Newer side"`
+	Identical bool          `json:"identical" jsonschema_description:"Whether nothing changed"`
+	Changes   catalog.Diff  `json:"changes" jsonschema_description:"Objects added, removed or changed between from and to; in field differences, source is the from value and target the to value"`
+	Message   string        `json:"message" jsonschema_description:"Summary message"`
+}
+
+type ListSnapshotsInput struct {
+	Connection string `json:"connection,omitempty" jsonschema_description:"Optional connection name; all connections when omitted"`
+	Schema     string `json:"schema,omitempty" jsonschema_description:"Optional schema name; all schemas when omitted"`
+	Limit      int    `json:"limit,omitempty" jsonschema_description:"Maximum number of snapshots to return, newest first (default 20)"`
+}
+
+type ListSnapshotsOutput struct {
+	Snapshots []snapshot.Info `json:"snapshots" jsonschema_description:"Snapshots, newest first"`
+	Total     int             `json:"total" jsonschema_description:"Number of matching snapshots in the store"`
+	Message   string          `json:"message" jsonschema_description:"Success message"`
+}
+
+func GetSnapshotSchemaTool(store *snapshot.Store) *ToolDefinition[SnapshotSchemaInput, SnapshotSchemaOutput] {
+	return NewToolDefinition[SnapshotSchemaInput, SnapshotSchemaOutput](
+		"snapshot_schema",
+		"Save the full catalog of a schema (tables, columns, indexes, constraints, views, routines) of the active connection to the snapshot store, for later comparison with schema_changes.",
+		func(ctx context.Context, req *mcp.CallToolRequest, input SnapshotSchemaInput) (*mcp.CallToolResult, SnapshotSchemaOutput, error) {
+			return snapshotSchemaHandler(ctx, req, input, store)
+		},
+	)
+}
+
+func GetSchemaChangesTool(store *snapshot.Store) *ToolDefinition[SchemaChangesInput, SchemaChangesOutput] {
+	return NewToolDefinition[SchemaChangesInput, SchemaChangesOutput](
+		"schema_changes",
+		"List what changed in a schema between two snapshots, or between a snapshot and the live database: tables, columns, indexes, constraints, views and routines added, removed or changed.",
+		func(ctx context.Context, req *mcp.CallToolRequest, input SchemaChangesInput) (*mcp.CallToolResult, SchemaChangesOutput, error) {
+			return schemaChangesHandler(ctx, req, input, store)
+		},
+	)
+}
+
+func GetListSnapshotsTool(store *snapshot.Store) *ToolDefinition[ListSnapshotsInput, ListSnapshotsOutput] {
+	return NewToolDefinition[ListSnapshotsInput, ListSnapshotsOutput](
+		"list_snapshots",
+		"List the schema snapshots in the snapshot store, newest first.",
+		func(ctx context.Context, req *mcp.CallToolRequest, input ListSnapshotsInput) (*mcp.CallToolResult, ListSnapshotsOutput, error) {
+			return listSnapshotsHandler(ctx, req, input, store)
+		},
+	)
+}
+
+func snapshotSchemaHandler(ctx context.Context, req *mcp.CallToolRequest, input SnapshotSchemaInput, store *snapshot.Store) (*mcp.CallToolResult, SnapshotSchemaOutput, error) {
+	sessionState, err := getActiveSession(req)
+	if err != nil {
+		return nil, SnapshotSchemaOutput{}, err
+	}
+
+	ctx, cancel, err := queryContext(ctx, sessionState, input.TimeoutMs)
+	if err != nil {
+		return nil, SnapshotSchemaOutput{}, err
+	}
+	defer cancel()
+
+	schema, err := resolveSchema(ctx, sessionState, input.Schema)
+	if err != nil {
+		return nil, SnapshotSchemaOutput{}, err
+	}
+
+	s, created, err := takeSnapshot(ctx, store, sessionState.ConnectionName, sessionState.Conn, sessionState.Dialect, schema)
+	if err != nil {
+		return nil, SnapshotSchemaOutput{}, err
+	}
+
+	output := SnapshotSchemaOutput{
+		ID:         s.ID,
+		Connection: s.Connection,
+		Schema:     s.Schema.Name,
+		TakenAt:    s.TakenAt,
+		Created:    created,
+		Tables:     len(s.Schema.Tables),
+		Views:      len(s.Schema.Views),
+		Routines:   len(s.Schema.Routines),
+	}
+	if created {
+		output.Message = fmt.Sprintf("Saved snapshot %s", s.ID)
+	} else {
+		output.Message = fmt.Sprintf("Schema unchanged since snapshot %s", s.ID)
+	}
+
+	jsonBytes, err := json.Marshal(output)
+	if err != nil {
+		return nil, SnapshotSchemaOutput{}, fmt.Errorf("JSON marshal error: %v", err)
+	}
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: string(jsonBytes)},
+		},
+	}, output, nil
+}
+
+func takeSnapshot(ctx context.Context, store *snapshot.Store, connectionName string, conn *sql.DB, d dialect.Dialect, schema string) (*snapshot.Snapshot, bool, error) {
+	s, err := catalog.Load(ctx, conn, d, schema)
+	if err != nil {
+		logger.LogDatabaseOperation("SNAPSHOT", connectionName+"."+schema, 0, err)
+		return nil, false, err
+	}
+
+	snap, created, err := store.Save(connectionName, s)
+	logger.LogDatabaseOperation("SNAPSHOT", connectionName+"."+schema, int64(len(s.Tables)), err)
+	return snap, created, err
+}
+
+// autoSnapshot snapshots the current schema of a connection that was just
+// opened, in the background so switch_connection does not wait on it.
+func autoSnapshot(store *snapshot.Store, name string, settings config.Settings, d dialect.Dialect, schema string) {
+	if schema == "" {
+		return
+	}
+	// Hold the pool even if the session switches away meanwhile.
+	db, ok := client.DefaultManager().Retain(name)
+	if !ok {
+		return
+	}
+
+	go func() {
+		defer client.DefaultManager().Release(name)
+
+		ctx, cancel, err := timeoutContext(context.Background(), settings, 0)
+		if err != nil {
+			logger.LogDatabaseOperation("AUTO_SNAPSHOT", name+"."+schema, 0, err)
+			return
+		}
+		defer cancel()
+
+		if _, _, err := takeSnapshot(ctx, store, name, db, d, schema); err != nil {
+			logger.LogDatabaseOperation("AUTO_SNAPSHOT", name+"."+schema, 0, err)
+		}
+	}()
+}
+
+func schemaChangesHandler(ctx context.Context, req *mcp.CallToolRequest, input SchemaChangesInput, store *snapshot.Store) (*mcp.CallToolResult, SchemaChangesOutput, error) {
+	if input.From != "" && input.Since != "" {
+		return nil, SchemaChangesOutput{}, fmt.Errorf("give either from or since, not both")
+	}
+
+	var to SchemaVersion
+	var toSchema *catalog.Schema
+	if input.To != "" {
+		s, err := store.Load(input.To)
+		if err != nil {
+			return nil, SchemaChangesOutput{}, err
+		}
+		to = SchemaVersion{ID: s.ID, Connection: s.Connection, Schema: s.Schema.Name, TakenAt: s.TakenAt}
+		toSchema = s.Schema
+	} else {
+		sessionState, err := getActiveSession(req)
+		if err != nil {
+			return nil, SchemaChangesOutput{}, err
+		}
+		ctx, cancel, err := queryContext(ctx, sessionState, input.TimeoutMs)
+		if err != nil {
+			return nil, SchemaChangesOutput{}, err
+		}
+		defer cancel()
+
+		schema, err := resolveSchema(ctx, sessionState, input.Schema)
+		if err != nil {
+			return nil, SchemaChangesOutput{}, err
+		}
+		toSchema, err = catalog.Load(ctx, sessionState.Conn, sessionState.Dialect, schema)
+		if err != nil {
+			logger.LogDatabaseOperation("SCHEMA_CHANGES", sessionState.ConnectionName+"."+schema, 0, err)
+			return nil, SchemaChangesOutput{}, err
+		}
+		to = SchemaVersion{Connection: sessionState.ConnectionName, Schema: schema, TakenAt: time.Now().UTC()}
+	}
+
+	fromID := input.From
+	if fromID == "" {
+		schema := to.Schema
+		if input.Schema != "" {
+			schema = input.Schema
+		}
+		before := to.TakenAt.Add(-time.Millisecond)
+		if input.Since != "" {
+			since, err := parseSince(input.Since)
+			if err != nil {
+				return nil, SchemaChangesOutput{}, err
+			}
+			before = since
+		}
+		info, err := store.Before(to.Connection, schema, before)
+		if err != nil {
+			return nil, SchemaChangesOutput{}, err
+		}
+		if info == nil {
+			return nil, SchemaChangesOutput{}, fmt.Errorf("no snapshot of %s.%s taken before %s; take one with snapshot_schema", to.Connection, schema, before.Format(time.RFC3339))
+		}
+		fromID = info.ID
+	}
+	from, err := store.Load(fromID)
+	if err != nil {
+		return nil, SchemaChangesOutput{}, err
+	}
+
+	changes := catalog.Changes(from.Schema, toSchema)
+	output := SchemaChangesOutput{
+		From:      SchemaVersion{ID: from.ID, Connection: from.Connection, Schema: from.Schema.Name, TakenAt: from.TakenAt},
+		To:        to,
+		Identical: changes.Empty(),
+		Changes:   *changes,
+	}
+	if output.Identical {
+		output.Message = fmt.Sprintf("No changes since %s", from.ID)
+	} else {
+		output.Message = fmt.Sprintf("%d tables, %d views and %d routines changed since %s",
+			len(changes.Tables), len(changes.Views), len(changes.Routines), from.ID)
+	}
+
+	logger.LogDatabaseOperation("SCHEMA_CHANGES", from.ID, int64(len(changes.Tables)+len(changes.Views)+len(changes.Routines)), nil)
+
+	jsonBytes, err := json.Marshal(output)
+	if err != nil {
+		return nil, SchemaChangesOutput{}, fmt.Errorf("JSON marshal error: %v", err)
+	}
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: string(jsonBytes)},
+		},
+	}, output, nil
+}
+
+// parseSince reads an RFC 3339 time, or a duration back from now; Go
+// durations are extended with a d suffix for days.
+func parseSince(since string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, since); err == nil {
+		return t, nil
+	}
+	if days, ok := strings.CutSuffix(since, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil && n >= 0 {
+			return time.Now().AddDate(0, 0, -n), nil
+		}
+	}
+	if d, err := time.ParseDuration(since); err == nil && d >= 0 {
+		return time.Now().Add(-d), nil
+	}
+	return time.Time{}, fmt.Errorf("since must be an RFC 3339 time or a duration such as 36h or 7d, got %q", since)
+}
+
+func listSnapshotsHandler(ctx context.Context, req *mcp.CallToolRequest, input ListSnapshotsInput, store *snapshot.Store) (*mcp.CallToolResult, ListSnapshotsOutput, error) {
+	if input.Limit < 0 {
+		return nil, ListSnapshotsOutput{}, fmt.Errorf("limit must not be negative")
+	}
+	limit := input.Limit
+	if limit == 0 {
+		limit = 20
+	}
+
+	infos, err := store.List(input.Connection, input.Schema)
+	if err != nil {
+		return nil, ListSnapshotsOutput{}, err
+	}
+
+	output := ListSnapshotsOutput{
+		Snapshots: make([]snapshot.Info, 0, min(limit, len(infos))),
+		Total:     len(infos),
+	}
+	for i := len(infos) - 1; i >= 0 && len(output.Snapshots) < limit; i-- {
+		output.Snapshots = append(output.Snapshots, infos[i])
+	}
+	output.Message = fmt.Sprintf("Showing %d of %d snapshots", len(output.Snapshots), len(infos))
+
+	jsonBytes, err := json.Marshal(output)
+	if err != nil {
+		return nil, ListSnapshotsOutput{}, fmt.Errorf("JSON marshal error: %v", err)
+	}
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: string(jsonBytes)},
+		},
+	}, output, nil
+}
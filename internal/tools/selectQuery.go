@@ -2,28 +2,40 @@ package tools
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
-	"strings"
-	"time"
 
+	"github.com/AbdelilahOu/DBMcp/internal/classifier"
+	"github.com/AbdelilahOu/DBMcp/internal/client"
+	"github.com/AbdelilahOu/DBMcp/internal/config"
 	"github.com/AbdelilahOu/DBMcp/internal/logger"
+	"github.com/AbdelilahOu/DBMcp/internal/render"
+	"github.com/AbdelilahOu/DBMcp/internal/resultset"
+	"github.com/AbdelilahOu/DBMcp/internal/state"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
 
 type SelectQueryInput struct {
-	Query string `json:"query" jsonschema:"required" jsonschema_description:"SELECT SQL query to execute"`
+	Query       string                 `json:"query,omitempty" jsonschema_description:"SELECT SQL query to execute (omit when passing cursor)"`
+	Params      []interface{}          `json:"params,omitempty" jsonschema_description:"Optional positional bind values for the dialect's placeholders ($1 on Postgres, ? on MySQL, ?1 on SQLite). Numbers, strings, booleans and null are passed as is; use {\"type\": \"timestamp\", \"value\": \"<ISO 8601>\"}, {\"type\": \"bytes\", \"value\": \"<base64>\"} or {\"type\": \"int\", \"value\": \"<digits>\"} for other types"`
+	NamedParams map[string]interface{} `json:"named_params,omitempty" jsonschema_description:"Optional bind values for :name placeholders, in the same forms as params. Cannot be combined with params"`
+	PageSize    int                    `json:"page_size,omitempty" jsonschema_description:"Optional number of rows per page (defaults to the connection's default_page_size, capped at max_page_size)"`
+	Cursor      string                 `json:"cursor,omitempty" jsonschema_description:"Cursor returned by a previous call; fetches the next page of that query"`
+	Format      string                 `json:"format,omitempty" jsonschema_description:"Text output format: json (default), markdown, csv or ndjson. The structured output is the same for every format"`
+	TimeoutMs   int                    `json:"timeout_ms,omitempty" jsonschema_description:"Optional timeout in milliseconds, capped by the connection's max_query_timeout"`
 }
 
 type SelectQueryOutput struct {
-	Data    []map[string]interface{} `json:"data" jsonschema_description:"Query results"`
-	Message string                   `json:"message" jsonschema_description:"Success message"`
+	Columns []resultset.Column `json:"columns" jsonschema_description:"Result columns, in order"`
+	Rows    [][]interface{}    `json:"rows" jsonschema_description:"Result rows; each row holds one value per column, in column order"`
+	HasMore bool               `json:"has_more" jsonschema_description:"Whether more rows follow this page"`
+	Cursor  string             `json:"cursor,omitempty" jsonschema_description:"Pass to select_query to fetch the next page; set only when has_more is true"`
+	Message string             `json:"message" jsonschema_description:"Success message"`
 }
 
 func GetSelectQueryTool() *ToolDefinition[SelectQueryInput, SelectQueryOutput] {
 	return NewToolDefinition[SelectQueryInput, SelectQueryOutput](
 		"select_query",
-		"Execute SELECT SQL queries and return result data.",
+		"Execute SELECT SQL queries and return result data one page at a time. When has_more is true, call again with the returned cursor for the next page.",
 		func(ctx context.Context, req *mcp.CallToolRequest, input SelectQueryInput) (*mcp.CallToolResult, SelectQueryOutput, error) {
 			return selectQueryHandler(ctx, req, input)
 		},
@@ -31,78 +43,147 @@ func GetSelectQueryTool() *ToolDefinition[SelectQueryInput, SelectQueryOutput] {
 }
 
 func selectQueryHandler(ctx context.Context, req *mcp.CallToolRequest, input SelectQueryInput) (*mcp.CallToolResult, SelectQueryOutput, error) {
-	sessionState, err := getActiveSession("default")
+	sessionState, err := getActiveSession(req)
 	if err != nil {
 		return nil, SelectQueryOutput{}, err
 	}
 
-	queryLower := strings.ToLower(strings.TrimSpace(input.Query))
-	if !strings.HasPrefix(queryLower, "select") {
-		return nil, SelectQueryOutput{}, fmt.Errorf("only SELECT queries are allowed")
+	format, err := render.ParseFormat(input.Format)
+	if err != nil {
+		return nil, SelectQueryOutput{}, err
 	}
 
-	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
-	defer cancel()
-
-	rows, err := sessionState.Conn.QueryContext(ctx, input.Query)
-
+	pageSize, err := pageSizeFor(sessionState.Settings, input.PageSize)
 	if err != nil {
-		logger.LogDatabaseOperation("SELECT", input.Query, 0, err)
-		return nil, SelectQueryOutput{}, fmt.Errorf("query execution error: %v", err)
+		return nil, SelectQueryOutput{}, err
 	}
-	defer rows.Close()
 
-	columns, err := rows.Columns()
+	ctx, cancel, err := queryContext(ctx, sessionState, input.TimeoutMs)
 	if err != nil {
-		return nil, SelectQueryOutput{}, fmt.Errorf("error getting columns: %v", err)
+		return nil, SelectQueryOutput{}, err
 	}
+	defer cancel()
 
-	var results []map[string]interface{}
-	for rows.Next() {
-		values := make([]interface{}, len(columns))
-		valuePtrs := make([]interface{}, len(columns))
-		for i := range columns {
-			valuePtrs[i] = &values[i]
+	var cursor *state.HeldCursor
+	if input.Cursor != "" {
+		var ok bool
+		cursor, ok = sessionState.TakeCursor(input.Cursor)
+		if !ok {
+			return nil, SelectQueryOutput{}, fmt.Errorf("cursor not found: it was read to the end, expired, or closed by switch_connection")
+		}
+		if input.PageSize > 0 {
+			cursor.PageSize = pageSize
+		}
+	} else {
+		if input.Query == "" {
+			return nil, SelectQueryOutput{}, fmt.Errorf("query is required unless cursor is given")
 		}
 
-		if err := rows.Scan(valuePtrs...); err != nil {
-			return nil, SelectQueryOutput{}, fmt.Errorf("error scanning row: %v", err)
+		stmt, err := classifier.ClassifySingle(sessionState.Dialect, input.Query)
+		if err != nil {
+			return nil, SelectQueryOutput{}, err
+		}
+		if stmt.Category != classifier.Read || !stmt.IsQuery() {
+			return nil, SelectQueryOutput{}, fmt.Errorf("only SELECT queries are allowed (got %s statement classified as %s)", stmt.Keyword, stmt.Category)
 		}
 
-		row := make(map[string]interface{})
-		for i, col := range columns {
-			val := values[i]
-			if b, ok := val.([]byte); ok {
-				row[col] = string(b)
-			} else {
-				row[col] = val
-			}
+		query, args, err := bindParams(sessionState.Dialect, input.Query, input.Params, input.NamedParams)
+		if err != nil {
+			return nil, SelectQueryOutput{}, err
+		}
+
+		cursor, err = sessionState.OpenCursor(func() (*client.Cursor, error) {
+			return client.OpenCursor(ctx, sessionState.Conn, sessionState.Dialect, query, args...)
+		}, input.Query, pageSize)
+		if err != nil {
+			logger.LogDatabaseOperation("SELECT", input.Query, 0, err)
+			return nil, SelectQueryOutput{}, err
 		}
-		results = append(results, row)
 	}
 
-	if err = rows.Err(); err != nil {
-		return nil, SelectQueryOutput{}, fmt.Errorf("error iterating rows: %v", err)
+	columns, results, hasMore, err := readPage(ctx, sessionState.Dialect.Name(), cursor)
+	if err != nil {
+		cursor.Close()
+		logger.LogDatabaseOperation("SELECT", cursor.Query, 0, err)
+		return nil, SelectQueryOutput{}, err
 	}
 
 	// Log successful database operation
-	logger.LogDatabaseOperation("SELECT", input.Query, int64(len(results)), nil)
-
-	message := fmt.Sprintf("SELECT query completed successfully (%d rows returned)", len(results))
+	logger.LogDatabaseOperation("SELECT", cursor.Query, int64(len(results)), nil)
 
 	output := SelectQueryOutput{
-		Data:    results,
-		Message: message,
+		Columns: columns,
+		Rows:    results,
+		HasMore: hasMore,
+		Message: fmt.Sprintf("SELECT query completed successfully (%d rows returned)", len(results)),
+	}
+	if hasMore {
+		if output.Cursor, err = sessionState.PutCursor(cursor); err != nil {
+			return nil, SelectQueryOutput{}, err
+		}
+		output.Message = fmt.Sprintf("SELECT query returned a page of %d rows; pass the cursor to fetch the next page", len(results))
+	} else {
+		cursor.Close()
 	}
 
-	jsonBytes, err := json.Marshal(output)
+	summary := output.Message
+	if output.Cursor != "" {
+		summary += "\ncursor: " + output.Cursor
+	}
+	result, err := renderedResult(format, output, render.Table{Columns: columnNames(columns), Rows: results}, summary)
 	if err != nil {
-		return nil, SelectQueryOutput{}, fmt.Errorf("JSON marshal error: %v", err)
+		return nil, SelectQueryOutput{}, err
 	}
+	return result, output, nil
+}
 
-	return &mcp.CallToolResult{
-		Content: []mcp.Content{
-			&mcp.TextContent{Text: string(jsonBytes)},
-		},
-	}, output, nil
+// pageSizeFor returns the requested page size, or the connection's default,
+// capped at its max_page_size.
+func pageSizeFor(settings config.Settings, requested int) (int, error) {
+	if requested < 0 {
+		return 0, fmt.Errorf("page_size must not be negative")
+	}
+
+	size := settings.DefaultPageSize
+	if size <= 0 {
+		size = config.DefaultPageSize
+	}
+	if requested > 0 {
+		size = requested
+	}
+	if max := settings.MaxPageSize; max > 0 && size > max {
+		size = max
+	}
+	return size, nil
+}
+
+// readPage reads the cursor's next page and reports whether rows remain.
+// The query is aborted if ctx ends first.
+func readPage(ctx context.Context, dbType string, cursor *state.HeldCursor) ([]resultset.Column, [][]interface{}, bool, error) {
+	stop := context.AfterFunc(ctx, cursor.Cancel)
+	defer stop()
+
+	scanner, err := resultset.NewScanner(cursor.Rows(), dbType)
+	if err != nil {
+		return nil, nil, false, err
+	}
+
+	results := make([][]interface{}, 0)
+	for len(results) < cursor.PageSize && cursor.Next() {
+		row, err := scanner.Scan()
+		if err != nil {
+			return nil, nil, false, err
+		}
+		results = append(results, row)
+	}
+	hasMore := cursor.HasMore()
+
+	if err := cursor.Rows().Err(); err != nil {
+		if ctx.Err() != nil {
+			err = ctx.Err()
+		}
+		return nil, nil, false, fmt.Errorf("error iterating rows: %v", err)
+	}
+
+	return scanner.Columns(), results, hasMore, nil
 }
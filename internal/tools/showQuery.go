@@ -2,22 +2,27 @@ package tools
 
 import (
 	"context"
-	"encoding/json"
+	"database/sql"
 	"fmt"
-	"strings"
-	"time"
 
+	"github.com/AbdelilahOu/DBMcp/internal/classifier"
+	"github.com/AbdelilahOu/DBMcp/internal/client"
 	"github.com/AbdelilahOu/DBMcp/internal/logger"
+	"github.com/AbdelilahOu/DBMcp/internal/render"
+	"github.com/AbdelilahOu/DBMcp/internal/resultset"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
 
 type ShowQueryInput struct {
-	Query string `json:"query" jsonschema:"required" jsonschema_description:"SHOW SQL query to execute (e.g., SHOW TABLES, SHOW DATABASES, SHOW COLUMNS, etc.)"`
+	Query     string `json:"query" jsonschema:"required" jsonschema_description:"SHOW SQL query to execute (e.g., SHOW TABLES, SHOW DATABASES, SHOW COLUMNS, etc.)"`
+	Format    string `json:"format,omitempty" jsonschema_description:"Text output format: json (default), markdown, csv or ndjson. The structured output is the same for every format"`
+	TimeoutMs int    `json:"timeout_ms,omitempty" jsonschema_description:"Optional timeout in milliseconds, capped by the connection's max_query_timeout"`
 }
 
 type ShowQueryOutput struct {
-	Data    []map[string]interface{} `json:"data" jsonschema_description:"Query results"`
-	Message string                   `json:"message" jsonschema_description:"Success message"`
+	Columns []resultset.Column `json:"columns" jsonschema_description:"Result columns, in order"`
+	Rows    [][]interface{}    `json:"rows" jsonschema_description:"Result rows; each row holds one value per column, in column order"`
+	Message string             `json:"message" jsonschema_description:"Success message"`
 }
 
 func GetShowQueryTool() *ToolDefinition[ShowQueryInput, ShowQueryOutput] {
@@ -31,58 +36,45 @@ func GetShowQueryTool() *ToolDefinition[ShowQueryInput, ShowQueryOutput] {
 }
 
 func showQueryHandler(ctx context.Context, req *mcp.CallToolRequest, input ShowQueryInput) (*mcp.CallToolResult, ShowQueryOutput, error) {
-	sessionState, err := getActiveSession("default")
+	sessionState, err := getActiveSession(req)
 	if err != nil {
 		return nil, ShowQueryOutput{}, err
 	}
 
-	queryLower := strings.ToLower(strings.TrimSpace(input.Query))
-	if !strings.HasPrefix(queryLower, "show") {
-		return nil, ShowQueryOutput{}, fmt.Errorf("only SHOW queries are allowed")
+	stmt, err := classifier.ClassifySingle(sessionState.Dialect, input.Query)
+	if err != nil {
+		return nil, ShowQueryOutput{}, err
+	}
+	if stmt.Category != classifier.Read || stmt.Keyword != "SHOW" {
+		return nil, ShowQueryOutput{}, fmt.Errorf("only SHOW queries are allowed (got %s statement classified as %s)", stmt.Keyword, stmt.Category)
 	}
 
-	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
-	defer cancel()
-
-	rows, err := sessionState.Conn.QueryContext(ctx, input.Query)
-
+	format, err := render.ParseFormat(input.Format)
 	if err != nil {
-		logger.LogDatabaseOperation("SHOW", input.Query, 0, err)
-		return nil, ShowQueryOutput{}, fmt.Errorf("query execution error: %v", err)
+		return nil, ShowQueryOutput{}, err
 	}
-	defer rows.Close()
 
-	columns, err := rows.Columns()
+	ctx, cancel, err := queryContext(ctx, sessionState, input.TimeoutMs)
 	if err != nil {
-		return nil, ShowQueryOutput{}, fmt.Errorf("error getting columns: %v", err)
+		return nil, ShowQueryOutput{}, err
 	}
+	defer cancel()
 
-	var results []map[string]interface{}
-	for rows.Next() {
-		values := make([]interface{}, len(columns))
-		valuePtrs := make([]interface{}, len(columns))
-		for i := range columns {
-			valuePtrs[i] = &values[i]
-		}
-
-		if err := rows.Scan(valuePtrs...); err != nil {
-			return nil, ShowQueryOutput{}, fmt.Errorf("error scanning row: %v", err)
-		}
-
-		row := make(map[string]interface{})
-		for i, col := range columns {
-			val := values[i]
-			if b, ok := val.([]byte); ok {
-				row[col] = string(b)
-			} else {
-				row[col] = val
-			}
+	var columns []resultset.Column
+	var results [][]interface{}
+	err = client.ReadOnlyTx(ctx, sessionState.Conn, sessionState.Dialect, func(tx *sql.Tx) error {
+		rows, err := tx.QueryContext(ctx, input.Query)
+		if err != nil {
+			return fmt.Errorf("query execution error: %v", err)
 		}
-		results = append(results, row)
-	}
+		defer rows.Close()
 
-	if err = rows.Err(); err != nil {
-		return nil, ShowQueryOutput{}, fmt.Errorf("error iterating rows: %v", err)
+		columns, results, err = resultset.ReadAll(rows, sessionState.Dialect.Name())
+		return err
+	})
+	if err != nil {
+		logger.LogDatabaseOperation("SHOW", input.Query, 0, err)
+		return nil, ShowQueryOutput{}, err
 	}
 
 	// Log successful database operation
@@ -91,18 +83,14 @@ func showQueryHandler(ctx context.Context, req *mcp.CallToolRequest, input ShowQ
 	message := fmt.Sprintf("SHOW query completed successfully (%d rows returned)", len(results))
 
 	output := ShowQueryOutput{
-		Data:    results,
+		Columns: columns,
+		Rows:    results,
 		Message: message,
 	}
 
-	jsonBytes, err := json.Marshal(output)
+	result, err := renderedResult(format, output, render.Table{Columns: columnNames(columns), Rows: results}, output.Message)
 	if err != nil {
-		return nil, ShowQueryOutput{}, fmt.Errorf("JSON marshal error: %v", err)
+		return nil, ShowQueryOutput{}, err
 	}
-
-	return &mcp.CallToolResult{
-		Content: []mcp.Content{
-			&mcp.TextContent{Text: string(jsonBytes)},
-		},
-	}, output, nil
-}
\ No newline at end of file
+	return result, output, nil
+}
@@ -0,0 +1,235 @@
+package tools
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/AbdelilahOu/DBMcp/internal/advisor"
+	"github.com/AbdelilahOu/DBMcp/internal/catalog"
+	"github.com/AbdelilahOu/DBMcp/internal/classifier"
+	"github.com/AbdelilahOu/DBMcp/internal/client"
+	"github.com/AbdelilahOu/DBMcp/internal/logger"
+	"github.com/AbdelilahOu/DBMcp/internal/plan"
+	"github.com/AbdelilahOu/DBMcp/internal/state"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+type SuggestIndexesInput struct {
+	Queries      []string `json:"queries" jsonschema:"required" jsonschema_description:"One or more SQL queries to find indexes for"`
+	Schema       string   `json:"schema,omitempty" jsonschema_description:"Optional schema of the tables the queries read (defaults to the connection's current schema)"`
+	Hypothetical bool     `json:"hypothetical,omitempty" jsonschema_description:"On Postgres with the hypopg extension, plan the queries again with each proposed index as a hypothetical index and compare costs"`
+	TimeoutMs    int      `json:"timeout_ms,omitempty" jsonschema_description:"Optional timeout in milliseconds, capped by the connection's max_query_timeout"`
+}
+
+type HypotheticalCheck struct {
+	Suggestion int     `json:"suggestion" jsonschema_description:"Number of the suggestion checked, from 1"`
+	Used       bool    `json:"used" jsonschema_description:"Whether the planner used the hypothetical index for any of the queries"`
+	CostBefore float64 `json:"cost_before" jsonschema_description:"Estimated cost of the suggestion's queries without the index"`
+	CostAfter  float64 `json:"cost_after" jsonschema_description:"Estimated cost of the suggestion's queries with the index"`
+}
+
+type SuggestIndexesOutput struct {
+	Suggestions []advisor.Suggestion `json:"suggestions" jsonschema_description:"Proposed indexes, with the reasoning behind each"`
+	Checks      []HypotheticalCheck  `json:"hypothetical_checks,omitempty" jsonschema_description:"Results of planning with each proposal as a hypothetical index, when asked for"`
+	Notes       []string             `json:"notes,omitempty" jsonschema_description:"Columns existing indexes already cover, and why checks were skipped"`
+	Message     string               `json:"message" jsonschema_description:"Summary message"`
+}
+
+func GetSuggestIndexesTool() *ToolDefinition[SuggestIndexesInput, SuggestIndexesOutput] {
+	return NewToolDefinition[SuggestIndexesInput, SuggestIndexesOutput](
+		"suggest_indexes",
+		"Propose indexes for one or more queries. Reads their plans (Postgres, MySQL) for filter, join and sort columns that full scans read without an index, leaves out what existing indexes cover, and returns CREATE INDEX statements with the reasoning. Optionally checks proposals with hypothetical indexes (Postgres with hypopg). Nothing is created.",
+		func(ctx context.Context, req *mcp.CallToolRequest, input SuggestIndexesInput) (*mcp.CallToolResult, SuggestIndexesOutput, error) {
+			return suggestIndexesHandler(ctx, req, input)
+		},
+	)
+}
+
+func suggestIndexesHandler(ctx context.Context, req *mcp.CallToolRequest, input SuggestIndexesInput) (*mcp.CallToolResult, SuggestIndexesOutput, error) {
+	sessionState, err := getActiveSession(req)
+	if err != nil {
+		return nil, SuggestIndexesOutput{}, err
+	}
+	if len(input.Queries) == 0 {
+		return nil, SuggestIndexesOutput{}, fmt.Errorf("at least one query is required")
+	}
+
+	ctx, cancel, err := queryContext(ctx, sessionState, input.TimeoutMs)
+	if err != nil {
+		return nil, SuggestIndexesOutput{}, err
+	}
+	defer cancel()
+
+	schema, err := resolveSchema(ctx, sessionState, input.Schema)
+	if err != nil {
+		return nil, SuggestIndexesOutput{}, err
+	}
+
+	queries := make([]advisor.Query, len(input.Queries))
+	for i, text := range input.Queries {
+		text, err := stripExplain(sessionState.Dialect, text, false)
+		if err != nil {
+			return nil, SuggestIndexesOutput{}, fmt.Errorf("query %d: %v", i+1, err)
+		}
+		stmt, err := classifier.ClassifySingle(sessionState.Dialect, text)
+		if err != nil {
+			return nil, SuggestIndexesOutput{}, fmt.Errorf("query %d: %v", i+1, err)
+		}
+		if stmt.Category != classifier.Read && stmt.Category != classifier.DML {
+			return nil, SuggestIndexesOutput{}, fmt.Errorf("query %d: only read and DML statements can be planned (got %s statement classified as %s)", i+1, stmt.Keyword, stmt.Category)
+		}
+
+		planText, _, err := explainPlan(ctx, sessionState, text, false, input.TimeoutMs)
+		if err != nil {
+			logger.LogDatabaseOperation("SUGGEST_INDEXES", text, 0, err)
+			return nil, SuggestIndexesOutput{}, fmt.Errorf("query %d: failed to explain query: %v", i+1, err)
+		}
+		nodes, err := plan.Parse(sessionState.Dialect.Name(), planText)
+		if err != nil {
+			return nil, SuggestIndexesOutput{}, fmt.Errorf("query %d: %v", i+1, err)
+		}
+		if err := setTableRows(ctx, sessionState, nodes); err != nil {
+			return nil, SuggestIndexesOutput{}, fmt.Errorf("query %d: failed to read table row estimates: %v", i+1, err)
+		}
+		queries[i] = advisor.Query{Text: text, Nodes: nodes}
+	}
+
+	tables := map[string]*advisor.Table{}
+	lookup := func(name string) (*advisor.Table, error) {
+		if t, ok := tables[name]; ok {
+			return t, nil
+		}
+		columns, err := catalog.Columns(ctx, sessionState.Conn, sessionState.Dialect, schema, name)
+		if err != nil {
+			return nil, err
+		}
+		var t *advisor.Table
+		if len(columns) > 0 {
+			indexes, err := catalog.Indexes(ctx, sessionState.Conn, sessionState.Dialect, schema, name)
+			if err != nil {
+				return nil, err
+			}
+			t = &advisor.Table{Columns: columns, Indexes: indexes}
+		}
+		tables[name] = t
+		return t, nil
+	}
+
+	advice, err := advisor.Suggest(sessionState.Dialect, schema, queries, lookup)
+	if err != nil {
+		logger.LogDatabaseOperation("SUGGEST_INDEXES", strings.Join(input.Queries, "; "), 0, err)
+		return nil, SuggestIndexesOutput{}, err
+	}
+
+	output := SuggestIndexesOutput{
+		Suggestions: advice.Suggestions,
+		Notes:       advice.Notes,
+	}
+	if input.Hypothetical && len(advice.Suggestions) > 0 {
+		checks, note, err := checkHypothetical(ctx, sessionState, advice.Suggestions, queries)
+		if err != nil {
+			return nil, SuggestIndexesOutput{}, fmt.Errorf("hypothetical index check failed: %v", err)
+		}
+		output.Checks = checks
+		if note != "" {
+			output.Notes = append(output.Notes, note)
+		}
+	}
+	logger.LogDatabaseOperation("SUGGEST_INDEXES", strings.Join(input.Queries, "; "), int64(len(advice.Suggestions)), nil)
+
+	switch len(advice.Suggestions) {
+	case 0:
+		output.Message = fmt.Sprintf("No missing indexes found for %d queries", len(queries))
+	default:
+		output.Message = fmt.Sprintf("%d indexes proposed for %d queries", len(advice.Suggestions), len(queries))
+	}
+
+	jsonBytes, err := json.Marshal(output)
+	if err != nil {
+		return nil, SuggestIndexesOutput{}, fmt.Errorf("JSON marshal error: %v", err)
+	}
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: string(jsonBytes)},
+		},
+	}, output, nil
+}
+
+// checkHypothetical plans each suggestion's queries again with the
+// suggestion as a hypothetical index. Hypothetical indexes belong to the
+// database session, so everything runs on one connection. When the
+// database cannot, it returns a note instead.
+func checkHypothetical(ctx context.Context, sessionState *state.DBSessionState, suggestions []advisor.Suggestion, queries []advisor.Query) ([]HypotheticalCheck, string, error) {
+	d := sessionState.Dialect
+	installed, create, reset := d.HypotheticalIndexQueries()
+	if installed == "" {
+		return nil, fmt.Sprintf("hypothetical index checks are not supported on %s", d.Name()), nil
+	}
+
+	conn, err := sessionState.Conn.Conn(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+	defer conn.Close()
+
+	var count int
+	if err := conn.QueryRowContext(ctx, installed).Scan(&count); err != nil {
+		return nil, "", err
+	}
+	if count == 0 {
+		return nil, "hypothetical index checks need the hypopg extension, which is not installed in this database", nil
+	}
+	defer conn.ExecContext(context.Background(), reset)
+
+	checks := make([]HypotheticalCheck, 0, len(suggestions))
+	for i, s := range suggestions {
+		if _, err := conn.ExecContext(ctx, reset); err != nil {
+			return nil, "", err
+		}
+		var name string
+		if err := conn.QueryRowContext(ctx, create, strings.TrimSuffix(s.Statement, ";")).Scan(&name); err != nil {
+			return nil, "", fmt.Errorf("suggestion %d: %v", i+1, err)
+		}
+
+		check := HypotheticalCheck{Suggestion: i + 1}
+		for _, q := range s.Queries {
+			query := queries[q-1]
+			if cost := query.Nodes[0].Cost; cost != nil {
+				check.CostBefore += *cost
+			}
+
+			// The query is only planned, but like explain_query it runs
+			// read-only so nothing appended to it can write.
+			var planText string
+			err := client.ReadOnlyConnTx(ctx, conn, d, func(tx *sql.Tx) error {
+				rows, err := tx.QueryContext(ctx, d.ExplainQuery(query.Text))
+				if err != nil {
+					return err
+				}
+				planText, _, err = readPlan(rows)
+				return err
+			})
+			if err != nil {
+				return nil, "", fmt.Errorf("query %d: %v", q, err)
+			}
+			nodes, err := plan.Parse(d.Name(), planText)
+			if err != nil {
+				return nil, "", fmt.Errorf("query %d: %v", q, err)
+			}
+			if cost := nodes[0].Cost; cost != nil {
+				check.CostAfter += *cost
+			}
+			for _, n := range nodes {
+				if n.Index == name {
+					check.Used = true
+				}
+			}
+		}
+		checks = append(checks, check)
+	}
+	return checks, "", nil
+}
@@ -3,6 +3,7 @@ package tools
 import (
 	"context"
 
+	"github.com/AbdelilahOu/DBMcp/internal/auth"
 	"github.com/AbdelilahOu/DBMcp/internal/logger"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
@@ -28,9 +29,17 @@ func NewToolDefinition[TInput, TOutput any](
 func (td *ToolDefinition[TInput, TOutput]) Register(s *mcp.Server) {
 	wrappedHandler := func(ctx context.Context, req *mcp.CallToolRequest, input TInput) (*mcp.CallToolResult, TOutput, error) {
 
+		var principalName string
+		if req.Extra != nil {
+			if principal := auth.PrincipalFromTokenInfo(req.Extra.TokenInfo); principal != nil {
+				ctx = auth.WithPrincipal(ctx, principal)
+				principalName = principal.Method + ":" + principal.Subject
+			}
+		}
+
 		result, output, err := td.Handler(ctx, req, input)
 
-		logger.LogToolCall(td.Tool.Name, input, output, err)
+		logger.LogToolCall(td.Tool.Name, principalName, input, output, err)
 
 		return result, output, err
 	}
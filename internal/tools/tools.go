@@ -1,50 +1,200 @@
 package tools
 
 import (
+	"context"
 	"fmt"
+	"time"
 
 	"github.com/AbdelilahOu/DBMcp/internal/config"
+	"github.com/AbdelilahOu/DBMcp/internal/render"
+	"github.com/AbdelilahOu/DBMcp/internal/resultset"
+	"github.com/AbdelilahOu/DBMcp/internal/snapshot"
 	"github.com/AbdelilahOu/DBMcp/internal/state"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
 
-func getActiveSession(sessionID string) (*state.DBSessionState, error) {
-	if sessionID == "" {
-		sessionID = "default"
+func sessionID(req *mcp.CallToolRequest) string {
+	if req != nil && req.Session != nil {
+		if id := req.Session.ID(); id != "" {
+			return id
+		}
 	}
+	return state.DefaultSessionID
+}
 
-	sessionState := state.GetSession(sessionID)
-	if sessionState == nil {
-		sessionState = state.GetOrCreateSession(sessionID, nil)
+// sessionFor returns the state of the MCP session that issued req. State for
+// a new session is released when its transport session ends.
+func sessionFor(req *mcp.CallToolRequest) *state.DBSessionState {
+	id := sessionID(req)
+	sessionState, created := state.GetOrCreateSession(id)
+	if created && id != state.DefaultSessionID {
+		go func(ss *mcp.ServerSession) {
+			ss.Wait()
+			state.OnDisconnect(id)
+		}(req.Session)
 	}
+	return sessionState
+}
+
+// getActiveSession returns a snapshot of the session that issued req, so the
+// handler reads its fields without racing switch_connection.
+func getActiveSession(req *mcp.CallToolRequest) (*state.DBSessionState, error) {
+	sessionState := sessionFor(req).Snapshot()
 
-	if sessionState.Conn == nil {
+	if sessionState.Conn == nil || sessionState.Dialect == nil {
 		return nil, fmt.Errorf("no active DB connection. Use switch_connection tool to connect to a database first")
 	}
 
 	return sessionState, nil
 }
 
+// requireAdmin fails unless the session's connection is configured with
+// admin.
+func requireAdmin(cfg *config.Config, sessionState *state.DBSessionState) error {
+	if cfg != nil {
+		if conn, ok := cfg.GetConnection(sessionState.ConnectionName); ok && conn.Admin {
+			return nil
+		}
+	}
+	return fmt.Errorf("connection '%s' does not allow admin operations; set \"admin\": true on it in the config", sessionState.ConnectionName)
+}
+
+// queryContext bounds ctx by the session's query_timeout, or by timeoutMs
+// when the caller gave one, capped at the connection's max_query_timeout.
+func queryContext(ctx context.Context, sessionState *state.DBSessionState, timeoutMs int) (context.Context, context.CancelFunc, error) {
+	return timeoutContext(ctx, sessionState.Settings, timeoutMs)
+}
+
+// timeoutContext is queryContext for a connection used outside a session.
+func timeoutContext(ctx context.Context, settings config.Settings, timeoutMs int) (context.Context, context.CancelFunc, error) {
+	if timeoutMs < 0 {
+		return nil, nil, fmt.Errorf("timeout_ms must not be negative")
+	}
+
+	timeout := time.Duration(settings.QueryTimeout)
+	if timeout <= 0 {
+		timeout = config.DefaultQueryTimeout
+	}
+	if timeoutMs > 0 {
+		timeout = time.Duration(timeoutMs) * time.Millisecond
+		if max := time.Duration(settings.MaxQueryTimeout); max > 0 && timeout > max {
+			timeout = max
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	return ctx, cancel, nil
+}
+
+// analyzeContext bounds ctx for a statement run by EXPLAIN ANALYZE: by the
+// connection's analyze_timeout, or by timeoutMs when that is shorter.
+func analyzeContext(ctx context.Context, settings config.Settings, timeoutMs int) (context.Context, context.CancelFunc, error) {
+	timeout := time.Duration(settings.AnalyzeTimeout)
+	if timeout <= 0 {
+		timeout = config.DefaultAnalyzeTimeout
+	}
+	return shortenedContext(ctx, timeout, timeoutMs)
+}
+
+// exportContext bounds ctx for export_query: by the connection's
+// export_timeout, or by timeoutMs when that is shorter.
+func exportContext(ctx context.Context, settings config.Settings, timeoutMs int) (context.Context, context.CancelFunc, error) {
+	timeout := time.Duration(settings.ExportTimeout)
+	if timeout <= 0 {
+		timeout = config.DefaultExportTimeout
+	}
+	return shortenedContext(ctx, timeout, timeoutMs)
+}
+
+// shortenedContext bounds ctx by timeout, or by timeoutMs when that is
+// shorter.
+func shortenedContext(ctx context.Context, timeout time.Duration, timeoutMs int) (context.Context, context.CancelFunc, error) {
+	if timeoutMs < 0 {
+		return nil, nil, fmt.Errorf("timeout_ms must not be negative")
+	}
+	if requested := time.Duration(timeoutMs) * time.Millisecond; requested > 0 && requested < timeout {
+		timeout = requested
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	return ctx, cancel, nil
+}
+
+// renderedResult builds a tool result whose text content is output rendered
+// in format. Other formats than JSON carry summary as a second text block so
+// the rendered data stays machine-readable.
+func renderedResult(format render.Format, output interface{}, table render.Table, summary string) (*mcp.CallToolResult, error) {
+	text, err := render.Render(format, output, table)
+	if err != nil {
+		return nil, err
+	}
+
+	content := []mcp.Content{&mcp.TextContent{Text: text}}
+	if format != render.JSON && summary != "" {
+		content = append(content, &mcp.TextContent{Text: summary})
+	}
+	return &mcp.CallToolResult{Content: content}, nil
+}
+
+func columnNames(columns []resultset.Column) []string {
+	names := make([]string, len(columns))
+	for i, c := range columns {
+		names[i] = c.Name
+	}
+	return names
+}
+
 func RegisterTools(s *mcp.Server, cfg *config.Config) {
 	// List Tables Tool
 	GetListTablesTool().Register(s)
 	// Describe Table Tool
 	GetDescribeTableTool().Register(s)
+	// ER Diagram Tool
+	GetGenerateERDiagramTool().Register(s)
+	// Join Path Tool
+	GetFindJoinPathTool().Register(s)
+	// DDL Tool
+	GetDDLTool().Register(s)
+	// Schema Diff Tool
+	GetDiffSchemasTool(cfg).Register(s)
+	// Schema Snapshot Tools (only if a snapshot directory is configured)
+	if cfg != nil && cfg.Snapshots.Dir != "" {
+		store := snapshot.NewStore(cfg.Snapshots.Dir)
+		GetSnapshotSchemaTool(store).Register(s)
+		GetSchemaChangesTool(store).Register(s)
+		GetListSnapshotsTool(store).Register(s)
+	}
 	// Get DB Info Tool
 	GetDbInfoTool().Register(s)
 	// Execute Query Tool (only if not read-only)
-	GetExecuteQueryTool().Register(s)
+	if cfg == nil || !cfg.ReadOnly {
+		GetExecuteQueryTool().Register(s)
+	}
 	// Select Query Tool
 	GetSelectQueryTool().Register(s)
+	// Export Query Tool (only if an export directory is configured)
+	if cfg != nil && cfg.Export.Dir != "" {
+		GetExportQueryTool(cfg.Export.Dir).Register(s)
+	}
 	// Show Query Tool
 	GetShowQueryTool().Register(s)
 	// Explain Query Tool
 	GetExplainQueryTool().Register(s)
+	// Index Advisor Tool
+	GetSuggestIndexesTool().Register(s)
+	// Top Queries Tool
+	GetTopQueriesTool(cfg).Register(s)
+	// Session Activity Tools (cancel_backend only if a connection allows admin)
+	GetActiveSessionsTool().Register(s)
+	if cfg != nil && cfg.HasAdminConnection() {
+		GetCancelBackendTool(cfg).Register(s)
+	}
 	// Connection Management Tools (always available)
 	GetListConnectionsTool(cfg).Register(s)
 	GetSwitchConnectionTool(cfg).Register(s)
 	GetTestConnectionTool(cfg).Register(s)
+	GetConnectionStatusTool().Register(s)
 	// Analyze Table Tool
 	GetAnalyzeTableTool().Register(s)
 }
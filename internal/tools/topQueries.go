@@ -0,0 +1,183 @@
+package tools
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/AbdelilahOu/DBMcp/internal/config"
+	"github.com/AbdelilahOu/DBMcp/internal/logger"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+type TopQueriesInput struct {
+	SortBy    string `json:"sort_by,omitempty" jsonschema_description:"Metric to rank by: total_time (default), mean_time, calls, rows or hit_ratio. hit_ratio ranks the lowest first, the others the highest"`
+	Limit     int    `json:"limit,omitempty" jsonschema_description:"Maximum number of queries to return (default 20, at most 100)"`
+	Reset     bool   `json:"reset,omitempty" jsonschema_description:"Reset the statistics after reading them, so the next call only sees what ran since. The reset clears the statistics of every database on the server and needs a connection configured with admin"`
+	TimeoutMs int    `json:"timeout_ms,omitempty" jsonschema_description:"Optional timeout in milliseconds, capped by the connection's max_query_timeout"`
+}
+
+type TopQuery struct {
+	ID          string   `json:"id" jsonschema_description:"Query ID (Postgres) or digest (MySQL)"`
+	Query       string   `json:"query" jsonschema_description:"Normalized query text, with constants replaced by placeholders"`
+	Calls       int64    `json:"calls" jsonschema_description:"Number of times the query ran"`
+	TotalTimeMs float64  `json:"total_time_ms" jsonschema_description:"Total execution time in milliseconds"`
+	MeanTimeMs  float64  `json:"mean_time_ms" jsonschema_description:"Mean execution time in milliseconds"`
+	Rows        int64    `json:"rows" jsonschema_description:"Total rows returned or affected"`
+	HitRatio    *float64 `json:"hit_ratio,omitempty" jsonschema_description:"Share of shared buffer reads found in cache, from 0 to 1 (Postgres)"`
+}
+
+type TopQueriesOutput struct {
+	Source  string     `json:"source" jsonschema_description:"Where the statistics come from"`
+	SortBy  string     `json:"sort_by" jsonschema_description:"Metric the queries are ranked by"`
+	Queries []TopQuery `json:"queries" jsonschema_description:"Queries, ranked"`
+	Reset   bool       `json:"reset" jsonschema_description:"Whether the statistics were reset after reading"`
+	Message string     `json:"message" jsonschema_description:"Summary message"`
+}
+
+const (
+	defaultTopQueries = 20
+	maxTopQueries     = 100
+)
+
+// topQueryMetrics are the sort_by values; true ranks the lowest first.
+var topQueryMetrics = map[string]bool{
+	"total_time": false,
+	"mean_time":  false,
+	"calls":      false,
+	"rows":       false,
+	"hit_ratio":  true,
+}
+
+func GetTopQueriesTool(cfg *config.Config) *ToolDefinition[TopQueriesInput, TopQueriesOutput] {
+	return NewToolDefinition[TopQueriesInput, TopQueriesOutput](
+		"top_queries",
+		"List the most expensive queries the database has recorded, from pg_stat_statements on Postgres or performance_schema on MySQL: normalized text, calls, total and mean time, rows and buffer cache hit ratio. On admin connections it can also reset the statistics, which clears them for the whole server, not just the current database.",
+		func(ctx context.Context, req *mcp.CallToolRequest, input TopQueriesInput) (*mcp.CallToolResult, TopQueriesOutput, error) {
+			return topQueriesHandler(ctx, req, input, cfg)
+		},
+	)
+}
+
+func topQueriesHandler(ctx context.Context, req *mcp.CallToolRequest, input TopQueriesInput, cfg *config.Config) (*mcp.CallToolResult, TopQueriesOutput, error) {
+	sessionState, err := getActiveSession(req)
+	if err != nil {
+		return nil, TopQueriesOutput{}, err
+	}
+	d := sessionState.Dialect
+
+	sortBy := strings.ToLower(input.SortBy)
+	if sortBy == "" {
+		sortBy = "total_time"
+	}
+	ascending, ok := topQueryMetrics[sortBy]
+	if !ok {
+		return nil, TopQueriesOutput{}, fmt.Errorf("unsupported sort_by %q: use total_time, mean_time, calls, rows or hit_ratio", input.SortBy)
+	}
+	limit := input.Limit
+	if limit < 0 {
+		return nil, TopQueriesOutput{}, fmt.Errorf("limit must not be negative")
+	}
+	if limit == 0 {
+		limit = defaultTopQueries
+	}
+	limit = min(limit, maxTopQueries)
+	if input.Reset {
+		// The reset is server-wide while the read is per database.
+		if err := requireAdmin(cfg, sessionState); err != nil {
+			return nil, TopQueriesOutput{}, fmt.Errorf("reset clears the statistics of the whole server: %v", err)
+		}
+		if sessionState.ReadOnly {
+			return nil, TopQueriesOutput{}, fmt.Errorf("the active connection is read-only; reset is disabled")
+		}
+	}
+
+	source, available, reset := d.StatementStatsQueries()
+	if source == "" {
+		return nil, TopQueriesOutput{}, fmt.Errorf("%s does not record statement statistics", d.Name())
+	}
+
+	ctx, cancel, err := queryContext(ctx, sessionState, input.TimeoutMs)
+	if err != nil {
+		return nil, TopQueriesOutput{}, err
+	}
+	defer cancel()
+
+	var version string
+	if err := sessionState.Conn.QueryRowContext(ctx, available).Scan(&version); err != nil {
+		return nil, TopQueriesOutput{}, fmt.Errorf("failed to check for %s: %v", source, err)
+	}
+	if version == "" {
+		return nil, TopQueriesOutput{}, fmt.Errorf("%s is not enabled on this database, so no statement statistics are recorded", source)
+	}
+	query := d.StatementStatsQuery(version)
+
+	column := sortBy
+	if column != "calls" && column != "rows" && column != "hit_ratio" {
+		column += "_ms"
+	}
+	direction := "DESC"
+	if ascending {
+		direction = "ASC"
+	}
+	column = d.QuoteIdentifier(column)
+	ranked := fmt.Sprintf("SELECT * FROM (%s) stats ORDER BY %s IS NULL, %s %s LIMIT %d", query, column, column, direction, limit)
+
+	rows, err := sessionState.Conn.QueryContext(ctx, ranked)
+	if err != nil {
+		logger.LogDatabaseOperation("TOP_QUERIES", source, 0, err)
+		return nil, TopQueriesOutput{}, fmt.Errorf("failed to read %s: %v", source, err)
+	}
+	defer rows.Close()
+
+	queries := make([]TopQuery, 0)
+	for rows.Next() {
+		var q TopQuery
+		var id, text sql.NullString
+		var hitRatio sql.NullFloat64
+		if err := rows.Scan(&id, &text, &q.Calls, &q.TotalTimeMs, &q.MeanTimeMs, &q.Rows, &hitRatio); err != nil {
+			return nil, TopQueriesOutput{}, fmt.Errorf("scan error: %v", err)
+		}
+		q.ID, q.Query = id.String, text.String
+		if hitRatio.Valid {
+			q.HitRatio = &hitRatio.Float64
+		}
+		queries = append(queries, q)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, TopQueriesOutput{}, fmt.Errorf("rows iteration error: %v", err)
+	}
+	rows.Close()
+
+	if input.Reset {
+		if _, err := sessionState.Conn.ExecContext(ctx, reset); err != nil {
+			logger.LogDatabaseOperation("TOP_QUERIES", reset, 0, err)
+			return nil, TopQueriesOutput{}, fmt.Errorf("failed to reset %s: %v", source, err)
+		}
+	}
+	logger.LogDatabaseOperation("TOP_QUERIES", source, int64(len(queries)), nil)
+
+	output := TopQueriesOutput{
+		Source:  source,
+		SortBy:  sortBy,
+		Queries: queries,
+		Reset:   input.Reset,
+		Message: fmt.Sprintf("%d queries from %s by %s", len(queries), source, strings.ReplaceAll(sortBy, "_", " ")),
+	}
+	if input.Reset {
+		output.Message += "; statistics reset"
+	}
+
+	jsonBytes, err := json.Marshal(output)
+	if err != nil {
+		return nil, TopQueriesOutput{}, fmt.Errorf("JSON marshal error: %v", err)
+	}
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: string(jsonBytes)},
+		},
+	}, output, nil
+}